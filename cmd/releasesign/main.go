@@ -0,0 +1,126 @@
+// releasesign 生成并签名 Release 清单
+//
+// 用法：
+//
+//	releasesign -genkey
+//	RELEASE_SIGNING_KEY=<base64 私钥种子> releasesign -version 2.1.0 -dir bin
+//
+// -genkey 输出新的密钥对：公钥写入 internal/service/keys/release-signing.pub，
+// 私钥种子保存到 CI 的 RELEASE_SIGNING_KEY 密钥中，不要提交到仓库
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/XgzK/intellijapp/internal/service"
+)
+
+func main() {
+	genKey := flag.Bool("genkey", false, "生成新的 ed25519 密钥对")
+	version := flag.String("version", "", "Release 版本号（不带 v 前缀）")
+	dir := flag.String("dir", "bin", "安装包所在目录，清单和签名也写入该目录")
+	flag.Parse()
+
+	if *genKey {
+		if err := generateKey(); err != nil {
+			fmt.Fprintln(os.Stderr, "生成密钥失败:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *version == "" {
+		fmt.Fprintln(os.Stderr, "必须指定 -version")
+		os.Exit(2)
+	}
+
+	if err := signRelease(strings.TrimPrefix(*version, "v"), *dir); err != nil {
+		fmt.Fprintln(os.Stderr, "签名失败:", err)
+		os.Exit(1)
+	}
+}
+
+// generateKey 输出新的公钥和私钥种子（均为 base64 编码）
+func generateKey() error {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	fmt.Println("public: ", base64.StdEncoding.EncodeToString(publicKey))
+	fmt.Println("private:", base64.StdEncoding.EncodeToString(privateKey.Seed()))
+	return nil
+}
+
+// signRelease 为目录中的所有安装包生成清单并使用 RELEASE_SIGNING_KEY 签名
+func signRelease(version, dir string) error {
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(os.Getenv("RELEASE_SIGNING_KEY")))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return fmt.Errorf("RELEASE_SIGNING_KEY 未设置或格式错误")
+	}
+	privateKey := ed25519.NewKeyFromSeed(seed)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	manifest := service.ReleaseManifest{SchemaVersion: 1, Version: version}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == service.ReleaseManifestName || name == service.ReleaseManifestSigName {
+			continue
+		}
+
+		sum, size, err := hashFile(filepath.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		manifest.Assets = append(manifest.Assets, service.ManifestAsset{Name: name, SHA256: sum, Size: size})
+	}
+	sort.Slice(manifest.Assets, func(i, j int) bool { return manifest.Assets[i].Name < manifest.Assets[j].Name })
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	signature := ed25519.Sign(privateKey, data)
+
+	if err := os.WriteFile(filepath.Join(dir, service.ReleaseManifestName), data, 0644); err != nil {
+		return err
+	}
+	sigText := base64.StdEncoding.EncodeToString(signature) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, service.ReleaseManifestSigName), []byte(sigText), 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("已签名 %d 个安装包\n", len(manifest.Assets))
+	return nil
+}
+
+// hashFile 计算文件的 SHA-256 和大小
+func hashFile(path string) (string, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), size, nil
+}
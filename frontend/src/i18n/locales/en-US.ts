@@ -123,4 +123,13 @@ export default {
     remindLater: 'Remind Later',
     checkingFailed: 'Failed to check for updates',
   },
+
+  optionsLost: {
+    title: 'IDE update replaced the configuration of {label}',
+    version: 'Current version {version}',
+    reapplied: 'Configuration re-applied automatically ({files} files)',
+    reapplyFailed: 'Automatic re-apply failed: {error}',
+    reapplyHint: 'Apply the configuration again, or enable automatic re-apply in settings',
+    restartRequired: 'The IDE is running; restart it for the change to take effect',
+  },
 }
@@ -118,4 +118,13 @@ export default {
     remindLater: '稍后提醒',
     checkingFailed: '检查更新失败',
   },
+
+  optionsLost: {
+    title: '{label} 的配置被 IDE 更新覆盖',
+    version: '当前版本 {version}',
+    reapplied: '已自动重新应用配置（{files} 个文件）',
+    reapplyFailed: '自动重新应用失败：{error}',
+    reapplyHint: '请重新应用配置，或在设置中开启自动重新应用',
+    restartRequired: 'IDE 正在运行，需重启后生效',
+  },
 }
@@ -0,0 +1,30 @@
+/**
+ * 安装相关的后端事件
+ * 后台监视发现 IDE 更新覆盖了 vmoptions 时，后端推送 installations:options-lost 事件
+ */
+
+import { Events } from '@wailsio/runtime'
+
+// 与 Go 端 EventManagedOptionsLost 保持一致
+export const EVENT_MANAGED_OPTIONS_LOST = 'installations:options-lost'
+
+export interface ManagedOptionsLost {
+  installationId: string
+  label: string
+  path: string
+  version: string
+  reapplied: boolean
+  files: number
+  error?: string
+  verifyNotice?: string
+  restartRequired?: Array<{ pid: number }>
+}
+
+/**
+ * 订阅配置被覆盖的事件，返回取消订阅函数
+ */
+export function onManagedOptionsLost(callback: (event: ManagedOptionsLost) => void): () => void {
+  return Events.On(EVENT_MANAGED_OPTIONS_LOST, (event: { data: ManagedOptionsLost }) => {
+    callback(event.data)
+  })
+}
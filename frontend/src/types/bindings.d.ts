@@ -15,6 +15,465 @@ declare module '../bindings/github.com/XgzK/intellijapp/internal/service/configs
     assets: AssetInfo[]
   }
 
+  export interface DownloadResult {
+    version: string
+    assetName: string
+    filePath: string
+    size: number
+    sha256: string
+    verified: boolean
+  }
+
+  export interface MirrorStatus {
+    url: string
+    kind: 'web' | 'api'
+    healthy: boolean
+    latencyMs: number
+    error?: string
+    checkedAt: string
+  }
+
+  export interface NetworkSettings {
+    webMirrors: string[]
+    apiMirrors: string[]
+    proxyMode: 'system' | 'none' | 'manual'
+    proxyUrl: string
+  }
+
+  export interface MirrorValidation {
+    url: string
+    kind: 'web' | 'api'
+    valid: boolean
+    version?: string
+    message: string
+  }
+
+  export interface AppSettings {
+    schemaVersion: number
+    general: {
+      language: '' | 'zh-CN' | 'en-US'
+      theme: '' | 'light' | 'dark'
+    }
+    paths: {
+      lastInstallPath: string
+      lastConfigPath: string
+      recentInstallPaths: string[]
+    }
+    update: {
+      checkOnStartup: boolean
+      skippedVersion: string
+    }
+    network: NetworkSettings
+    watcher: {
+      enabled: boolean
+      autoReapply: boolean
+    }
+    logging: {
+      level: LogLevel
+      format: '' | 'text' | 'json'
+    }
+    installations: SavedInstallation[]
+    relocations: RelocationRecord[]
+  }
+
+  export interface EffectiveOption {
+    option: string
+    key: string
+    source: 'env' | 'bin' | 'toolbox' | 'user' | 'productInfo'
+    file: string
+    line: number
+    overridden: boolean
+    overriddenBy?: string
+  }
+
+  export interface EffectiveOptions {
+    options: EffectiveOption[]
+    arguments: string[]
+    files: string[]
+    envVar?: string
+  }
+
+  export interface ManagedOptionsLost {
+    installationId: string
+    label: string
+    path: string
+    version: string
+    reapplied: boolean
+    files: number
+    error?: string
+    verifyNotice?: string
+    restartRequired?: RunningInstance[]
+  }
+
+  export type LogLevel = '' | 'debug' | 'info' | 'warn' | 'error'
+
+  export interface LogEntry {
+    time: string
+    level: 'DEBUG' | 'INFO' | 'WARN' | 'ERROR'
+    message: string
+    attrs?: Record<string, string>
+  }
+
+  export interface LogFilter {
+    level: LogLevel
+    query: string
+    limit: number
+  }
+
+  export interface DiagnosticsOptions {
+    outputPath: string
+    redactPaths: boolean
+    redactEnvValues: boolean
+    includeLogs: boolean
+    includeBackups: boolean
+  }
+
+  export interface DiagnosticsResult {
+    path: string
+    size: number
+    files: string[]
+  }
+
+  export interface JVMCheckResult {
+    ok: boolean
+    java: string
+    javaVersion: string
+    arguments: string[]
+    problems: string[]
+    flags: Record<string, string>
+    output: string
+    durationMs: number
+  }
+
+  export type FlagIssueSeverity = 'error' | 'warning'
+
+  export interface FlagIssue {
+    option: string
+    flag: string
+    file: string
+    line: number
+    severity: FlagIssueSeverity
+    problem: string
+  }
+
+  export interface FlagValidation {
+    valid: boolean
+    buildNumber: string
+    java: string
+    cached: boolean
+    checked: number
+    issues: FlagIssue[]
+  }
+
+  export type OptionRisk = 'low' | 'medium' | 'high'
+
+  export interface OptionInfo {
+    key: string
+    name: string
+    description: string
+    type: 'size' | 'int' | 'bool' | 'string' | 'flag'
+    default?: string
+    min?: string
+    max?: string
+    recommended?: string
+    risk: OptionRisk
+    docs: string
+    deprecatedIn?: number
+    removedIn?: number
+    replacement?: string
+  }
+
+  export interface OptionCatalog {
+    version: number
+    options: OptionInfo[]
+  }
+
+  export interface OptionDescription {
+    option: string
+    key: string
+    value: string
+    known: boolean
+    info?: OptionInfo
+    javaMajor?: number
+    deprecated: boolean
+    removed: boolean
+    warnings: string[]
+    catalogVersion: number
+  }
+
+  export type MemoryLevel = 'ok' | 'warning' | 'critical'
+
+  export interface InstallationMemory {
+    installationId: string
+    label: string
+    path: string
+    xmx: string
+    heapBytes: number
+    defaultHeap: boolean
+    running: boolean
+    residentBytes: number
+    suggestedXmx: string
+    suggestedBytes: number
+    error?: string
+  }
+
+  export interface MemoryAdvice {
+    level: MemoryLevel
+    totalBytes: number
+    availableBytes: number
+    heapBudgetBytes: number
+    configuredHeapBytes: number
+    runningHeapBytes: number
+    warnings: string[]
+    installations: InstallationMemory[]
+  }
+
+  export type PluginSource = 'bundled' | 'user'
+
+  export interface PluginInfo {
+    id: string
+    name: string
+    version: string
+    vendor: string
+    source: PluginSource
+    path: string
+    enabled: boolean
+    sinceBuild?: string
+    untilBuild?: string
+    compatible: boolean
+    error?: string
+  }
+
+  export interface PluginList {
+    configDir: string
+    pluginsDir: string
+    disabledFile: string
+    buildNumber: string
+    plugins: PluginInfo[]
+    unknownDisabled: string[]
+  }
+
+  export interface ProfileRuntime {
+    path: string
+    version: string
+    major: number
+    vendor: string
+    isJbr: boolean
+  }
+
+  export interface TuningProfile {
+    schemaVersion: number
+    productCode: string
+    buildNumber: string
+    exportedAt: string
+    vmOptions: string[]
+    properties: Record<string, string>
+    runtime?: ProfileRuntime
+  }
+
+  export interface ProfileExportResult {
+    path: string
+    profile: TuningProfile
+  }
+
+  export interface ProfileChange {
+    kind: 'vmoption' | 'property' | 'runtime'
+    key: string
+    current: string
+    value: string
+    action: 'add' | 'update' | 'unchanged' | 'skip'
+    warning?: string
+    dangerous: boolean
+  }
+
+  export interface ProfileImportResult {
+    applied: boolean
+    profile: TuningProfile
+    changes: ProfileChange[]
+    warnings: string[]
+  }
+
+  export interface DiscoveredInstallation {
+    path: string
+    productCode: string
+    productName: string
+    version: string
+    buildNumber: string
+  }
+
+  export interface SavedInstallation {
+    id: string
+    label: string
+    path: string
+    source: 'manual' | 'discovered'
+    productCode: string
+    productName: string
+    version: string
+    buildNumber: string
+    addedAt: string
+    managedConfigPath: string
+    status: 'ok' | 'moved' | 'missing' | 'invalid'
+    lastCheckedAt: string
+    previousPath?: string
+    previousVersion?: string
+    upgraded: boolean
+    vmOptionsReset: boolean
+    message?: string
+  }
+
+  export interface InstallationSelector {
+    ids: string[]
+    paths: string[]
+    productCode: string
+    all: boolean
+  }
+
+  export interface BatchItemResult {
+    id: string
+    label: string
+    path: string
+    success: boolean
+    files: number
+    error?: string
+    verifyNotice?: string
+    restartRequired?: RunningInstance[]
+  }
+
+  export interface RunningInstance {
+    pid: number
+    executable: string
+    commandLine: string
+    source: 'process' | 'lockFile'
+    lockFile?: string
+  }
+
+  export interface InstanceReport {
+    running: boolean
+    instances: RunningInstance[]
+    staleLocks: string[]
+  }
+
+  export interface BatchReport {
+    total: number
+    succeeded: number
+    failed: number
+    results: BatchItemResult[]
+    envWarning?: string
+  }
+
+  export interface EnvVarDefinition {
+    name: string
+    value: string
+    scope: 'user' | 'system'
+    source: 'shell' | 'pam' | 'environment.d' | 'desktop' | 'launchd' | 'registry'
+    file: string
+    line: number
+    targetPath: string
+    targetExists: boolean
+  }
+
+  export interface EnvVarInspection {
+    name: string
+    inProcess: boolean
+    processValue: string
+    user: EnvVarDefinition[]
+    system: EnvVarDefinition[]
+    effectiveValue: string
+    effectiveSource: '' | 'process' | 'user' | 'system'
+    targetPath: string
+    targetExists: boolean
+  }
+
+  export interface ProductDefinition {
+    code: string
+    name: string
+    launchers: string[]
+    vmOptionsFiles: string[]
+    envVars: string[]
+    configDirPrefix: string
+    toolboxId: string
+    custom: boolean
+  }
+
+  export interface PropertyEntry {
+    key: string
+    value: string
+    line: number
+  }
+
+  export interface PropertiesFile {
+    scope: 'bin' | 'user'
+    path: string
+    exists: boolean
+    entries: PropertyEntry[]
+  }
+
+  export type IDEDirectoryKind = 'config' | 'system' | 'plugins' | 'log'
+
+  export interface IDEDirectory {
+    kind: IDEDirectoryKind
+    property: string
+    path: string
+    custom: boolean
+    source: 'default' | 'bin' | 'user'
+    exists: boolean
+    size: number
+  }
+
+  export interface RelocationRequest {
+    kind: IDEDirectoryKind
+    target: string
+    move: boolean
+    scope: '' | 'bin' | 'user'
+  }
+
+  export interface RelocationRecord {
+    id: string
+    installPath: string
+    kind: IDEDirectoryKind
+    property: string
+    scope: 'bin' | 'user'
+    from: string
+    to: string
+    moved: boolean
+    hadPreviousValue: boolean
+    previousValue: string
+    files: number
+    bytes: number
+    createdAt: string
+    revertedAt?: string
+  }
+
+  export interface RelocationProgress {
+    kind: IDEDirectoryKind
+    phase: 'copy' | 'verify' | 'remove'
+    done: number
+    total: number
+    file: string
+  }
+
+  export interface JavaRuntime {
+    path: string
+    version: string
+    major: number
+    vendor: string
+    arch: string
+    isJbr: boolean
+    javaBinary: string
+    description: string
+  }
+
+  export interface RuntimeStatus {
+    bundled: JavaRuntime | null
+    selected: JavaRuntime | null
+    selectedPath: string
+    jdkFile: string
+    envVar: string
+    envOverride: string
+    requiredMajor: number
+    restartRequired?: RunningInstance[]
+  }
+
   export interface UpdateCheckResult {
     hasUpdate: boolean
     release: ReleaseInfo | null
@@ -27,4 +486,55 @@ declare module '../bindings/github.com/XgzK/intellijapp/internal/service/configs
   export function CheckForUpdates(): Promise<UpdateCheckResult>
   export function GetAccessibleGitHubMirror(): Promise<string>
   export function ConvertToAccessibleURL(originalURL: string): Promise<string>
+  export function DownloadUpdate(): Promise<DownloadResult>
+  export function CancelUpdateDownload(): Promise<void>
+  export function ApplyUpdate(): Promise<string>
+  export function GetMirrorStatus(): Promise<MirrorStatus[]>
+  export function RefreshMirrorStatus(): Promise<MirrorStatus[]>
+  export function GetNetworkSettings(): Promise<NetworkSettings>
+  export function UpdateNetworkSettings(settings: NetworkSettings): Promise<NetworkSettings>
+  export function GetSettings(): Promise<AppSettings>
+  export function UpdateSettings(settings: AppSettings): Promise<AppSettings>
+  export function ValidateMirror(kind: 'web' | 'api', mirror: string): Promise<MirrorValidation>
+  export function DiscoverInstallations(): Promise<DiscoveredInstallation[]>
+  export function ListInstallations(): Promise<SavedInstallation[]>
+  export function AddInstallation(path: string, label: string): Promise<SavedInstallation>
+  export function AddDiscoveredInstallations(): Promise<SavedInstallation[]>
+  export function RemoveInstallation(id: string): Promise<void>
+  export function RenameInstallation(id: string, label: string): Promise<SavedInstallation>
+  export function RefreshInstallations(): Promise<SavedInstallation[]>
+  export function SubmitPathsBatch(selector: InstallationSelector, configPath: string): Promise<BatchReport>
+  export function ClearConfigBatch(selector: InstallationSelector): Promise<BatchReport>
+  export function DetectEnvVarDefinitions(): Promise<EnvVarDefinition[]>
+  export function DisableEnvVarDefinitions(): Promise<string>
+  export function InspectEnvironment(): Promise<EnvVarInspection[]>
+  export function GetProductCatalog(): Promise<ProductDefinition[]>
+  export function ReloadProductCatalog(): Promise<ProductDefinition[]>
+  export function GetProperties(installPath: string): Promise<PropertiesFile[]>
+  export function SetProperties(installPath: string, scope: 'bin' | 'user', values: Record<string, string>, remove: string[]): Promise<PropertiesFile>
+  export function GetIDEDirectories(installPath: string): Promise<IDEDirectory[]>
+  export function RelocateDirectory(installPath: string, request: RelocationRequest): Promise<RelocationRecord>
+  export function ListRelocations(): Promise<RelocationRecord[]>
+  export function RevertRelocation(id: string): Promise<RelocationRecord>
+  export function ListRuntimes(): Promise<JavaRuntime[]>
+  export function GetRuntime(installPath: string): Promise<RuntimeStatus>
+  export function SetRuntime(installPath: string, jdkPath: string): Promise<RuntimeStatus>
+  export function ResetRuntime(installPath: string): Promise<RuntimeStatus>
+  export function GetRunningInstances(installPath: string): Promise<InstanceReport>
+  export function StartWatcher(): Promise<void>
+  export function StopWatcher(): Promise<void>
+  export function ResolveEffectiveOptions(installPath: string): Promise<EffectiveOptions>
+  export function GetRecentLogs(filter: LogFilter): Promise<LogEntry[]>
+  export function SetLogLevel(level: LogLevel): Promise<AppSettings>
+  export function GetLogDir(): Promise<string>
+  export function ExportDiagnostics(options: DiagnosticsOptions): Promise<DiagnosticsResult>
+  export function VerifyInstallation(installPath: string): Promise<JVMCheckResult>
+  export function ValidateJVMFlags(installPath: string): Promise<FlagValidation>
+  export function GetOptionCatalog(): Promise<OptionCatalog>
+  export function DescribeOption(option: string, installPath: string): Promise<OptionDescription>
+  export function GetMemoryAdvice(): Promise<MemoryAdvice>
+  export function ListPlugins(installPath: string): Promise<PluginList>
+  export function SetPluginsEnabled(installPath: string, ids: string[], enabled: boolean): Promise<PluginList>
+  export function ExportProfile(installPath: string, outputPath: string): Promise<ProfileExportResult>
+  export function ImportProfile(installPath: string, profilePath: string, apply: boolean, allowDangerous: boolean): Promise<ProfileImportResult>
 }
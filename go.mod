@@ -3,6 +3,7 @@ module github.com/XgzK/intellijapp
 go 1.25.3
 
 require (
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/wailsapp/wails/v3 v3.0.0-alpha.54
 	golang.org/x/sys v0.39.0
 )
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 批量操作时同时处理的安装数量上限
+const batchConcurrency = 4
+
+// InstallationSelector 选择批量操作的目标安装
+// IDs 和 Paths 指定具体安装；ProductCode（如 GO、IU）选择所有已保存的该产品安装；All 选择全部已保存的安装
+type InstallationSelector struct {
+	IDs         []string `json:"ids"`
+	Paths       []string `json:"paths"`
+	ProductCode string   `json:"productCode"`
+	All         bool     `json:"all"`
+}
+
+// BatchItemResult 保存单个安装的批量操作结果
+type BatchItemResult struct {
+	ID      string `json:"id"`
+	Label   string `json:"label"`
+	Path    string `json:"path"`
+	Success bool   `json:"success"`
+	Files   int    `json:"files"`
+	Error   string `json:"error,omitempty"`
+	// VerifyNotice 说明跳过 JVM 参数验证的原因，或修改前已存在的参数问题
+	VerifyNotice string `json:"verifyNotice,omitempty"`
+	// RestartRequired 列出需要重启才能使配置生效的运行实例
+	RestartRequired []RunningInstance `json:"restartRequired,omitempty"`
+}
+
+// BatchReport 汇总批量操作的结果，单个安装失败不会中断其他安装的处理
+type BatchReport struct {
+	Total      int               `json:"total"`
+	Succeeded  int               `json:"succeeded"`
+	Failed     int               `json:"failed"`
+	Results    []BatchItemResult `json:"results"`
+	EnvWarning string            `json:"envWarning,omitempty"`
+}
+
+// resolveSelector 将选择条件解析为目标安装列表，按路径去重并保持选择顺序
+func resolveSelector(selector InstallationSelector, saved []SavedInstallation) ([]BatchItemResult, error) {
+	var targets []BatchItemResult
+	add := func(item BatchItemResult) {
+		if !slices.ContainsFunc(targets, func(t BatchItemResult) bool { return t.Path == item.Path }) {
+			targets = append(targets, item)
+		}
+	}
+	fromSaved := func(inst SavedInstallation) BatchItemResult {
+		return BatchItemResult{ID: inst.ID, Label: inst.Label, Path: inst.Path}
+	}
+
+	for _, id := range selector.IDs {
+		index := slices.IndexFunc(saved, func(inst SavedInstallation) bool { return inst.ID == id })
+		if index < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInstallationNotFound, id)
+		}
+		add(fromSaved(saved[index]))
+	}
+
+	for _, path := range selector.Paths {
+		home := installationHome(path)
+		if home == "" {
+			continue
+		}
+		index := slices.IndexFunc(saved, func(inst SavedInstallation) bool { return inst.Path == home })
+		if index >= 0 {
+			add(fromSaved(saved[index]))
+		} else {
+			add(BatchItemResult{Label: home, Path: home})
+		}
+	}
+
+	code := strings.TrimSpace(selector.ProductCode)
+	for _, inst := range saved {
+		if selector.All || (code != "" && strings.EqualFold(inst.ProductCode, code)) {
+			add(fromSaved(inst))
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, ErrNoInstallationSelected
+	}
+	return targets, nil
+}
+
+// runBatch 以有限并发对每个目标安装执行操作，并汇总结果
+func (c *ConfigService) runBatch(targets []BatchItemResult, operation func(path string) (int, string, error)) BatchReport {
+	results := slices.Clone(targets)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchConcurrency)
+	for i := range results {
+		wg.Add(1)
+		go func(item *BatchItemResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			count, notice, err := operation(item.Path)
+			if err != nil {
+				item.Error = err.Error()
+				return
+			}
+			item.Success = true
+			item.Files = count
+			item.VerifyNotice = notice
+			_, item.RestartRequired = restartNotice(installationHome(item.Path))
+		}(&results[i])
+	}
+	wg.Wait()
+
+	report := BatchReport{Total: len(results), Results: results}
+	for _, item := range results {
+		if item.Success {
+			report.Succeeded++
+		} else {
+			report.Failed++
+		}
+	}
+	return report
+}
+
+// recordBatchPaths 将批量操作中成功的安装记录为最近使用的路径，configPath 为空时不修改最近的配置路径
+func (c *ConfigService) recordBatchPaths(report BatchReport, configPath string) {
+	for _, item := range report.Results {
+		if item.Success {
+			c.recordRecentPaths(sanitizePath(item.Path), configPath)
+		}
+	}
+}
+
+// SubmitPathsBatch 将同一配置目录应用到多个 IDE 安装
+func (c *ConfigService) SubmitPathsBatch(selector InstallationSelector, configPath string) (BatchReport, error) {
+	configPath = sanitizePath(configPath)
+	if configPath == "" {
+		return BatchReport{}, ErrEmptyPath
+	}
+	if err := validateConfigPath(configPath); err != nil {
+		c.logger.Error("配置路径验证失败", slog.Any("error", err))
+		return BatchReport{}, err
+	}
+
+	targets, err := resolveSelector(selector, c.currentSettings().Installations)
+	if err != nil {
+		return BatchReport{}, err
+	}
+
+	c.logger.Info("开始批量应用配置",
+		slog.Int("count", len(targets)),
+		slog.String("configPath", configPath))
+
+	// 环境变量是全局的，只需在批量操作开始前处理一次
+	envWarning, err := prepareJetBrainsEnvVars(c.envRoot, c.logger)
+	if err != nil {
+		c.logger.Warn("清除环境变量时出现警告", slog.Any("error", err))
+	}
+
+	normalizedConfigPath := filepath.ToSlash(configPath)
+	report := c.runBatch(targets, func(path string) (int, string, error) {
+		return c.applyManagedConfig(path, normalizedConfigPath, "处理")
+	})
+	report.EnvWarning = envWarning
+	c.recordBatchPaths(report, configPath)
+
+	c.logger.Info("批量应用配置完成",
+		slog.Int("succeeded", report.Succeeded),
+		slog.Int("failed", report.Failed))
+	return report, nil
+}
+
+// ClearConfigBatch 从多个 IDE 安装中移除添加的配置
+func (c *ConfigService) ClearConfigBatch(selector InstallationSelector) (BatchReport, error) {
+	targets, err := resolveSelector(selector, c.currentSettings().Installations)
+	if err != nil {
+		return BatchReport{}, err
+	}
+
+	c.logger.Info("开始批量清除配置", slog.Int("count", len(targets)))
+
+	report := c.runBatch(targets, func(path string) (int, string, error) {
+		return c.applyManagedConfig(path, "", "清除")
+	})
+
+	envWarning, err := prepareJetBrainsEnvVars(c.envRoot, c.logger)
+	if err != nil {
+		c.logger.Warn("清除环境变量时出现警告", slog.Any("error", err))
+	}
+	report.EnvWarning = envWarning
+	c.recordBatchPaths(report, "")
+
+	c.logger.Info("批量清除配置完成",
+		slog.Int("succeeded", report.Succeeded),
+		slog.Int("failed", report.Failed))
+	return report, nil
+}
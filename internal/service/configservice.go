@@ -1,19 +1,53 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sync"
+	"time"
 )
 
 // ConfigService 提供 IntelliJ 配置管理的路径验证工具
 // 优化：将大文件拆分为多个功能模块，遵循单一职责原则
 type ConfigService struct {
-	logger *slog.Logger
+	logger  *slog.Logger
+	emitter EventEmitter
+	mirrors *mirrorCache
+
+	settingsMu      sync.RWMutex
+	settings        AppSettings
+	settingsPath    string
+	settingsModTime time.Time
+
+	downloadMu     sync.Mutex
+	cancelMu       sync.Mutex
+	downloadCancel context.CancelFunc
+
+	watcherMu sync.Mutex
+	watcher   *vmOptionsWatcher
+
+	logLevel slog.LevelVar
+	logRing  *logRing
+	logDir   string
+	logFile  *rotatingWriter
+
+	flagCache *jvmFlagCache
+
+	// installLocks 按安装目录保存 *sync.Mutex，见 lockInstallation
+	installLocks sync.Map
+
+	// envRoot 为系统级环境变量来源文件（/etc 等）所在的根目录，为空时使用 /
+	envRoot string
+
+	// quit 通过应用的正常退出流程结束当前进程，自更新启动新版本后调用
+	quit func()
 }
 
 // Developer 保存开发者信息
@@ -36,11 +70,77 @@ type AboutInfo struct {
 // VMOptionsOperation 定义对 vmoptions 文件的操作
 type VMOptionsOperation func(filePath string) error
 
+// ServiceOption 定义 ConfigService 的可选配置项
+type ServiceOption func(*ConfigService)
+
+// WithEventEmitter 设置事件推送函数
+func WithEventEmitter(emitter EventEmitter) ServiceOption {
+	return func(c *ConfigService) {
+		c.emitter = emitter
+	}
+}
+
+// WithSettingsPath 指定设置文件路径，默认位于用户配置目录下
+func WithSettingsPath(path string) ServiceOption {
+	return func(c *ConfigService) {
+		c.settingsPath = path
+	}
+}
+
+// WithLogDir 指定日志文件目录，未设置时只输出到标准错误和内存
+func WithLogDir(dir string) ServiceOption {
+	return func(c *ConfigService) {
+		c.logDir = dir
+	}
+}
+
+// WithEnvRoot 指定扫描系统级环境变量定义时使用的根目录，测试中用于避免修改真实的 /etc
+// Windows 上环境变量保存在注册表中，该选项不起作用
+func WithEnvRoot(root string) ServiceOption {
+	return func(c *ConfigService) {
+		c.envRoot = root
+	}
+}
+
+// WithQuitFunc 设置退出应用的函数，自更新在启动新版本后通过它结束当前进程
+// 未设置时安装更新后不会自动重新启动
+func WithQuitFunc(quit func()) ServiceOption {
+	return func(c *ConfigService) {
+		c.quit = quit
+	}
+}
+
 // NewConfigService 构造一个准备好与 Wails 绑定的 ConfigService 实例
-func NewConfigService() *ConfigService {
-	return &ConfigService{
-		logger: slog.Default(),
+func NewConfigService(options ...ServiceOption) *ConfigService {
+	c := &ConfigService{
+		logger:   slog.Default(),
+		settings: defaultSettings(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+
+	if c.settingsPath == "" {
+		if path, err := settingsFilePath(); err == nil {
+			c.settingsPath = path
+		} else {
+			c.logger.Warn("无法确定设置文件路径", slog.Any("error", err))
+		}
+	}
+	c.initSettings()
+	c.initLogging(c.settings.Logging)
+
+	flagCacheDir, err := jvmFlagCacheDir()
+	if err != nil {
+		c.logger.Warn("JVM 参数表只缓存在内存中", slog.Any("error", err))
 	}
+	c.flagCache = newJVMFlagCache(flagCacheDir)
+
+	c.mirrors = newMirrorCache(mirrorCacheTTL, &http.Client{
+		Transport: newTransport(c.settings.Network),
+		Timeout:   mirrorProbeTimeout,
+	})
+	return c
 }
 
 // processVMOptionsFilesGeneric 通用的 vmoptions 文件处理流程
@@ -106,9 +206,9 @@ func (c *ConfigService) SubmitPaths(projectPath, configPath string) (string, err
 		return "", err
 	}
 
-	// 先清除已有的环境变量，避免旧配置干扰
+	// 先处理已有的环境变量，避免旧配置干扰：Windows 上删除，其他平台只提示定义位置
 	c.logger.Info("开始清除旧的环境变量")
-	envWarningMsg, err := removeJetBrainsEnvVars(c.logger)
+	envWarningMsg, err := prepareJetBrainsEnvVars(c.envRoot, c.logger)
 	if err != nil {
 		c.logger.Warn("清除环境变量时出现警告", slog.Any("error", err))
 		// 环境变量清除失败不影响整体操作，继续执行
@@ -118,22 +218,25 @@ func (c *ConfigService) SubmitPaths(projectPath, configPath string) (string, err
 	normalizedConfigPath := filepath.ToSlash(configPath)
 
 	// 处理 vmoptions 文件
-	operation := func(vmFile string) error {
-		return processVMOptionsFile(vmFile, normalizedConfigPath, c.logger)
-	}
-
-	processedCount, err := c.processVMOptionsFilesGeneric(projectPath, operation, "处理")
+	processedCount, verifyNotice, err := c.applyManagedConfig(projectPath, normalizedConfigPath, "处理")
 	if err != nil {
 		return "", err
 	}
 
 	c.logger.Info("配置应用成功", slog.Int("processedCount", processedCount))
+	c.recordRecentPaths(sanitizePath(projectPath), configPath)
 
 	// 构建返回消息
 	resultMsg := fmt.Sprintf("配置成功应用到 %d 个文件, 请重启需要激活编译器输入激活码", processedCount)
 	if envWarningMsg != "" {
 		resultMsg += "\n⚠️ " + envWarningMsg
 	}
+	if verifyNotice != "" {
+		resultMsg += "\n⚠️ " + verifyNotice
+	}
+	if notice, _ := restartNotice(installationHome(projectPath)); notice != "" {
+		resultMsg += "\n⚠️ " + notice
+	}
 
 	return resultMsg, nil
 }
@@ -143,17 +246,13 @@ func (c *ConfigService) ClearConfig(projectPath string) (string, error) {
 	c.logger.Info("开始清除配置", slog.String("intellijPath", projectPath))
 
 	// 处理 vmoptions 文件
-	operation := func(vmFile string) error {
-		return clearVMOptionsFile(vmFile, c.logger)
-	}
-
-	clearedCount, err := c.processVMOptionsFilesGeneric(projectPath, operation, "清除")
+	clearedCount, verifyNotice, err := c.applyManagedConfig(projectPath, "", "清除")
 	if err != nil {
 		return "", err
 	}
 
 	// 清除环境变量
-	warningMsg, err := removeJetBrainsEnvVars(c.logger)
+	warningMsg, err := prepareJetBrainsEnvVars(c.envRoot, c.logger)
 	if err != nil {
 		c.logger.Warn("清除环境变量时出现警告", slog.Any("error", err))
 		// 环境变量清除失败不影响整体操作，继续执行
@@ -166,6 +265,12 @@ func (c *ConfigService) ClearConfig(projectPath string) (string, error) {
 	if warningMsg != "" {
 		resultMsg += "\n⚠️ " + warningMsg
 	}
+	if verifyNotice != "" {
+		resultMsg += "\n⚠️ " + verifyNotice
+	}
+	if notice, _ := restartNotice(installationHome(projectPath)); notice != "" {
+		resultMsg += "\n⚠️ " + notice
+	}
 
 	return resultMsg, nil
 }
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DiagnosticsOptions 控制诊断包的内容和脱敏方式
+type DiagnosticsOptions struct {
+	// OutputPath 为空时写入用户的下载目录（不存在时为主目录）
+	OutputPath string `json:"outputPath"`
+	// RedactPaths 将主目录替换为 ~，并隐藏用户名和主机名
+	RedactPaths bool `json:"redactPaths"`
+	// RedactEnvValues 隐藏环境变量的值，只保留变量名和来源文件
+	RedactEnvValues bool `json:"redactEnvValues"`
+	IncludeLogs     bool `json:"includeLogs"`
+	IncludeBackups  bool `json:"includeBackups"`
+}
+
+// DiagnosticsResult 描述生成的诊断包
+type DiagnosticsResult struct {
+	Path  string   `json:"path"`
+	Size  int64    `json:"size"`
+	Files []string `json:"files"`
+}
+
+// diagnosticsSystemInfo 对应诊断包中的 system.json
+type diagnosticsSystemInfo struct {
+	App       AboutInfo `json:"app"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	OSRelease string    `json:"osRelease,omitempty"`
+	CPUs      int       `json:"cpus"`
+	Hostname  string    `json:"hostname"`
+	CreatedAt string    `json:"createdAt"`
+}
+
+// diagnosticsInstallation 对应诊断包中 installations.json 的一项
+type diagnosticsInstallation struct {
+	Path        string             `json:"path"`
+	Saved       *SavedInstallation `json:"saved,omitempty"`
+	ProductInfo *productInfo       `json:"productInfo,omitempty"`
+	Error       string             `json:"error,omitempty"`
+	Directory   string             `json:"directory"`
+}
+
+// unsafeNamePattern 匹配不适合作为诊断包内目录名的字符
+var unsafeNamePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// diagnosticsBundle 向 zip 写入条目，所有文本内容按需脱敏
+type diagnosticsBundle struct {
+	zw     *zip.Writer
+	redact func(string) string
+	files  []string
+}
+
+// addBytes 写入一个条目
+func (b *diagnosticsBundle) addBytes(name string, data []byte) error {
+	w, err := b.zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	if _, err := w.Write([]byte(b.redact(string(data)))); err != nil {
+		return fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	b.files = append(b.files, name)
+	return nil
+}
+
+// addJSON 以缩进 JSON 写入一个条目
+func (b *diagnosticsBundle) addJSON(name string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化 %s 失败: %w", name, err)
+	}
+	return b.addBytes(name, data)
+}
+
+// addFile 复制磁盘上的文件，文件不存在时跳过
+func (b *diagnosticsBundle) addFile(name, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return b.addBytes(name+".error.txt", []byte(err.Error()))
+	}
+	return b.addBytes(name, data)
+}
+
+// newDiagnosticsRedactor 创建脱敏函数：主目录替换为 ~，用户名和主机名替换为占位符
+func newDiagnosticsRedactor(enabled bool) func(string) string {
+	if !enabled {
+		return func(s string) string { return s }
+	}
+	home, _ := os.UserHomeDir()
+	paths := newHomeRedactor(home)
+
+	// 用户名和主机名只按完整单词替换，避免误伤包含相同片段的其他内容
+	type replacement struct {
+		pattern     *regexp.Regexp
+		placeholder string
+	}
+	var names []replacement
+	if u, err := user.Current(); err == nil {
+		// Windows 上用户名为 DOMAIN\name
+		name := u.Username[strings.LastIndex(u.Username, `\`)+1:]
+		if len(name) > 2 {
+			names = append(names, replacement{regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`), "<user>"})
+		}
+	}
+	if host, err := os.Hostname(); err == nil && len(host) > 2 {
+		names = append(names, replacement{regexp.MustCompile(`\b` + regexp.QuoteMeta(host) + `\b`), "<host>"})
+	}
+	return func(s string) string {
+		s = paths.redact(s)
+		for _, r := range names {
+			s = r.pattern.ReplaceAllString(s, r.placeholder)
+		}
+		return s
+	}
+}
+
+// stripURLCredentials 去掉代理地址中的用户名和密码，无论是否启用脱敏
+func stripURLCredentials(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.User == nil {
+		return raw
+	}
+	parsed.User = url.User("***")
+	return parsed.String()
+}
+
+// osRelease 返回 Linux 发行版名称，其他平台返回空字符串
+func osRelease() string {
+	data, err := os.ReadFile("/etc/os-release")
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "PRETTY_NAME="); ok {
+			return strings.Trim(value, `"`)
+		}
+	}
+	return ""
+}
+
+// defaultDiagnosticsPath 返回诊断包的默认保存位置
+func defaultDiagnosticsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取主目录: %w", err)
+	}
+	dir := filepath.Join(home, "Downloads")
+	if _, err := os.Stat(dir); err != nil {
+		dir = home
+	}
+	name := fmt.Sprintf("%s-diagnostics-%s.zip", appDataDirName, time.Now().Format("20060102-150405"))
+	return filepath.Join(dir, name), nil
+}
+
+// addInstallationFiles 写入一个安装的 vmoptions、idea.properties（及其备份）和解析后的 JVM 参数
+func (c *ConfigService) addInstallationFiles(b *diagnosticsBundle, dir, home string, includeBackups bool) error {
+	var files []string
+	if binDir, err := validateIntelliJPath(home); err == nil {
+		if vmFiles, err := findVMOptionsFiles(binDir); err == nil {
+			files = append(files, vmFiles...)
+		}
+	}
+	for _, scope := range []string{PropertiesScopeBin, PropertiesScopeUser} {
+		if path, err := propertiesFilePath(home, scope); err == nil {
+			files = append(files, path)
+		}
+	}
+	if effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS); err == nil {
+		for _, file := range effective.Files {
+			if !strings.HasPrefix(file, filepath.Join(home, "bin")) {
+				files = append(files, file)
+			}
+		}
+		if err := b.addJSON(dir+"effective-options.json", effective); err != nil {
+			return err
+		}
+	}
+
+	for i, file := range files {
+		// 不同位置可能有同名文件，加序号区分
+		prefix := fmt.Sprintf("%s%02d-", dir, i+1)
+		if err := b.addFile(prefix+filepath.Base(file), file); err != nil {
+			return err
+		}
+		if !includeBackups {
+			continue
+		}
+		for _, backup := range listBackups(file) {
+			if err := b.addFile(prefix+"backups/"+filepath.Base(backup), backup); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportDiagnostics 将应用日志、设置、IDE 安装信息、vmoptions/idea.properties、环境变量检查结果和系统信息打包为 zip
+// 用于在提交问题时附加，脱敏方式由 options 控制
+func (c *ConfigService) ExportDiagnostics(options DiagnosticsOptions) (DiagnosticsResult, error) {
+	output := sanitizePath(options.OutputPath)
+	if output == "" {
+		path, err := defaultDiagnosticsPath()
+		if err != nil {
+			return DiagnosticsResult{}, err
+		}
+		output = path
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".diagnostics-*.zip")
+	if err != nil {
+		return DiagnosticsResult{}, fmt.Errorf("创建诊断包失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	bundle := &diagnosticsBundle{zw: zip.NewWriter(tmp), redact: newDiagnosticsRedactor(options.RedactPaths)}
+	if err := c.writeDiagnostics(bundle, options); err != nil {
+		tmp.Close()
+		return DiagnosticsResult{}, err
+	}
+	if err := bundle.zw.Close(); err != nil {
+		tmp.Close()
+		return DiagnosticsResult{}, fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return DiagnosticsResult{}, fmt.Errorf("写入诊断包失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), output); err != nil {
+		return DiagnosticsResult{}, fmt.Errorf("保存诊断包失败: %w", err)
+	}
+
+	result := DiagnosticsResult{Path: output, Files: bundle.files}
+	if info, err := os.Stat(output); err == nil {
+		result.Size = info.Size()
+	}
+	c.logger.Info("诊断包已导出", slog.String("path", output), slog.Int("files", len(bundle.files)))
+	return result, nil
+}
+
+// writeDiagnostics 依次写入诊断包的各部分
+func (c *ConfigService) writeDiagnostics(b *diagnosticsBundle, options DiagnosticsOptions) error {
+	hostname, _ := os.Hostname()
+	system := diagnosticsSystemInfo{
+		App:       c.GetAboutInfo(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		OSRelease: osRelease(),
+		CPUs:      runtime.NumCPU(),
+		Hostname:  hostname,
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := b.addJSON("system.json", system); err != nil {
+		return err
+	}
+
+	settings := c.currentSettings()
+	settings.Network.ProxyURL = stripURLCredentials(settings.Network.ProxyURL)
+	if err := b.addJSON("settings.json", settings); err != nil {
+		return err
+	}
+
+	// 已保存的安装和扫描到的安装合并，按路径去重
+	var installs []diagnosticsInstallation
+	seen := make(map[string]bool)
+	for _, inst := range settings.Installations {
+		saved := inst
+		installs = append(installs, diagnosticsInstallation{Path: inst.Path, Saved: &saved})
+		seen[inst.Path] = true
+	}
+	for _, found := range c.DiscoverInstallations() {
+		if !seen[found.Path] {
+			installs = append(installs, diagnosticsInstallation{Path: found.Path})
+			seen[found.Path] = true
+		}
+	}
+	for i := range installs {
+		inst := &installs[i]
+		name := filepath.Base(inst.Path)
+		if info, err := readProductInfo(inst.Path); err == nil {
+			inst.ProductInfo = info
+			name = info.ProductCode + "-" + info.BuildNumber
+		} else {
+			inst.Error = err.Error()
+		}
+		inst.Directory = fmt.Sprintf("installations/%02d-%s/", i+1, unsafeNamePattern.ReplaceAllString(name, "_"))
+		if err := c.addInstallationFiles(b, inst.Directory, inst.Path, options.IncludeBackups); err != nil {
+			return err
+		}
+	}
+	if err := b.addJSON("installations.json", installs); err != nil {
+		return err
+	}
+
+	inspection := c.InspectEnvironment()
+	if options.RedactEnvValues {
+		for i := range inspection {
+			inspection[i].ProcessValue = redactedValue(inspection[i].ProcessValue)
+			inspection[i].EffectiveValue = redactedValue(inspection[i].EffectiveValue)
+			inspection[i].TargetPath = redactedValue(inspection[i].TargetPath)
+			for _, defs := range [][]EnvVarDefinition{inspection[i].User, inspection[i].System} {
+				for j := range defs {
+					defs[j].Value = redactedValue(defs[j].Value)
+					defs[j].TargetPath = redactedValue(defs[j].TargetPath)
+				}
+			}
+		}
+	}
+	if err := b.addJSON("environment.json", inspection); err != nil {
+		return err
+	}
+
+	if !options.IncludeLogs {
+		return nil
+	}
+	recent, _ := c.GetRecentLogs(LogFilter{})
+	if err := b.addJSON("logs/recent.json", recent); err != nil {
+		return err
+	}
+	if dir := c.GetLogDir(); dir != "" {
+		matches, _ := filepath.Glob(filepath.Join(globEscape(dir), logFileName+"*"))
+		for _, path := range matches {
+			if err := b.addFile("logs/"+filepath.Base(path), path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// redactedValue 将非空值替换为占位符
+func redactedValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "<redacted>"
+}
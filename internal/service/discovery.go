@@ -0,0 +1,120 @@
+package service
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// 扫描安装目录时的最大深度（Toolbox 旧版目录结构为 apps/<产品>/ch-0/<构建号>）
+const discoveryMaxDepth = 4
+
+// DiscoveredInstallation 保存自动发现的 IDE 安装
+type DiscoveredInstallation struct {
+	Path        string `json:"path"`
+	ProductCode string `json:"productCode"`
+	ProductName string `json:"productName"`
+	Version     string `json:"version"`
+	BuildNumber string `json:"buildNumber"`
+}
+
+// discoveryRoots 返回当前平台上 IDE 的常见安装位置
+func discoveryRoots() []string {
+	home, _ := os.UserHomeDir()
+
+	switch runtime.GOOS {
+	case "windows":
+		var roots []string
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			if dir := os.Getenv(env); dir != "" {
+				roots = append(roots, filepath.Join(dir, "JetBrains"))
+			}
+		}
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			roots = append(roots,
+				filepath.Join(local, "Programs"),
+				filepath.Join(local, "JetBrains", "Toolbox", "apps"))
+		}
+		return roots
+	case "darwin":
+		return []string{
+			"/Applications",
+			filepath.Join(home, "Applications"),
+			filepath.Join(home, "Library", "Application Support", "JetBrains", "Toolbox", "apps"),
+		}
+	default:
+		return []string{
+			"/opt",
+			"/usr/local",
+			"/usr/share",
+			"/snap",
+			filepath.Join(home, ".local", "share", "JetBrains", "Toolbox", "apps"),
+			filepath.Join(home, "Applications"),
+			filepath.Join(home, "apps"),
+		}
+	}
+}
+
+// discoverInstallations 在给定目录下查找包含 product-info.json 的 IDE 安装目录
+func discoverInstallations(roots []string, maxDepth int) []DiscoveredInstallation {
+	var found []DiscoveredInstallation
+	seen := make(map[string]bool)
+
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		if depth > maxDepth || seen[dir] {
+			return
+		}
+		seen[dir] = true
+
+		// macOS 应用包的安装目录为 *.app/Contents
+		if strings.HasSuffix(dir, ".app") {
+			dir = filepath.Join(dir, "Contents")
+		}
+
+		if _, ok := productInfoPath(dir); ok {
+			if _, err := validateIntelliJPath(dir); err == nil {
+				if info, err := readProductInfo(dir); err == nil {
+					found = append(found, DiscoveredInstallation{
+						Path:        dir,
+						ProductCode: info.ProductCode,
+						ProductName: info.displayName(),
+						Version:     info.Version,
+						BuildNumber: info.BuildNumber,
+					})
+				}
+			}
+			return
+		}
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			walk(filepath.Join(dir, entry.Name()), depth+1)
+		}
+	}
+
+	for _, root := range roots {
+		walk(root, 0)
+	}
+
+	slices.SortFunc(found, func(a, b DiscoveredInstallation) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return found
+}
+
+// DiscoverInstallations 扫描常见安装位置，返回找到的 IDE 安装
+func (c *ConfigService) DiscoverInstallations() []DiscoveredInstallation {
+	found := discoverInstallations(discoveryRoots(), discoveryMaxDepth)
+	c.logger.Info("IDE 安装扫描完成", slog.Int("count", len(found)))
+	return found
+}
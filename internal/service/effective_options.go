@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// JVM 参数的来源
+const (
+	OptionSourceEnv         = "env"
+	OptionSourceBin         = "bin"
+	OptionSourceToolbox     = "toolbox"
+	OptionSourceUser        = "user"
+	OptionSourceProductInfo = "productInfo"
+)
+
+// userGCPattern 启动器在用户 vmoptions 指定 GC 时会去掉默认文件中的 GC 选项
+var userGCPattern = regexp.MustCompile(`^-XX:\+Use.*GC`)
+
+// EffectiveOption 描述启动器最终传给 JVM 的一个参数及其来源
+type EffectiveOption struct {
+	Option string `json:"option"`
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	// Overridden 表示该参数被后面的同名参数或用户文件中的 GC 设置覆盖，不会生效
+	Overridden   bool   `json:"overridden"`
+	OverriddenBy string `json:"overriddenBy,omitempty"`
+}
+
+// EffectiveOptions 汇总一个安装的 JVM 参数解析结果
+type EffectiveOptions struct {
+	Options   []EffectiveOption `json:"options"`
+	Arguments []string          `json:"arguments"`
+	// Files 按启动器的读取顺序列出实际使用的 vmoptions 文件
+	Files  []string `json:"files"`
+	EnvVar string   `json:"envVar,omitempty"`
+}
+
+// jvmOptionKey 返回参数的比较键，同键参数中最后出现的生效
+// --add-opens、-javaagent 等可重复的参数返回完整参数，不参与覆盖
+func jvmOptionKey(option string) string {
+	switch {
+	case strings.HasPrefix(option, "-XX:"):
+		name := strings.TrimPrefix(option, "-XX:")
+		name = strings.TrimLeft(name, "+-")
+		name, _, _ = strings.Cut(name, "=")
+		return "-XX:" + name
+	case strings.HasPrefix(option, "-D"):
+		name, _, _ := strings.Cut(option, "=")
+		return name
+	}
+	for _, prefix := range []string{"-Xmx", "-Xms", "-Xss", "-Xmn"} {
+		if strings.HasPrefix(option, prefix) {
+			return prefix
+		}
+	}
+	return option
+}
+
+// readVMOptionsLines 读取 vmoptions 文件中的参数，跳过空行和 # 注释
+func readVMOptionsLines(path, source string) ([]EffectiveOption, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	defer file.Close()
+
+	var options []EffectiveOption
+	scanner := bufio.NewScanner(file)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		options = append(options, EffectiveOption{Option: text, Key: jvmOptionKey(text), Source: source, File: path, Line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	return options, nil
+}
+
+// binVMOptionsFile 返回启动器读取的 bin 目录 vmoptions 文件
+// 优先使用 product-info.json 中的 vmOptionsFilePath，否则按产品目录的启动器名推断
+func binVMOptionsFile(home string, info *productInfo, goos string) string {
+	if info != nil {
+		if launch, ok := info.launchFor(goos); ok && launch.VMOptionsFilePath != "" {
+			if path := filepath.Join(home, filepath.FromSlash(launch.VMOptionsFilePath)); fileExists(path) {
+				return path
+			}
+		}
+	}
+
+	binDir := filepath.Join(home, "bin")
+	if info != nil {
+		if product, ok := productByCode(info.ProductCode); ok && len(product.Launchers) > 0 {
+			base := strings.TrimSuffix(product.Launchers[0], ".sh")
+			name := map[string]string{"windows": base + "64.exe.vmoptions", "darwin": base + ".vmoptions"}[goos]
+			if name == "" {
+				name = base + "64.vmoptions"
+			}
+			if path := filepath.Join(binDir, name); fileExists(path) {
+				return path
+			}
+		}
+	}
+
+	if files, err := findVMOptionsFiles(binDir); err == nil && len(files) > 0 {
+		return files[0]
+	}
+	return ""
+}
+
+// jsonStringLine 从 offset 开始查找 JSON 字符串值所在的行号，返回行号和下次查找的位置
+// 找不到时行号为 0
+func jsonStringLine(data []byte, value string, offset int) (int, int) {
+	encoded, err := json.Marshal(value)
+	if err != nil || offset > len(data) {
+		return 0, offset
+	}
+	index := bytes.Index(data[offset:], encoded)
+	if index < 0 {
+		return 0, offset
+	}
+	end := offset + index + len(encoded)
+	return bytes.Count(data[:offset+index], []byte("\n")) + 1, end
+}
+
+// toolboxVMOptionsFile 返回 Toolbox 在安装目录旁写入的 vmoptions 文件路径
+// macOS 的安装目录为 *.app/Contents，对应文件为 *.app.vmoptions
+func toolboxVMOptionsFile(home string) string {
+	if filepath.Base(home) == "Contents" {
+		return filepath.Dir(home) + ".vmoptions"
+	}
+	return home + ".vmoptions"
+}
+
+// resolveEffectiveOptions 按启动器的实际顺序合并 JVM 参数：
+//  1. *_VM_OPTIONS 环境变量指向的文件存在时，只使用该文件
+//  2. 否则以 bin 目录的 vmoptions 为基础
+//  3. Toolbox 的 <安装目录>.vmoptions 存在时追加在后，否则追加配置目录中的用户 vmoptions，
+//     两者只使用其一；追加的文件指定 GC 时去掉 bin 文件中的 GC 选项
+//  4. 最后追加 product-info.json 中的 additionalJvmArguments
+func resolveEffectiveOptions(home string, lookupEnv func(string) (string, bool), goos string) (EffectiveOptions, error) {
+	result := EffectiveOptions{Options: []EffectiveOption{}, Arguments: []string{}, Files: []string{}}
+	info, _ := readProductInfo(home)
+
+	var envFile string
+	if info != nil {
+		if product, ok := productByCode(info.ProductCode); ok {
+			for _, name := range product.EnvVars {
+				if value, ok := lookupEnv(name); ok && value != "" && fileExists(value) {
+					result.EnvVar = name
+					envFile = value
+					break
+				}
+			}
+		}
+	}
+
+	type optionsFile struct{ path, source string }
+	var files []optionsFile
+	if envFile != "" {
+		files = append(files, optionsFile{envFile, OptionSourceEnv})
+	} else {
+		bin := binVMOptionsFile(home, info, goos)
+		if bin != "" {
+			files = append(files, optionsFile{bin, OptionSourceBin})
+		}
+		// Toolbox 管理的安装由 <安装目录>.vmoptions 代替用户文件，用户文件与 bin 目录中的文件同名
+		if toolbox := toolboxVMOptionsFile(home); fileExists(toolbox) {
+			files = append(files, optionsFile{toolbox, OptionSourceToolbox})
+		} else if bin != "" {
+			if configDir, err := ideConfigDir(home); err == nil {
+				if user := filepath.Join(configDir, filepath.Base(bin)); fileExists(user) {
+					files = append(files, optionsFile{user, OptionSourceUser})
+				}
+			}
+		}
+	}
+	if len(files) == 0 && info == nil {
+		return result, ErrNoVMOptions
+	}
+
+	for _, f := range files {
+		options, err := readVMOptionsLines(f.path, f.source)
+		if err != nil {
+			return result, err
+		}
+		if f.source == OptionSourceUser || f.source == OptionSourceToolbox {
+			for _, opt := range options {
+				if !userGCPattern.MatchString(opt.Option) {
+					continue
+				}
+				for i := range result.Options {
+					if userGCPattern.MatchString(result.Options[i].Option) {
+						result.Options[i].Overridden = true
+						result.Options[i].OverriddenBy = fmt.Sprintf("%s:%d", opt.File, opt.Line)
+					}
+				}
+			}
+		}
+		result.Options = append(result.Options, options...)
+		result.Files = append(result.Files, f.path)
+	}
+
+	if info != nil {
+		if launch, ok := info.launchFor(goos); ok {
+			infoPath, _ := productInfoPath(home)
+			data, _ := os.ReadFile(infoPath)
+			replacer := strings.NewReplacer("$IDE_HOME", home, "%IDE_HOME%", home, "$APP_PACKAGE", filepath.Dir(home))
+			offset := 0
+			for _, raw := range launch.AdditionalJVMArguments {
+				var line int
+				line, offset = jsonStringLine(data, raw, offset)
+				arg := replacer.Replace(raw)
+				result.Options = append(result.Options, EffectiveOption{
+					Option: arg, Key: jvmOptionKey(arg), Source: OptionSourceProductInfo, File: infoPath, Line: line,
+				})
+			}
+		}
+	}
+
+	// 同键参数以最后出现的为准
+	last := make(map[string]int)
+	for i, opt := range result.Options {
+		if !opt.Overridden {
+			last[opt.Key] = i
+		}
+	}
+	for i := range result.Options {
+		opt := &result.Options[i]
+		if opt.Overridden {
+			continue
+		}
+		if winner := last[opt.Key]; winner != i {
+			opt.Overridden = true
+			opt.OverriddenBy = fmt.Sprintf("%s:%d", result.Options[winner].File, result.Options[winner].Line)
+			continue
+		}
+		result.Arguments = append(result.Arguments, opt.Option)
+	}
+	return result, nil
+}
+
+// ResolveEffectiveOptions 返回 IDE 启动器最终使用的 JVM 参数及每个参数的来源文件和行号
+func (c *ConfigService) ResolveEffectiveOptions(installPath string) (EffectiveOptions, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return EffectiveOptions{}, ErrEmptyPath
+	}
+	return resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestResolveEffectiveOptions 测试 bin、用户、环境变量和 product-info.json 参数的合并顺序
+func TestResolveEffectiveOptions(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xms128m\n-Xmx2048m\n# comment\n-XX:+UseG1GC\n-Dsun.io.useCanonCaches=false\n")
+	info := `{
+  "name": "IntelliJ IDEA",
+  "version": "2024.1",
+  "buildNumber": "241.1",
+  "productCode": "IU",
+  "dataDirectoryName": "IntelliJIdea2024.1",
+  "launch": [
+    {
+      "os": "Linux",
+      "vmOptionsFilePath": "bin/idea64.vmoptions",
+      "additionalJvmArguments": [
+        "-Didea.vendor.name=JetBrains",
+        "-Djava.system.class.loader=com.intellij.util.lang.PathClassLoader",
+        "-Dsun.io.useCanonCaches=true"
+      ]
+    }
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(home, productInfoFileName), []byte(info), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	userDir := filepath.Join(base, "config", "JetBrains", "IntelliJIdea2024.1")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	userFile := filepath.Join(userDir, "idea64.vmoptions")
+	if err := os.WriteFile(userFile, []byte("-Xmx4096m\n-XX:+UseZGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	noEnv := func(string) (string, bool) { return "", false }
+	result, err := resolveEffectiveOptions(home, noEnv, "linux")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	want := []string{
+		"-Xms128m",
+		"-Xmx4096m",
+		"-XX:+UseZGC",
+		"-Didea.vendor.name=JetBrains",
+		"-Djava.system.class.loader=com.intellij.util.lang.PathClassLoader",
+		"-Dsun.io.useCanonCaches=true",
+	}
+	if !slices.Equal(result.Arguments, want) {
+		t.Errorf("最终参数不正确:\n got %v\nwant %v", result.Arguments, want)
+	}
+	if len(result.Files) != 2 || result.Files[1] != userFile {
+		t.Errorf("读取的文件不正确: %v", result.Files)
+	}
+
+	byOption := map[string]EffectiveOption{}
+	for _, opt := range result.Options {
+		byOption[opt.Option] = opt
+	}
+	if g1 := byOption["-XX:+UseG1GC"]; !g1.Overridden || g1.OverriddenBy != userFile+":2" {
+		t.Errorf("用户文件指定 GC 时应覆盖默认 GC: %+v", g1)
+	}
+	if xmx := byOption["-Xmx2048m"]; !xmx.Overridden || xmx.Line != 2 {
+		t.Errorf("-Xmx2048m 应被用户文件覆盖: %+v", xmx)
+	}
+	if vendor := byOption["-Didea.vendor.name=JetBrains"]; vendor.Source != OptionSourceProductInfo || vendor.Line != 12 {
+		t.Errorf("product-info 参数的来源或行号不正确: %+v", vendor)
+	}
+
+	// 环境变量指向的文件替代 bin 和用户文件
+	envFile := filepath.Join(base, "custom.vmoptions")
+	if err := os.WriteFile(envFile, []byte("-Xmx1g\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	result, err = resolveEffectiveOptions(home, func(name string) (string, bool) {
+		return envFile, name == "IDEA_VM_OPTIONS"
+	}, "linux")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if result.EnvVar != "IDEA_VM_OPTIONS" || len(result.Files) != 1 || result.Arguments[0] != "-Xmx1g" {
+		t.Errorf("环境变量文件应替代其他文件: %+v", result)
+	}
+}
+
+// TestResolveToolboxOptions 测试 Toolbox 的 <安装目录>.vmoptions 追加在 bin 文件后并代替用户文件
+func TestResolveToolboxOptions(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "apps", "IDEA-U", "ch-0", "241.1")
+	createTestInstallation(t, home, "IU", "241.1", "-Xms128m\n-Xmx2048m\n-XX:+UseG1GC\n")
+
+	userDir := filepath.Join(base, "config", "JetBrains", "IntelliJIdea2024.1")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(userDir, "idea64.vmoptions"), []byte("-Xmx8g\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	toolbox := toolboxVMOptionsFile(home)
+	if err := os.WriteFile(toolbox, []byte("-Xmx4096m\n-XX:+UseZGC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := resolveEffectiveOptions(home, func(string) (string, bool) { return "", false }, "linux")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	bin := filepath.Join(home, "bin", "idea64.vmoptions")
+	if !slices.Equal(result.Files, []string{bin, toolbox}) {
+		t.Errorf("应读取 bin 文件和 Toolbox 文件，不读取用户文件: %v", result.Files)
+	}
+	want := []string{"-Xms128m", "-Xmx4096m", "-XX:+UseZGC"}
+	if !slices.Equal(result.Arguments, want) {
+		t.Errorf("最终参数不正确:\n got %v\nwant %v", result.Arguments, want)
+	}
+	for _, opt := range result.Options {
+		if opt.Option == "-XX:+UseG1GC" && (!opt.Overridden || opt.OverriddenBy != toolbox+":2") {
+			t.Errorf("Toolbox 文件指定 GC 时应覆盖 bin 文件中的 GC: %+v", opt)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// envSources 返回 Linux 和 macOS 上可能定义 *_VM_OPTIONS 的文件，系统级文件位于 root 下，root 为空时使用 /
+func envSources(root string) []envSource {
+	home, _ := os.UserHomeDir()
+	if root == "" {
+		root = "/"
+	}
+
+	var sources []envSource
+	add := func(scope, kind string, parser func(string, []string) []envAssignment, patterns ...string) {
+		for _, pattern := range patterns {
+			matches, _ := filepath.Glob(pattern)
+			for _, match := range matches {
+				sources = append(sources, envSource{Path: match, Kind: kind, Scope: scope, Parser: parser})
+			}
+		}
+	}
+	// system 将系统级路径放到 root 下
+	system := func(patterns ...string) []string {
+		for i, pattern := range patterns {
+			patterns[i] = filepath.Join(root, pattern)
+		}
+		return patterns
+	}
+
+	if home != "" {
+		add(EnvScopeUser, EnvSourceShell, parseEnvAssignments,
+			filepath.Join(home, ".profile"),
+			filepath.Join(home, ".bash_profile"),
+			filepath.Join(home, ".bash_login"),
+			filepath.Join(home, ".bashrc"),
+			filepath.Join(home, ".zshenv"),
+			filepath.Join(home, ".zprofile"),
+			filepath.Join(home, ".zshrc"),
+			filepath.Join(home, ".zlogin"),
+			filepath.Join(home, ".xprofile"),
+			filepath.Join(home, ".xsessionrc"),
+			filepath.Join(home, ".config", "fish", "config.fish"),
+			filepath.Join(home, ".config", "fish", "conf.d", "*.fish"))
+		add(EnvScopeUser, EnvSourcePam, parseEnvAssignments,
+			filepath.Join(home, ".pam_environment"))
+		add(EnvScopeUser, EnvSourceEnvironmentD, parseEnvAssignments,
+			filepath.Join(home, ".config", "environment.d", "*.conf"))
+		add(EnvScopeUser, EnvSourceDesktop, parseDesktopAssignments,
+			filepath.Join(home, ".local", "share", "applications", "*.desktop"))
+	}
+
+	add(EnvScopeSystem, EnvSourceShell, parseEnvAssignments, system(
+		"/etc/profile",
+		"/etc/profile.d/*.sh",
+		"/etc/bash.bashrc",
+		"/etc/bashrc",
+		"/etc/zshenv",
+		"/etc/zprofile",
+		"/etc/zsh/zshenv",
+		"/etc/zsh/zprofile")...)
+	add(EnvScopeSystem, EnvSourcePam, parseEnvAssignments, system(
+		"/etc/environment",
+		"/etc/security/pam_env.conf")...)
+	add(EnvScopeSystem, EnvSourceEnvironmentD, parseEnvAssignments, system(
+		"/etc/environment.d/*.conf")...)
+	add(EnvScopeSystem, EnvSourceDesktop, parseDesktopAssignments, system(
+		"/usr/share/applications/*.desktop",
+		"/usr/local/share/applications/*.desktop")...)
+
+	// macOS 上 GUI 应用的环境变量通常通过 launchctl setenv 设置
+	if runtime.GOOS == "darwin" {
+		if home != "" {
+			add(EnvScopeUser, EnvSourceLaunchd, parseLaunchdPlist,
+				filepath.Join(home, "Library", "LaunchAgents", "*.plist"))
+		}
+		add(EnvScopeSystem, EnvSourceLaunchd, parseLaunchdPlist, system(
+			"/Library/LaunchAgents/*.plist",
+			"/Library/LaunchDaemons/*.plist")...)
+		add(EnvScopeSystem, EnvSourceLaunchd, parseEnvAssignments, system(
+			"/etc/launchd.conf")...)
+	}
+
+	return sources
+}
+
+// findEnvVarDefinitions 扫描 shell 启动脚本、environment.d 和 .desktop 启动器中的 *_VM_OPTIONS 定义
+func findEnvVarDefinitions(root string, logger *slog.Logger) []EnvVarDefinition {
+	defs := scanEnvSources(envSources(root), jetbrainsEnvVarNames())
+	logger.Debug("环境变量定义扫描完成", slog.Int("count", len(defs)))
+	return defs
+}
+
+// prepareJetBrainsEnvVars 在应用或清除配置前检查环境变量，只报告定义位置，不修改 shell 启动脚本和 /etc 下的文件
+// 注释定义需要用户通过 DisableEnvVarDefinitions 单独确认
+func prepareJetBrainsEnvVars(root string, logger *slog.Logger) (string, error) {
+	var warnings []string
+	if defs := findEnvVarDefinitions(root, logger); len(defs) > 0 {
+		files := make([]string, 0, len(defs))
+		for _, def := range defs {
+			if !slices.Contains(files, def.File) {
+				files = append(files, def.File)
+			}
+		}
+		warnings = append(warnings, fmt.Sprintf("检测到 %d 处 *_VM_OPTIONS 环境变量定义会覆盖 vmoptions 配置（%s），本工具不会自动修改，确认后可单独注释这些定义。",
+			len(defs), strings.Join(files, "、")))
+	}
+	if anyEnvVarInProcess() {
+		warnings = append(warnings, "当前会话中存在 *_VM_OPTIONS 环境变量，IDE 可能不会读取本工具修改的 vmoptions 文件。")
+	}
+	return strings.Join(warnings, " "), nil
+}
+
+// removeJetBrainsEnvVars 注释掉所有 JetBrains 产品的环境变量定义
+// 修改前会备份原文件；无写入权限的系统级文件只返回警告，不会中断操作
+func removeJetBrainsEnvVars(root string, logger *slog.Logger) (string, error) {
+	defs := findEnvVarDefinitions(root, logger)
+	if len(defs) == 0 {
+		logger.Info("未检测到任何 JetBrains 环境变量")
+		return "", nil
+	}
+
+	logger.Info("开始注释 JetBrains 环境变量定义", slog.Int("count", len(defs)))
+	disabled, warnings := disableEnvDefinitions(defs, logger)
+
+	if disabled > 0 {
+		warnings = append(warnings, fmt.Sprintf("已注释 %d 处环境变量定义（原文件已备份），需重新登录后生效。", disabled))
+	}
+	if anyEnvVarInProcess() {
+		warnings = append(warnings, "当前会话中仍存在 *_VM_OPTIONS 环境变量，请重新登录或重启 IDE 启动器。")
+	}
+
+	logger.Info("环境变量清除完成", slog.Int("disabledCount", disabled))
+	return strings.Join(warnings, " "), nil
+}
+
+// anyEnvVarInProcess 检查当前进程环境中是否存在 JetBrains 环境变量
+func anyEnvVarInProcess() bool {
+	for _, name := range jetbrainsEnvVarNames() {
+		if _, ok := os.LookupEnv(name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -12,24 +12,22 @@ import (
 	"golang.org/x/sys/windows/registry"
 )
 
-// JetBrains 产品列表（与 VBS 脚本中的列表一致）
-var jetbrainsProducts = []string{
-	"idea", "clion", "phpstorm", "goland", "pycharm", "webstorm",
-	"webide", "rider", "datagrip", "rubymine", "dataspell", "aqua",
-	"rustrover", "gateway", "jetbrains_client", "jetbrainsclient",
-	"studio", "devecostudio",
-}
+// 用户级和系统级环境变量所在的注册表位置
+const (
+	userEnvRegistryPath   = `Environment`
+	systemEnvRegistryPath = `SYSTEM\CurrentControlSet\Control\Session Manager\Environment`
+)
 
-// buildEnvVarName 构建 JetBrains 产品的环境变量名
-func buildEnvVarName(product string) string {
-	return strings.ToUpper(product) + "_VM_OPTIONS"
+// prepareJetBrainsEnvVars 在应用或清除配置前处理环境变量，Windows 上与之前一致直接删除注册表中的定义
+func prepareJetBrainsEnvVars(root string, logger *slog.Logger) (string, error) {
+	return removeJetBrainsEnvVars(root, logger)
 }
 
 // removeJetBrainsEnvVars 删除所有 JetBrains 产品的环境变量
 // 在 Windows 上删除用户级和系统级环境变量
 // 在其他平台上仅记录警告（因为通常不使用环境变量方式）
-// 返回的错误字符串会包含权限提示信息
-func removeJetBrainsEnvVars(logger *slog.Logger) (string, error) {
+// 返回的错误字符串会包含权限提示信息；环境变量保存在注册表中，不使用 root
+func removeJetBrainsEnvVars(_ string, logger *slog.Logger) (string, error) {
 	if runtime.GOOS != "windows" {
 		logger.Info("非 Windows 平台，跳过环境变量清除")
 		return "", nil
@@ -43,12 +41,12 @@ func removeJetBrainsEnvVars(logger *slog.Logger) (string, error) {
 	hasAdminRights := isRunningAsAdmin()
 
 	// 检查用户级和系统级环境变量是否存在
-	userVarsExist := checkEnvVarsExist(registry.CURRENT_USER, `Environment`, logger)
-	systemVarsExist := checkEnvVarsExist(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Control\Session Manager\Environment`, logger)
+	userVarsExist := checkEnvVarsExist(registry.CURRENT_USER, userEnvRegistryPath, logger)
+	systemVarsExist := checkEnvVarsExist(registry.LOCAL_MACHINE, systemEnvRegistryPath, logger)
 
 	// 清除用户级环境变量
 	if userVarsExist {
-		userRemoved, err := removeEnvVarsFromRegistry(registry.CURRENT_USER, `Environment`, logger)
+		userRemoved, err := removeEnvVarsFromRegistry(registry.CURRENT_USER, userEnvRegistryPath, logger)
 		if err != nil {
 			errors = append(errors, fmt.Sprintf("用户级: %v", err))
 		} else {
@@ -67,7 +65,7 @@ func removeJetBrainsEnvVars(logger *slog.Logger) (string, error) {
 		} else {
 			systemRemoved, err := removeEnvVarsFromRegistry(
 				registry.LOCAL_MACHINE,
-				`SYSTEM\CurrentControlSet\Control\Session Manager\Environment`,
+				systemEnvRegistryPath,
 				logger,
 			)
 			if err != nil {
@@ -101,6 +99,36 @@ func removeJetBrainsEnvVars(logger *slog.Logger) (string, error) {
 	return warningMsg, nil
 }
 
+// findEnvVarDefinitions 读取注册表中用户级和系统级的 *_VM_OPTIONS 定义
+func findEnvVarDefinitions(_ string, logger *slog.Logger) []EnvVarDefinition {
+	var defs []EnvVarDefinition
+	for _, location := range []struct {
+		root  registry.Key
+		path  string
+		scope string
+		name  string
+	}{
+		{registry.CURRENT_USER, userEnvRegistryPath, EnvScopeUser, `HKCU\` + userEnvRegistryPath},
+		{registry.LOCAL_MACHINE, systemEnvRegistryPath, EnvScopeSystem, `HKLM\` + systemEnvRegistryPath},
+	} {
+		key, err := registry.OpenKey(location.root, location.path, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		for _, name := range jetbrainsEnvVarNames() {
+			value, _, err := key.GetStringValue(name)
+			if err != nil {
+				continue
+			}
+			defs = append(defs, newEnvVarDefinition(name, value, location.scope, EnvSourceRegistry, location.name, 0))
+		}
+		key.Close()
+	}
+
+	logger.Debug("环境变量定义扫描完成", slog.Int("count", len(defs)))
+	return defs
+}
+
 // isRunningAsAdmin 检测当前进程是否具有管理员权限
 func isRunningAsAdmin() bool {
 	if runtime.GOOS != "windows" {
@@ -110,7 +138,7 @@ func isRunningAsAdmin() bool {
 	// 尝试打开需要管理员权限的注册表键
 	key, err := registry.OpenKey(
 		registry.LOCAL_MACHINE,
-		`SYSTEM\CurrentControlSet\Control\Session Manager\Environment`,
+		systemEnvRegistryPath,
 		registry.SET_VALUE,
 	)
 	if err != nil {
@@ -135,8 +163,7 @@ func checkEnvVarsExist(rootKey registry.Key, path string, logger *slog.Logger) b
 	}
 	defer key.Close()
 
-	for _, product := range jetbrainsProducts {
-		envVarName := buildEnvVarName(product)
+	for _, envVarName := range jetbrainsEnvVarNames() {
 		_, _, err := key.GetStringValue(envVarName)
 		if err == nil {
 			// 找到至少一个环境变量
@@ -161,8 +188,7 @@ func removeEnvVarsFromRegistry(rootKey registry.Key, path string, logger *slog.L
 	var errors []string
 
 	// 遍历所有 JetBrains 产品
-	for _, product := range jetbrainsProducts {
-		envVarName := buildEnvVarName(product)
+	for _, envVarName := range jetbrainsEnvVarNames() {
 
 		// 检查环境变量是否存在
 		_, _, err := key.GetStringValue(envVarName)
@@ -0,0 +1,363 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// 环境变量的作用范围
+const (
+	EnvScopeUser   = "user"
+	EnvScopeSystem = "system"
+)
+
+// 环境变量定义的来源类型
+const (
+	EnvSourceShell        = "shell"         // shell 启动脚本（.profile、.bashrc、/etc/profile.d 等）
+	EnvSourcePam          = "pam"           // /etc/environment、~/.pam_environment
+	EnvSourceEnvironmentD = "environment.d" // systemd 的 environment.d/*.conf
+	EnvSourceDesktop      = "desktop"       // .desktop 启动器的 Exec 行
+	EnvSourceLaunchd      = "launchd"       // macOS launchd plist
+	EnvSourceRegistry     = "registry"      // Windows 注册表
+)
+
+// 注释掉环境变量定义时添加的前缀，便于识别和手动恢复
+const envDisabledPrefix = "# [intellijapp] "
+
+// EnvVarDefinition 保存在某个来源中找到的 *_VM_OPTIONS 环境变量定义
+type EnvVarDefinition struct {
+	Name         string `json:"name"`
+	Value        string `json:"value"`
+	Scope        string `json:"scope"`
+	Source       string `json:"source"`
+	File         string `json:"file"`
+	Line         int    `json:"line"`
+	TargetPath   string `json:"targetPath"`
+	TargetExists bool   `json:"targetExists"`
+}
+
+// envSource 描述一个需要扫描的环境变量来源文件
+type envSource struct {
+	Path   string
+	Kind   string
+	Scope  string
+	Parser func(content string, names []string) []envAssignment
+}
+
+// envAssignment 为从文件中解析出的单个赋值
+type envAssignment struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// envAssignmentPattern 匹配各种 shell 和配置文件中的赋值写法：
+// NAME=value、export NAME=value、env NAME=value、setenv NAME value（csh）、set -gx NAME value（fish）、
+// NAME DEFAULT=value（pam_env.conf）。空白分隔的写法只在 setenv 和 set -gx 之后识别，
+// 避免把 echo IDEA_VM_OPTIONS foo 这类命令参数当作赋值；不匹配 $NAME 和 ${NAME} 形式的引用
+var envAssignmentPattern = regexp.MustCompile(`(?:^|[^\w$\{])(?:(?:setenv|set\s+-gx)\s+([A-Z_]+_VM_OPTIONS)\s+|([A-Z_]+_VM_OPTIONS)(?:=|\s+(?:DEFAULT|OVERRIDE)=))("[^"]*"|'[^']*'|[^\s;]+)`)
+
+// parseEnvAssignments 解析行式配置文件中的 *_VM_OPTIONS 赋值，忽略注释和 unset 语句
+func parseEnvAssignments(content string, names []string) []envAssignment {
+	var result []envAssignment
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "unset ") ||
+			strings.HasPrefix(trimmed, "set -e ") || strings.HasPrefix(trimmed, "unsetenv ") {
+			continue
+		}
+		result = append(result, matchEnvAssignments(trimmed, names, i+1)...)
+	}
+	return result
+}
+
+// parseDesktopAssignments 解析 .desktop 文件 Exec 行中通过 env 设置的 *_VM_OPTIONS
+func parseDesktopAssignments(content string, names []string) []envAssignment {
+	var result []envAssignment
+	for i, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "Exec=") {
+			continue
+		}
+		result = append(result, matchEnvAssignments(strings.TrimPrefix(trimmed, "Exec="), names, i+1)...)
+	}
+	return result
+}
+
+// matchEnvAssignments 在单行中查找已知变量名的赋值
+func matchEnvAssignments(line string, names []string, lineNumber int) []envAssignment {
+	var result []envAssignment
+	for _, match := range envAssignmentPattern.FindAllStringSubmatch(line, -1) {
+		name := match[1] + match[2]
+		if !slices.Contains(names, name) {
+			continue
+		}
+		result = append(result, envAssignment{Name: name, Value: unquoteEnvValue(match[3]), Line: lineNumber})
+	}
+	return result
+}
+
+// envQuotedPattern 匹配 shell 行中的引号字符串，判断复合语句时忽略其中的内容
+var envQuotedPattern = regexp.MustCompile(`"[^"]*"|'[^']*'`)
+
+// isCompoundShellLine 判断行中是否包含多条语句或续行，这类行整体注释会连带注释其他命令
+func isCompoundShellLine(line string) bool {
+	line = envQuotedPattern.ReplaceAllString(strings.TrimSpace(line), "")
+	return strings.ContainsAny(line, ";&|`") || strings.Contains(line, "$(") || strings.HasSuffix(line, "\\")
+}
+
+// unquoteEnvValue 去除值两端的引号
+func unquoteEnvValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// expandEnvPath 展开值中的 ~、$HOME 和其他环境变量，得到实际引用的文件路径
+func expandEnvPath(value string) string {
+	if value == "" {
+		return ""
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		if value == "~" || strings.HasPrefix(value, "~/") {
+			value = filepath.Join(home, strings.TrimPrefix(value, "~"))
+		}
+	}
+	return filepath.Clean(os.ExpandEnv(value))
+}
+
+// newEnvVarDefinition 根据赋值生成定义，并检查引用的文件是否存在
+func newEnvVarDefinition(name, value, scope, source, file string, line int) EnvVarDefinition {
+	def := EnvVarDefinition{
+		Name:   name,
+		Value:  value,
+		Scope:  scope,
+		Source: source,
+		File:   file,
+		Line:   line,
+	}
+	if value != "" {
+		def.TargetPath = expandEnvPath(value)
+		_, err := os.Stat(def.TargetPath)
+		def.TargetExists = err == nil
+	}
+	return def
+}
+
+// scanEnvSources 扫描所有来源文件，返回找到的环境变量定义
+func scanEnvSources(sources []envSource, names []string) []EnvVarDefinition {
+	var defs []EnvVarDefinition
+	for _, source := range sources {
+		content, err := os.ReadFile(source.Path)
+		if err != nil {
+			continue
+		}
+		for _, a := range source.Parser(string(content), names) {
+			defs = append(defs, newEnvVarDefinition(a.Name, a.Value, source.Scope, source.Kind, source.Path, a.Line))
+		}
+	}
+	return defs
+}
+
+// disableEnvDefinitions 在备份后注释掉文件中的环境变量定义
+// .desktop 文件只删除 Exec 行中的赋值，保留启动器本身；无写入权限的文件跳过并返回警告
+func disableEnvDefinitions(defs []EnvVarDefinition, logger *slog.Logger) (int, []string) {
+	byFile := make(map[string][]EnvVarDefinition)
+	var files []string
+	var warnings []string
+	for _, def := range defs {
+		if def.Source == EnvSourceLaunchd {
+			warnings = append(warnings, fmt.Sprintf("%s 通过 launchctl setenv 设置了 %s，请手动移除", def.File, def.Name))
+			continue
+		}
+		if def.Line <= 0 || def.Source == EnvSourceRegistry {
+			continue
+		}
+		if _, ok := byFile[def.File]; !ok {
+			files = append(files, def.File)
+		}
+		byFile[def.File] = append(byFile[def.File], def)
+	}
+
+	disabled := 0
+	for _, file := range files {
+		fileDefs := byFile[file]
+		if err := checkFileWritePermission(file); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s 中定义了 %s，需要更高权限才能修改", file, fileDefs[0].Name))
+			logger.Warn("无权限修改环境变量定义", slog.String("file", file))
+			continue
+		}
+
+		count, skipped, err := disableEnvDefinitionsInFile(file, fileDefs)
+		warnings = append(warnings, skipped...)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("修改 %s 失败: %v", file, err))
+			logger.Warn("修改环境变量定义失败", slog.String("file", file), slog.Any("error", err))
+			continue
+		}
+		disabled += count
+		logger.Info("已注释环境变量定义", slog.String("file", file), slog.Int("count", count))
+	}
+	return disabled, warnings
+}
+
+// disableEnvDefinitionsInFile 修改单个文件中的定义，返回修改的数量和因复合语句而跳过的警告
+func disableEnvDefinitionsInFile(file string, defs []EnvVarDefinition) (int, []string, error) {
+	// dotfile 管理工具常把 ~/.bashrc 等文件链接到仓库中，写入链接指向的文件，避免用普通文件替换符号链接
+	file, err := filepath.EvalSymlinks(file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("解析文件路径失败: %w", err)
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	count, changed := 0, 0
+	var warnings []string
+	for _, def := range defs {
+		index := def.Line - 1
+		if index >= len(lines) {
+			continue
+		}
+		line := lines[index]
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			// 同一行中的多个定义已在前面一并注释
+			count++
+			continue
+		}
+
+		if def.Source == EnvSourceDesktop {
+			lines[index] = removeDesktopAssignment(line, def.Name)
+		} else if isCompoundShellLine(line) {
+			// 注释整行会同时注释同一行中的其他命令，只提示用户手动修改
+			warnings = append(warnings, fmt.Sprintf("%s 第 %d 行在多条语句中定义了 %s，请手动移除", file, def.Line, def.Name))
+			continue
+		} else {
+			lines[index] = envDisabledPrefix + line
+		}
+		count++
+		changed++
+	}
+
+	if changed == 0 {
+		return 0, warnings, nil
+	}
+	if _, err := writeFileWithBackup(file, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return 0, warnings, err
+	}
+	return count, warnings, nil
+}
+
+// emptyEnvPattern 匹配 env 后已没有任何赋值的 Exec 行
+var emptyEnvPattern = regexp.MustCompile(`Exec=\s*env\s+([^\s=]+(?:\s|$))`)
+
+// removeDesktopAssignment 从 .desktop 的 Exec 行中删除 NAME=value，env 后不再有赋值时一并删除 env
+func removeDesktopAssignment(line, name string) string {
+	pattern := regexp.MustCompile(`\s*\b` + regexp.QuoteMeta(name) + `=("[^"]*"|'[^']*'|\S+)`)
+	line = pattern.ReplaceAllString(line, "")
+	return emptyEnvPattern.ReplaceAllString(line, "Exec=$1")
+}
+
+// DetectEnvVarDefinitions 返回所有 *_VM_OPTIONS 环境变量的定义位置及其引用的文件
+// 这些变量会覆盖 IDE bin 目录下的 vmoptions 文件
+func (c *ConfigService) DetectEnvVarDefinitions() []EnvVarDefinition {
+	return findEnvVarDefinitions(c.envRoot, c.logger)
+}
+
+// DisableEnvVarDefinitions 备份后注释（Windows 上删除）所有 *_VM_OPTIONS 环境变量定义
+func (c *ConfigService) DisableEnvVarDefinitions() (string, error) {
+	return removeJetBrainsEnvVars(c.envRoot, c.logger)
+}
+
+// launchdStringPattern 匹配 plist 中的 <string> 元素
+var launchdStringPattern = regexp.MustCompile(`<string>([^<]*)</string>`)
+
+// parseLaunchdPlist 解析 LaunchAgent plist 中通过 launchctl setenv NAME value 设置的变量
+func parseLaunchdPlist(content string, names []string) []envAssignment {
+	type token struct {
+		value string
+		line  int
+	}
+	var tokens []token
+	for i, line := range strings.Split(content, "\n") {
+		for _, match := range launchdStringPattern.FindAllStringSubmatch(line, -1) {
+			tokens = append(tokens, token{value: strings.TrimSpace(match[1]), line: i + 1})
+		}
+	}
+
+	var result []envAssignment
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].value == "setenv" && slices.Contains(names, tokens[i+1].value) {
+			result = append(result, envAssignment{Name: tokens[i+1].value, Value: tokens[i+2].value, Line: tokens[i+1].line})
+		}
+	}
+	return result
+}
+
+// EnvVarInspection 汇总单个 *_VM_OPTIONS 变量在当前进程、用户级和系统级的定义
+type EnvVarInspection struct {
+	Name            string             `json:"name"`
+	InProcess       bool               `json:"inProcess"`
+	ProcessValue    string             `json:"processValue"`
+	User            []EnvVarDefinition `json:"user"`
+	System          []EnvVarDefinition `json:"system"`
+	EffectiveValue  string             `json:"effectiveValue"`
+	EffectiveSource string             `json:"effectiveSource"`
+	TargetPath      string             `json:"targetPath"`
+	TargetExists    bool               `json:"targetExists"`
+}
+
+// inspectEnvVars 按变量名汇总定义，每个变量名都会返回一条记录（未定义时各字段为空）
+// 生效值按当前进程、用户级、系统级的顺序确定，同一范围内以最后一个定义为准
+func inspectEnvVars(names []string, defs []EnvVarDefinition, lookup func(string) (string, bool)) []EnvVarInspection {
+	result := make([]EnvVarInspection, 0, len(names))
+	for _, name := range names {
+		inspection := EnvVarInspection{Name: name, User: []EnvVarDefinition{}, System: []EnvVarDefinition{}}
+		inspection.ProcessValue, inspection.InProcess = lookup(name)
+
+		for _, def := range defs {
+			if def.Name != name {
+				continue
+			}
+			if def.Scope == EnvScopeUser {
+				inspection.User = append(inspection.User, def)
+			} else {
+				inspection.System = append(inspection.System, def)
+			}
+		}
+
+		switch {
+		case inspection.InProcess:
+			inspection.EffectiveValue = inspection.ProcessValue
+			inspection.EffectiveSource = "process"
+		case len(inspection.User) > 0:
+			inspection.EffectiveValue = inspection.User[len(inspection.User)-1].Value
+			inspection.EffectiveSource = EnvScopeUser
+		case len(inspection.System) > 0:
+			inspection.EffectiveValue = inspection.System[len(inspection.System)-1].Value
+			inspection.EffectiveSource = EnvScopeSystem
+		}
+
+		if inspection.EffectiveValue != "" {
+			target := newEnvVarDefinition(name, inspection.EffectiveValue, "", "", "", 0)
+			inspection.TargetPath = target.TargetPath
+			inspection.TargetExists = target.TargetExists
+		}
+		result = append(result, inspection)
+	}
+	return result
+}
+
+// InspectEnvironment 只读地检查所有 *_VM_OPTIONS 变量在当前进程、用户级和系统级的值
+// 以及引用的文件是否存在，用于解释 IDE 为何忽略修改后的 vmoptions 文件
+func (c *ConfigService) InspectEnvironment() []EnvVarInspection {
+	return inspectEnvVars(jetbrainsEnvVarNames(), findEnvVarDefinitions(c.envRoot, c.logger), os.LookupEnv)
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseEnvAssignments 测试识别各种 shell 写法中的环境变量定义
+func TestParseEnvAssignments(t *testing.T) {
+	names := jetbrainsEnvVarNames()
+	content := strings.Join([]string{
+		`export IDEA_VM_OPTIONS="/opt/idea.vmoptions"`,
+		`# export GOLAND_VM_OPTIONS=/commented`,
+		`echo $PYCHARM_VM_OPTIONS`,
+		`unset CLION_VM_OPTIONS`,
+		`set -gx WEBSTORM_VM_OPTIONS ~/webstorm.vmoptions`,
+		`RIDER_VM_OPTIONS DEFAULT=/etc/rider.vmoptions`,
+		`UNKNOWN_VM_OPTIONS=/ignored`,
+		`echo IDEA_VM_OPTIONS is /not/an/assignment`,
+		`setenv CLION_VM_OPTIONS /opt/clion.vmoptions`,
+	}, "\n")
+
+	got := parseEnvAssignments(content, names)
+	want := []envAssignment{
+		{Name: "IDEA_VM_OPTIONS", Value: "/opt/idea.vmoptions", Line: 1},
+		{Name: "WEBSTORM_VM_OPTIONS", Value: "~/webstorm.vmoptions", Line: 5},
+		{Name: "RIDER_VM_OPTIONS", Value: "/etc/rider.vmoptions", Line: 6},
+		{Name: "CLION_VM_OPTIONS", Value: "/opt/clion.vmoptions", Line: 9},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("期望 %d 个定义，实际 %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第 %d 个定义: 期望 %+v，实际 %+v", i, want[i], got[i])
+		}
+	}
+}
+
+// TestDisableEnvDefinitions 测试注释 shell 定义和移除 .desktop 中的赋值，并保留备份
+func TestDisableEnvDefinitions(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, ".profile")
+	desktop := filepath.Join(dir, "idea.desktop")
+
+	if err := os.WriteFile(profile, []byte("export PATH=$PATH:/bin\nexport IDEA_VM_OPTIONS=/tmp/idea.vmoptions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(desktop, []byte("[Desktop Entry]\nExec=env IDEA_VM_OPTIONS=/tmp/idea.vmoptions /opt/idea/bin/idea.sh %f\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defs := scanEnvSources([]envSource{
+		{Path: profile, Kind: EnvSourceShell, Scope: EnvScopeUser, Parser: parseEnvAssignments},
+		{Path: desktop, Kind: EnvSourceDesktop, Scope: EnvScopeUser, Parser: parseDesktopAssignments},
+	}, jetbrainsEnvVarNames())
+	if len(defs) != 2 {
+		t.Fatalf("期望 2 个定义，实际 %+v", defs)
+	}
+	if defs[0].Line != 2 || defs[0].TargetExists {
+		t.Errorf("定义信息不正确: %+v", defs[0])
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	disabled, warnings := disableEnvDefinitions(defs, logger)
+	if disabled != 2 || len(warnings) != 0 {
+		t.Fatalf("期望注释 2 处定义，实际 %d，警告 %v", disabled, warnings)
+	}
+
+	content, _ := os.ReadFile(profile)
+	if !strings.Contains(string(content), envDisabledPrefix+"export IDEA_VM_OPTIONS") {
+		t.Errorf("shell 定义应被注释:\n%s", content)
+	}
+	content, _ = os.ReadFile(desktop)
+	if !strings.Contains(string(content), "Exec=/opt/idea/bin/idea.sh %f") {
+		t.Errorf(".desktop 中的赋值应被移除:\n%s", content)
+	}
+
+	if backups := listBackups(profile); len(backups) != 1 {
+		t.Errorf("应创建 1 个备份，实际 %v", backups)
+	}
+}
+
+// TestDisableEnvDefinitionSymlink 测试注释符号链接指向的文件，不替换链接本身
+func TestDisableEnvDefinitionSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "dotfiles", "bashrc")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(target, []byte("export IDEA_VM_OPTIONS=/tmp/idea.vmoptions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, ".bashrc")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("无法创建符号链接: %v", err)
+	}
+
+	defs := scanEnvSources([]envSource{
+		{Path: link, Kind: EnvSourceShell, Scope: EnvScopeUser, Parser: parseEnvAssignments},
+	}, jetbrainsEnvVarNames())
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if disabled, warnings := disableEnvDefinitions(defs, logger); disabled != 1 || len(warnings) != 0 {
+		t.Fatalf("期望注释 1 处定义，实际 %d，警告 %v", disabled, warnings)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("符号链接应保留: %v", err)
+	}
+	if content, _ := os.ReadFile(target); !strings.HasPrefix(string(content), envDisabledPrefix) {
+		t.Errorf("链接指向的文件应被注释:\n%s", content)
+	}
+}
+
+// TestDisableCompoundEnvDefinition 测试同一行包含多条语句时跳过并给出警告
+func TestDisableCompoundEnvDefinition(t *testing.T) {
+	dir := t.TempDir()
+	profile := filepath.Join(dir, ".bashrc")
+	original := "export IDEA_VM_OPTIONS=/tmp/idea.vmoptions && source ~/.aliases\nexport GOLAND_VM_OPTIONS=\"/tmp/a;b.vmoptions\"\n"
+	if err := os.WriteFile(profile, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	defs := scanEnvSources([]envSource{
+		{Path: profile, Kind: EnvSourceShell, Scope: EnvScopeUser, Parser: parseEnvAssignments},
+	}, jetbrainsEnvVarNames())
+	if len(defs) != 2 {
+		t.Fatalf("期望 2 个定义，实际 %+v", defs)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	disabled, warnings := disableEnvDefinitions(defs, logger)
+	if disabled != 1 || len(warnings) != 1 || !strings.Contains(warnings[0], "IDEA_VM_OPTIONS") {
+		t.Fatalf("复合语句应跳过并警告，实际注释 %d，警告 %v", disabled, warnings)
+	}
+
+	content, _ := os.ReadFile(profile)
+	lines := strings.Split(string(content), "\n")
+	if lines[0] != "export IDEA_VM_OPTIONS=/tmp/idea.vmoptions && source ~/.aliases" {
+		t.Errorf("复合语句所在行不应被修改: %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], envDisabledPrefix) {
+		t.Errorf("引号中的分号不应视为复合语句: %q", lines[1])
+	}
+}
+
+// TestInspectEnvVars 测试生效值按进程、用户级、系统级的优先级确定
+func TestInspectEnvVars(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "idea.vmoptions")
+	if err := os.WriteFile(target, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plist := `<array>
+	<string>launchctl</string>
+	<string>setenv</string>
+	<string>GOLAND_VM_OPTIONS</string>
+	<string>/missing/goland.vmoptions</string>
+</array>`
+	launchd := parseLaunchdPlist(plist, jetbrainsEnvVarNames())
+	if len(launchd) != 1 || launchd[0].Name != "GOLAND_VM_OPTIONS" || launchd[0].Line != 4 {
+		t.Fatalf("plist 解析不正确: %+v", launchd)
+	}
+
+	defs := []EnvVarDefinition{
+		newEnvVarDefinition("IDEA_VM_OPTIONS", "/etc/idea.vmoptions", EnvScopeSystem, EnvSourcePam, "/etc/environment", 1),
+		newEnvVarDefinition("IDEA_VM_OPTIONS", target, EnvScopeUser, EnvSourceShell, "/home/u/.profile", 3),
+		newEnvVarDefinition(launchd[0].Name, launchd[0].Value, EnvScopeUser, EnvSourceLaunchd, "agent.plist", launchd[0].Line),
+	}
+	lookup := func(name string) (string, bool) {
+		if name == "GOLAND_VM_OPTIONS" {
+			return "/proc/goland.vmoptions", true
+		}
+		return "", false
+	}
+
+	result := inspectEnvVars([]string{"IDEA_VM_OPTIONS", "GOLAND_VM_OPTIONS", "CLION_VM_OPTIONS"}, defs, lookup)
+	if len(result) != 3 {
+		t.Fatalf("每个变量名都应返回结果，实际 %d", len(result))
+	}
+
+	idea := result[0]
+	if idea.EffectiveSource != EnvScopeUser || !idea.TargetExists || len(idea.System) != 1 {
+		t.Errorf("IDEA_VM_OPTIONS 检查结果不正确: %+v", idea)
+	}
+	goland := result[1]
+	if goland.EffectiveSource != "process" || goland.TargetExists {
+		t.Errorf("GOLAND_VM_OPTIONS 应以进程值为准: %+v", goland)
+	}
+	if result[2].EffectiveSource != "" {
+		t.Errorf("未定义的变量不应有生效值: %+v", result[2])
+	}
+}
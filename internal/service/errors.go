@@ -12,6 +12,55 @@ var (
 	ErrNoVMOptions      = errors.New("未找到任何 .vmoptions 文件")
 	ErrMissingJarFile   = errors.New("配置目录缺少 ja-netfilter.jar 文件")
 	ErrPermissionDenied = errors.New("权限不足")
+
+	ErrNoMatchingAsset    = errors.New("未找到适用于当前平台的安装包")
+	ErrChecksumMismatch   = errors.New("安装包 SHA-256 校验失败")
+	ErrDownloadInProgress = errors.New("已有下载任务正在进行")
+	ErrNoReleaseAvailable = errors.New("未找到可用的 Release")
+	ErrNoNewerRelease     = errors.New("没有比当前版本更新的 Release")
+
+	ErrSelfUpdateUnsupported = errors.New("当前安装方式不支持应用内更新")
+	ErrHealthCheckFailed     = errors.New("新版本健康检查失败")
+	ErrManifestMissing       = errors.New("Release 未提供签名清单")
+	ErrManifestSignature     = errors.New("Release 清单签名验证失败")
+	ErrSigningKeyMissing     = errors.New("未配置 Release 签名公钥，无法使用应用内更新")
+
+	ErrInvalidMirrorURL = errors.New("镜像地址无效")
+	ErrInvalidProxy     = errors.New("代理设置无效")
+
+	ErrInvalidSettings     = errors.New("设置无效")
+	ErrSettingsTooNew      = errors.New("设置文件由更新版本的应用创建")
+	ErrSettingsUnavailable = errors.New("设置文件不可用")
+	ErrFileLocked          = errors.New("文件正被其他实例使用")
+
+	ErrProductInfoMissing     = errors.New("安装目录缺少 product-info.json")
+	ErrInstallationNotFound   = errors.New("未找到已保存的安装")
+	ErrInstallationExists     = errors.New("该安装已保存")
+	ErrNoInstallationSelected = errors.New("未选择任何 IDE 安装")
+	ErrInvalidCatalog         = errors.New("产品目录格式无效")
+
+	ErrConfigDirUnknown       = errors.New("无法确定 IDE 的用户配置目录")
+	ErrInvalidPropertiesScope = errors.New("无效的 idea.properties 位置")
+	ErrInvalidProperty        = errors.New("属性无效")
+
+	ErrInvalidRelocation        = errors.New("目录迁移无效")
+	ErrRelocationTargetNotEmpty = errors.New("目标目录不为空")
+	ErrRelocationVerify         = errors.New("迁移后的文件校验失败")
+	ErrRelocationNotFound       = errors.New("未找到迁移记录")
+
+	ErrInvalidRuntime      = errors.New("不是有效的 JDK/JBR 目录")
+	ErrRuntimeIncompatible = errors.New("运行时版本不满足 IDE 要求")
+
+	ErrIDERunning = errors.New("IDE 正在运行，请先关闭后再操作")
+
+	ErrRuntimeNotFound    = errors.New("找不到 IDE 使用的 Java 运行时")
+	ErrVerificationFailed = errors.New("JVM 参数验证失败")
+
+	ErrInvalidOption = errors.New("不是有效的 JVM 参数")
+	ErrMemoryUnknown = errors.New("无法读取系统内存信息")
+	ErrInvalidPlugin = errors.New("插件无效")
+
+	ErrInvalidProfile = errors.New("调优配置文件无效")
 )
 
 // toolAddedLines 定义本工具添加的特定配置行（使用包级变量避免重复创建）
@@ -0,0 +1,21 @@
+package service
+
+// EventEmitter 定义向前端推送事件的函数签名
+// 由 main 包在创建 Wails 应用后注入，service 包本身不依赖 Wails 运行时
+type EventEmitter func(name string, data any)
+
+// 前端可订阅的事件名称
+const (
+	EventUpdateDownloadProgress = "update:download:progress"
+	EventSettingsChanged        = "settings:changed"
+	EventRelocationProgress     = "relocation:progress"
+	EventManagedOptionsLost     = "installations:options-lost"
+)
+
+// emit 推送事件，未设置推送函数时静默忽略
+func (c *ConfigService) emit(name string, data any) {
+	if c.emitter == nil {
+		return
+	}
+	c.emitter(name, data)
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	backupSuffix     = ".bak"
+	backupTimeFormat = "20060102-150405.000000"
+	backupsToKeep    = 5
+)
+
+// backupFile 在原文件旁创建带时间戳的备份（如 idea64.vmoptions.20240101-120000.000000.bak），并清理过旧的备份
+// 文件不存在时不创建备份，返回空路径
+func backupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil
+		}
+		return "", fmt.Errorf("读取待备份文件失败: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("获取文件权限失败: %w", err)
+	}
+
+	backupPath := fmt.Sprintf("%s.%s%s", path, time.Now().Format(backupTimeFormat), backupSuffix)
+	if err := os.WriteFile(backupPath, data, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("创建备份失败: %w", err)
+	}
+
+	pruneBackups(path)
+	return backupPath, nil
+}
+
+// listBackups 返回文件的所有备份，按时间从新到旧排列
+func listBackups(path string) []string {
+	matches, _ := filepath.Glob(globEscape(path) + ".*" + backupSuffix)
+	matches = slices.DeleteFunc(matches, func(m string) bool {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, path+"."), backupSuffix)
+		_, err := time.Parse(backupTimeFormat, stamp)
+		return err != nil
+	})
+	slices.Sort(matches)
+	slices.Reverse(matches)
+	return matches
+}
+
+// pruneBackups 只保留最近的 backupsToKeep 个备份
+func pruneBackups(path string) {
+	backups := listBackups(path)
+	if len(backups) <= backupsToKeep {
+		return
+	}
+	for _, old := range backups[backupsToKeep:] {
+		_ = os.Remove(old)
+	}
+}
+
+// globEscape 转义路径中的通配符，避免 filepath.Glob 误匹配
+func globEscape(path string) string {
+	replacer := strings.NewReplacer("*", `\*`, "?", `\?`, "[", `\[`)
+	if filepath.Separator == '\\' {
+		// Windows 上反斜杠是路径分隔符，不能用作转义字符
+		replacer = strings.NewReplacer("*", "[*]", "?", "[?]", "[", "[[]")
+	}
+	return replacer.Replace(path)
+}
+
+// writeFileAtomic 先写入同目录下的临时文件再重命名，避免写入中断导致文件损坏
+// 文件已存在时保留原有权限
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("替换文件失败: %w", err)
+	}
+	return nil
+}
+
+// writeFileWithBackup 备份原文件后以原子方式写入新内容，返回备份路径
+func writeFileWithBackup(path string, data []byte, perm os.FileMode) (string, error) {
+	backupPath, err := backupFile(path)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(path, data, perm); err != nil {
+		return backupPath, err
+	}
+	return backupPath, nil
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+// +build !windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile 对 path 加排他锁（flock），用于多个应用实例之间互斥读写同一文件
+// 超过 fileLockTimeout 仍未获得锁时返回 ErrFileLocked，返回的函数用于释放锁
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) || time.Now().After(deadline) {
+			file.Close()
+			if errors.Is(err, unix.EWOULDBLOCK) {
+				return nil, fmt.Errorf("%w: %s", ErrFileLocked, path)
+			}
+			return nil, fmt.Errorf("加锁失败: %w", err)
+		}
+		time.Sleep(fileLockRetryInterval)
+	}
+
+	return func() {
+		_ = unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		file.Close()
+	}, nil
+}
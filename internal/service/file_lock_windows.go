@@ -0,0 +1,46 @@
+//go:build windows
+// +build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile 对 path 加排他锁（LockFileEx），用于多个应用实例之间互斥读写同一文件
+// 超过 fileLockTimeout 仍未获得锁时返回 ErrFileLocked，返回的函数用于释放锁
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+
+	handle := windows.Handle(file.Fd())
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		overlapped := new(windows.Overlapped)
+		err := windows.LockFileEx(handle, flags, 0, 1, 0, overlapped)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, windows.ERROR_LOCK_VIOLATION) || time.Now().After(deadline) {
+			file.Close()
+			if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+				return nil, fmt.Errorf("%w: %s", ErrFileLocked, path)
+			}
+			return nil, fmt.Errorf("加锁失败: %w", err)
+		}
+		time.Sleep(fileLockRetryInterval)
+	}
+
+	return func() {
+		_ = windows.UnlockFileEx(handle, 0, 1, 0, new(windows.Overlapped))
+		file.Close()
+	}, nil
+}
@@ -39,7 +39,8 @@ type LineProcessor func(string) bool
 
 // processVMOptionsFileGeneric 通用的 vmoptions 文件处理函数
 // 避免 processVMOptionsFile 和 clearVMOptionsFile 中的代码重复
-func processVMOptionsFileGeneric(filePath string, processor LineProcessor, logger *slog.Logger) error {
+// 删除 processor 匹配的行并在末尾追加 appendLines，修改前备份原文件并以原子方式写入
+func processVMOptionsFileGeneric(filePath string, processor LineProcessor, appendLines []string, logger *slog.Logger) error {
 	// 检查文件权限
 	if err := checkFileReadPermission(filePath); err != nil {
 		return err
@@ -59,18 +60,28 @@ func processVMOptionsFileGeneric(filePath string, processor LineProcessor, logge
 	lines := strings.Split(string(content), "\n")
 	newLines := slices.DeleteFunc(lines, processor)
 
-	// 写回文件
 	newContent := strings.Join(newLines, "\n")
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return fmt.Errorf("获取文件权限失败: %w", err)
+	if len(appendLines) > 0 {
+		if newContent != "" && !strings.HasSuffix(newContent, "\n") {
+			newContent += "\n"
+		}
+		newContent += strings.Join(appendLines, "\n") + "\n"
 	}
 
-	if err := os.WriteFile(filePath, []byte(newContent), info.Mode().Perm()); err != nil {
+	if newContent == string(content) {
+		logger.Debug("文件无需修改", slog.String("file", filepath.Base(filePath)))
+		return nil
+	}
+
+	// 写回文件
+	backupPath, err := writeFileWithBackup(filePath, []byte(newContent), 0644)
+	if err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
-	logger.Debug("成功更新文件", slog.String("file", filepath.Base(filePath)))
+	logger.Debug("成功更新文件",
+		slog.String("file", filepath.Base(filePath)),
+		slog.String("backup", backupPath))
 	return nil
 }
 
@@ -86,27 +97,15 @@ func processVMOptionsFile(filePath, configPath string, logger *slog.Logger) erro
 		return shouldDelete
 	}
 
-	if err := processVMOptionsFileGeneric(filePath, processor, logger); err != nil {
-		return err
-	}
-
 	// 添加新的配置
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %w", err)
-	}
-	defer file.Close()
-
 	newConfigs := []string{
 		"--add-opens=java.base/jdk.internal.org.objectweb.asm=ALL-UNNAMED",
 		"--add-opens=java.base/jdk.internal.org.objectweb.asm.tree=ALL-UNNAMED",
 		fmt.Sprintf("-javaagent:\"%s/ja-netfilter.jar\"=jetbrains", configPath),
 	}
 
-	for _, config := range newConfigs {
-		if _, err := file.WriteString(config + "\n"); err != nil {
-			return fmt.Errorf("写入配置失败: %w", err)
-		}
+	if err := processVMOptionsFileGeneric(filePath, processor, newConfigs, logger); err != nil {
+		return err
 	}
 
 	logger.Debug("添加配置",
@@ -124,17 +123,7 @@ func clearVMOptionsFile(filePath string, logger *slog.Logger) error {
 	processor := func(line string) bool {
 		trimmed := strings.TrimSpace(line)
 
-		// 只删除本工具添加的特定 --add-opens 配置
-		if _, exists := toolAddedLines[trimmed]; exists {
-			logger.Debug("删除行", slog.String("line", trimmed))
-			removedCount++
-			return true
-		}
-
-		// 只删除包含 ja-netfilter.jar 和 jetbrains 的 javaagent 配置（兼容有引号和无引号格式）
-		if strings.HasPrefix(trimmed, "-javaagent:") &&
-			strings.Contains(trimmed, "ja-netfilter.jar") &&
-			strings.Contains(trimmed, "jetbrains") {
+		if isToolAddedLine(trimmed) {
 			logger.Debug("删除行", slog.String("line", trimmed))
 			removedCount++
 			return true
@@ -143,7 +132,7 @@ func clearVMOptionsFile(filePath string, logger *slog.Logger) error {
 		return false
 	}
 
-	if err := processVMOptionsFileGeneric(filePath, processor, logger); err != nil {
+	if err := processVMOptionsFileGeneric(filePath, processor, nil, logger); err != nil {
 		return err
 	}
 
@@ -153,6 +142,24 @@ func clearVMOptionsFile(filePath string, logger *slog.Logger) error {
 	return nil
 }
 
+// isToolAddedLine 判断一行（已去除首尾空白）是否为本工具添加的配置
+func isToolAddedLine(trimmed string) bool {
+	// 本工具添加的特定 --add-opens 配置
+	if _, exists := toolAddedLines[trimmed]; exists {
+		return true
+	}
+
+	// 包含 ja-netfilter.jar 和 jetbrains 的 javaagent 配置（兼容有引号和无引号格式）
+	return isToolAgentLine(trimmed)
+}
+
+// isToolAgentLine 判断一行是否为本工具添加的 javaagent 配置
+func isToolAgentLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "-javaagent:") &&
+		strings.Contains(trimmed, "ja-netfilter.jar") &&
+		strings.Contains(trimmed, "jetbrains")
+}
+
 // trimTrailingEmptyLines 使用 Go 1.23 slices.Backward 移除尾部空行
 func trimTrailingEmptyLines(lines []string) []string {
 	trimCount := 0
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// IDE 目录类型及其在 idea.properties 中对应的属性
+const (
+	IDEDirConfig  = "config"
+	IDEDirSystem  = "system"
+	IDEDirPlugins = "plugins"
+	IDEDirLog     = "log"
+)
+
+var ideDirProperties = map[string]string{
+	IDEDirConfig:  "idea.config.path",
+	IDEDirSystem:  "idea.system.path",
+	IDEDirPlugins: "idea.plugins.path",
+	IDEDirLog:     "idea.log.path",
+}
+
+// ideDirKinds 按固定顺序列出目录类型
+var ideDirKinds = []string{IDEDirConfig, IDEDirSystem, IDEDirPlugins, IDEDirLog}
+
+// IDEDirectory 描述 IDE 的一个数据目录及其当前位置
+type IDEDirectory struct {
+	Kind     string `json:"kind"`
+	Property string `json:"property"`
+	Path     string `json:"path"`
+	Custom   bool   `json:"custom"`
+	Source   string `json:"source"`
+	Exists   bool   `json:"exists"`
+	Size     int64  `json:"size"`
+}
+
+// ideDirLocations 计算安装的 config/system/plugins/log 目录的当前位置
+// 用户级 idea.properties 优先于 bin 目录下的 idea.properties，未设置时使用平台默认位置
+func ideDirLocations(home string) ([]IDEDirectory, error) {
+	configDir, err := ideConfigDir(home)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(configDir)
+
+	// 读取两处 idea.properties 中的路径设置，用户级优先
+	custom := make(map[string]IDEDirectory)
+	for _, scope := range []string{PropertiesScopeBin, PropertiesScopeUser} {
+		path, err := propertiesFilePath(home, scope)
+		if err != nil {
+			continue
+		}
+		doc, exists, err := readPropertiesDocument(path)
+		if err != nil || !exists {
+			continue
+		}
+		for kind, property := range ideDirProperties {
+			if value, ok := doc.get(property); ok && strings.TrimSpace(value) != "" {
+				custom[kind] = IDEDirectory{Path: value, Source: scope}
+			}
+		}
+	}
+
+	userHome, _ := os.UserHomeDir()
+	vars := map[string]string{"user.home": userHome, "idea.home.path": home}
+	resolve := func(kind, fallback string) IDEDirectory {
+		dir := IDEDirectory{Kind: kind, Property: ideDirProperties[kind], Path: fallback, Source: "default"}
+		if c, ok := custom[kind]; ok {
+			dir.Path = expandIDEPath(c.Path, vars)
+			dir.Custom = true
+			dir.Source = c.Source
+		}
+		vars[dir.Property] = dir.Path
+		return dir
+	}
+
+	config := resolve(IDEDirConfig, configDir)
+	system := resolve(IDEDirSystem, defaultSystemDir(name))
+
+	// 自定义了 config/system 目录时，plugins/log 默认位于其子目录中
+	pluginsDefault := defaultPluginsDir(name, config.Path)
+	if config.Custom {
+		pluginsDefault = filepath.Join(config.Path, "plugins")
+	}
+	logDefault := defaultLogDir(name, system.Path)
+	if system.Custom {
+		logDefault = filepath.Join(system.Path, "log")
+	}
+
+	dirs := []IDEDirectory{config, system, resolve(IDEDirPlugins, pluginsDefault), resolve(IDEDirLog, logDefault)}
+	for i := range dirs {
+		if _, err := os.Stat(dirs[i].Path); err == nil {
+			dirs[i].Exists = true
+		}
+	}
+	return dirs, nil
+}
+
+// defaultSystemDir 返回平台默认的 system（缓存）目录
+func defaultSystemDir(name string) string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	// Windows 上 UserCacheDir 为 %LOCALAPPDATA%，与 IDE 的默认位置一致
+	return filepath.Join(cacheDir, "JetBrains", name)
+}
+
+// defaultPluginsDir 返回平台默认的 plugins 目录
+func defaultPluginsDir(name, configDir string) string {
+	if runtime.GOOS == "linux" {
+		if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+			return filepath.Join(dataHome, "JetBrains", name)
+		}
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, ".local", "share", "JetBrains", name)
+		}
+	}
+	return filepath.Join(configDir, "plugins")
+}
+
+// defaultLogDir 返回平台默认的 log 目录
+func defaultLogDir(name, systemDir string) string {
+	if runtime.GOOS == "darwin" {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, "Library", "Logs", "JetBrains", name)
+		}
+	}
+	return filepath.Join(systemDir, "log")
+}
+
+// expandIDEPath 展开 idea.properties 路径中的 ${user.home} 等变量和 ~
+func expandIDEPath(value string, vars map[string]string) string {
+	value = strings.TrimSpace(value)
+	if value == "~" || strings.HasPrefix(value, "~/") {
+		value = "${user.home}" + strings.TrimPrefix(value, "~")
+	}
+	for name, replacement := range vars {
+		value = strings.ReplaceAll(value, "${"+name+"}", replacement)
+	}
+	return filepath.Clean(filepath.FromSlash(value))
+}
+
+// dirSize 统计目录中所有普通文件的大小
+func dirSize(root string) (int64, int, error) {
+	var size int64
+	var count int
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+			count++
+		}
+		return nil
+	})
+	return size, count, err
+}
+
+// GetIDEDirectories 返回 IDE 的 config、system、plugins 和 log 目录的当前位置及大小
+func (c *ConfigService) GetIDEDirectories(installPath string) ([]IDEDirectory, error) {
+	dirs, err := ideDirLocations(installationHome(installPath))
+	if err != nil {
+		return nil, err
+	}
+	for i := range dirs {
+		if dirs[i].Exists {
+			dirs[i].Size, _, _ = dirSize(dirs[i].Path)
+		}
+	}
+	return dirs, nil
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 已保存安装的健康状态
+const (
+	InstallationStatusOK      = "ok"      // 安装有效
+	InstallationStatusMoved   = "moved"   // 原路径不存在，已在其他位置找到同一产品
+	InstallationStatusMissing = "missing" // 原路径不存在且未找到新位置
+	InstallationStatusInvalid = "invalid" // 路径存在但不是有效的 IDE 安装目录
+)
+
+// 安装来源
+const (
+	InstallationSourceManual     = "manual"
+	InstallationSourceDiscovered = "discovered"
+)
+
+// SavedInstallation 保存用户登记的 IDE 安装及其最近一次检查结果
+type SavedInstallation struct {
+	ID          string `json:"id"`
+	Label       string `json:"label"`
+	Path        string `json:"path"`
+	Source      string `json:"source"`
+	ProductCode string `json:"productCode"`
+	ProductName string `json:"productName"`
+	Version     string `json:"version"`
+	BuildNumber string `json:"buildNumber"`
+	AddedAt     string `json:"addedAt"`
+
+	// ManagedConfigPath 为最近一次通过本工具应用到该安装的配置目录，清除配置后为空
+	ManagedConfigPath string `json:"managedConfigPath"`
+
+	Status        string `json:"status"`
+	LastCheckedAt string `json:"lastCheckedAt"`
+	PreviousPath  string `json:"previousPath,omitempty"`
+	// Upgraded 和 PreviousVersion 在检测到升级后保留，用户重新应用或清除配置后清空
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	Upgraded        bool   `json:"upgraded"`
+	VMOptionsReset  bool   `json:"vmOptionsReset"`
+	Message         string `json:"message,omitempty"`
+}
+
+// newInstallationID 生成安装记录的唯一标识
+func newInstallationID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// hasManagedVMOptions 检查 bin 目录中的 vmoptions 文件是否仍包含本工具添加的 javaagent 配置
+func hasManagedVMOptions(binDir string) (bool, error) {
+	files, err := findVMOptionsFiles(binDir)
+	if err != nil {
+		return false, err
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return false, fmt.Errorf("读取文件失败: %w", err)
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if isToolAgentLine(strings.TrimSpace(line)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkInstallation 重新验证单个安装，返回更新后的记录
+// discovered 在原路径失效时用于查找移动后的位置，按需扫描并在多次调用间复用
+func checkInstallation(inst SavedInstallation, discovered func() []DiscoveredInstallation, registered []string) SavedInstallation {
+	inst.LastCheckedAt = time.Now().Format(time.RFC3339)
+	inst.Message = ""
+
+	binDir, err := validateIntelliJPath(inst.Path)
+	if err != nil {
+		if _, statErr := os.Stat(inst.Path); !errors.Is(statErr, fs.ErrNotExist) {
+			inst.Status = InstallationStatusInvalid
+			inst.Message = err.Error()
+			return inst
+		}
+
+		// 原路径已不存在，尝试在常见位置查找同一产品的安装
+		for _, candidate := range discovered() {
+			if candidate.ProductCode != inst.ProductCode || slices.Contains(registered, candidate.Path) {
+				continue
+			}
+			if candidate.BuildNumber == inst.BuildNumber || compareVersions(candidate.BuildNumber, inst.BuildNumber) > 0 {
+				inst.PreviousPath = inst.Path
+				inst.Path = candidate.Path
+				inst.Status = InstallationStatusMoved
+				binDir, err = validateIntelliJPath(candidate.Path)
+				break
+			}
+		}
+
+		if inst.Status != InstallationStatusMoved || err != nil {
+			inst.Status = InstallationStatusMissing
+			inst.Message = "安装目录不存在"
+			return inst
+		}
+	} else {
+		inst.Status = InstallationStatusOK
+	}
+
+	if info, err := readProductInfo(inst.Path); err == nil {
+		if inst.BuildNumber != "" && info.BuildNumber != inst.BuildNumber {
+			inst.Upgraded = true
+			inst.PreviousVersion = inst.Version
+		}
+		inst.ProductCode = info.ProductCode
+		inst.ProductName = info.displayName()
+		inst.Version = info.Version
+		inst.BuildNumber = info.BuildNumber
+	} else if product, ok := productForBinDir(binDir); ok && inst.ProductCode == "" {
+		// 旧版本 IDE 没有 product-info.json，根据 bin 目录中的文件名识别产品
+		inst.ProductCode = product.Code
+		inst.ProductName = product.Name
+	}
+
+	// IDE 更新会覆盖 bin 目录下的 vmoptions 文件，导致本工具添加的配置丢失
+	if inst.ManagedConfigPath != "" {
+		managed, err := hasManagedVMOptions(binDir)
+		if err != nil {
+			inst.Message = err.Error()
+		}
+		inst.VMOptionsReset = err == nil && !managed
+	} else {
+		inst.VMOptionsReset = false
+	}
+
+	return inst
+}
+
+// findInstallation 按 ID 查找已保存的安装
+func (c *ConfigService) findInstallation(id string) (SavedInstallation, error) {
+	for _, inst := range c.currentSettings().Installations {
+		if inst.ID == id {
+			return inst, nil
+		}
+	}
+	return SavedInstallation{}, fmt.Errorf("%w: %s", ErrInstallationNotFound, id)
+}
+
+// ListInstallations 返回已保存的 IDE 安装
+func (c *ConfigService) ListInstallations() []SavedInstallation {
+	return c.currentSettings().Installations
+}
+
+// AddInstallation 验证并保存一个 IDE 安装，label 为空时使用产品名称和版本
+func (c *ConfigService) AddInstallation(path, label string) (SavedInstallation, error) {
+	return c.addInstallation(path, label, InstallationSourceManual)
+}
+
+// AddDiscoveredInstallations 扫描常见安装位置，保存所有尚未登记的安装
+func (c *ConfigService) AddDiscoveredInstallations() ([]SavedInstallation, error) {
+	var added []SavedInstallation
+	for _, found := range c.DiscoverInstallations() {
+		inst, err := c.addInstallation(found.Path, "", InstallationSourceDiscovered)
+		if errors.Is(err, ErrInstallationExists) {
+			continue
+		}
+		if err != nil {
+			return added, err
+		}
+		added = append(added, inst)
+	}
+	return added, nil
+}
+
+// addInstallation 验证路径并保存安装记录
+func (c *ConfigService) addInstallation(path, label, source string) (SavedInstallation, error) {
+	home := installationHome(path)
+	if home == "" {
+		return SavedInstallation{}, ErrEmptyPath
+	}
+
+	if _, err := validateIntelliJPath(home); err != nil {
+		return SavedInstallation{}, err
+	}
+
+	inst := SavedInstallation{
+		ID:      newInstallationID(),
+		Label:   strings.TrimSpace(label),
+		Path:    home,
+		Source:  source,
+		AddedAt: time.Now().Format(time.RFC3339),
+	}
+	inst = checkInstallation(inst, func() []DiscoveredInstallation { return nil }, nil)
+	if inst.Label == "" {
+		inst.Label = strings.TrimSpace(inst.ProductName + " " + inst.Version)
+		if inst.Label == "" {
+			inst.Label = filepath.Base(home)
+		}
+	}
+
+	// 已应用过本工具配置的安装直接记录配置目录，便于后续检测配置是否被 IDE 更新覆盖
+	if binDir, err := validateIntelliJPath(home); err == nil {
+		if managed, _ := hasManagedVMOptions(binDir); managed {
+			inst.ManagedConfigPath = c.currentSettings().Paths.LastConfigPath
+		}
+	}
+
+	_, err := c.updateSettings(func(s *AppSettings) error {
+		for _, existing := range s.Installations {
+			if existing.Path == home {
+				return fmt.Errorf("%w: %s", ErrInstallationExists, home)
+			}
+		}
+		s.Installations = append(s.Installations, inst)
+		return nil
+	})
+	if err != nil {
+		return SavedInstallation{}, err
+	}
+
+	c.logger.Info("已保存 IDE 安装",
+		slog.String("path", home),
+		slog.String("product", inst.ProductCode),
+		slog.String("source", source))
+	return inst, nil
+}
+
+// RemoveInstallation 删除已保存的安装记录（不会删除磁盘上的文件）
+func (c *ConfigService) RemoveInstallation(id string) error {
+	_, err := c.updateSettings(func(s *AppSettings) error {
+		index := slices.IndexFunc(s.Installations, func(inst SavedInstallation) bool { return inst.ID == id })
+		if index < 0 {
+			return fmt.Errorf("%w: %s", ErrInstallationNotFound, id)
+		}
+		s.Installations = slices.Delete(s.Installations, index, index+1)
+		return nil
+	})
+	return err
+}
+
+// RenameInstallation 修改安装记录的标签
+func (c *ConfigService) RenameInstallation(id, label string) (SavedInstallation, error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return SavedInstallation{}, fmt.Errorf("%w: 标签不能为空", ErrInvalidSettings)
+	}
+
+	var renamed SavedInstallation
+	_, err := c.updateSettings(func(s *AppSettings) error {
+		for i := range s.Installations {
+			if s.Installations[i].ID == id {
+				s.Installations[i].Label = label
+				renamed = s.Installations[i]
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrInstallationNotFound, id)
+	})
+	return renamed, err
+}
+
+// RefreshInstallations 重新验证所有已保存的安装
+// 检测安装被移动或删除、IDE 版本升级，以及 vmoptions 中本工具的配置是否被更新覆盖
+func (c *ConfigService) RefreshInstallations() ([]SavedInstallation, error) {
+	return c.refreshInstallations(discoveryRoots())
+}
+
+// refreshInstallations 使用指定的扫描目录重新验证所有已保存的安装
+func (c *ConfigService) refreshInstallations(roots []string) ([]SavedInstallation, error) {
+	current := c.currentSettings().Installations
+
+	var discovered []DiscoveredInstallation
+	scanned := false
+	discover := func() []DiscoveredInstallation {
+		if !scanned {
+			discovered = discoverInstallations(roots, discoveryMaxDepth)
+			scanned = true
+		}
+		return discovered
+	}
+
+	registered := make([]string, 0, len(current))
+	for _, inst := range current {
+		registered = append(registered, inst.Path)
+	}
+
+	checked := make(map[string]SavedInstallation, len(current))
+	for _, inst := range current {
+		updated := checkInstallation(inst, discover, registered)
+		if updated.Status == InstallationStatusMoved {
+			registered = append(registered, updated.Path)
+		}
+		checked[inst.ID] = updated
+	}
+
+	settings, err := c.updateSettings(func(s *AppSettings) error {
+		for i, inst := range s.Installations {
+			if updated, ok := checked[inst.ID]; ok {
+				// 保留检查期间用户修改的标签
+				updated.Label = inst.Label
+				s.Installations[i] = updated
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Info("已刷新 IDE 安装状态", slog.Int("count", len(settings.Installations)))
+	return settings.Installations, nil
+}
+
+// lockInstallation 锁定安装的 vmoptions 文件，串行化用户操作与后台自动重新应用，返回解锁函数
+func (c *ConfigService) lockInstallation(projectPath string) func() {
+	value, _ := c.installLocks.LoadOrStore(installationHome(projectPath), &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// applyManagedConfig 在安装锁内写入（configPath 非空）或清除本工具添加的配置，并更新安装记录中的托管状态
+func (c *ConfigService) applyManagedConfig(projectPath, configPath, operationName string) (int, string, error) {
+	defer c.lockInstallation(projectPath)()
+	return c.applyManagedConfigLocked(projectPath, configPath, operationName)
+}
+
+// applyManagedConfigLocked 与 applyManagedConfig 相同，调用方需已持有安装锁
+func (c *ConfigService) applyManagedConfigLocked(projectPath, configPath, operationName string) (int, string, error) {
+	count, notice, err := c.editVMOptionsVerified(projectPath, func(vmFile string) error {
+		if configPath == "" {
+			return clearVMOptionsFile(vmFile, c.logger)
+		}
+		return processVMOptionsFile(vmFile, configPath, c.logger)
+	}, operationName)
+	if err == nil {
+		c.markInstallationManaged(projectPath, configPath)
+	}
+	return count, notice, err
+}
+
+// markInstallationManaged 记录安装最近一次应用的配置目录，configPath 为空表示已清除配置
+func (c *ConfigService) markInstallationManaged(projectPath, configPath string) {
+	home := installationHome(projectPath)
+	if !slices.ContainsFunc(c.currentSettings().Installations, func(inst SavedInstallation) bool {
+		return inst.Path == home
+	}) {
+		return
+	}
+
+	_, err := c.updateSettings(func(s *AppSettings) error {
+		for i := range s.Installations {
+			if s.Installations[i].Path == home {
+				s.Installations[i].ManagedConfigPath = configPath
+				s.Installations[i].VMOptionsReset = false
+				// 用户已针对升级后的版本重新处理配置，不再提示升级
+				s.Installations[i].Upgraded = false
+				s.Installations[i].PreviousVersion = ""
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("更新安装记录失败", slog.Any("error", err))
+	}
+}
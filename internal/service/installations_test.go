@@ -0,0 +1,272 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createTestInstallation 创建包含 product-info.json 和 vmoptions 的模拟 IDE 安装目录
+func createTestInstallation(t *testing.T, home, code, build, vmoptions string) {
+	t.Helper()
+
+	binDir := filepath.Join(home, "bin")
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(binDir, "idea64.vmoptions"), []byte(vmoptions), 0644); err != nil {
+		t.Fatalf("写入 vmoptions 失败: %v", err)
+	}
+
+	info := `{"name":"IntelliJ IDEA","version":"` + build + `","buildNumber":"` + build + `","productCode":"` + code + `"}`
+	if err := os.WriteFile(filepath.Join(home, productInfoFileName), []byte(info), 0644); err != nil {
+		t.Fatalf("写入 product-info.json 失败: %v", err)
+	}
+}
+
+// TestRefreshInstallations 测试安装被移动、升级和 vmoptions 被覆盖的检测
+func TestRefreshInstallations(t *testing.T) {
+	root := t.TempDir()
+	svc := NewConfigService(WithSettingsPath(filepath.Join(t.TempDir(), settingsFileName)))
+
+	managedOptions := "-Xmx2048m\n-javaagent:/cfg/ja-netfilter.jar=jetbrains\n"
+
+	upgraded := filepath.Join(root, "idea-upgraded")
+	moved := filepath.Join(root, "goland-old")
+	createTestInstallation(t, upgraded, "IU", "241.1", managedOptions)
+	createTestInstallation(t, moved, "GO", "241.2", "-Xmx2048m\n")
+
+	first, err := svc.AddInstallation(upgraded, "")
+	if err != nil {
+		t.Fatalf("保存安装失败: %v", err)
+	}
+	if first.Label != "IntelliJ IDEA Ultimate 241.1" {
+		t.Errorf("默认标签不正确: %q", first.Label)
+	}
+	svc.markInstallationManaged(upgraded, "/cfg")
+
+	second, err := svc.AddInstallation(filepath.Join(moved, "bin"), "GoLand")
+	if err != nil {
+		t.Fatalf("保存安装失败: %v", err)
+	}
+	if _, err := svc.AddInstallation(moved, ""); !errors.Is(err, ErrInstallationExists) {
+		t.Errorf("重复保存应返回 ErrInstallationExists，实际 %v", err)
+	}
+
+	// 模拟 IDE 升级覆盖 vmoptions，以及安装目录被移动
+	createTestInstallation(t, upgraded, "IU", "242.1", "-Xmx2048m\n")
+	newLocation := filepath.Join(root, "apps", "goland")
+	if err := os.MkdirAll(filepath.Dir(newLocation), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(moved, newLocation); err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := svc.refreshInstallations([]string{root})
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	if len(refreshed) != 2 {
+		t.Fatalf("期望 2 个安装，实际 %d", len(refreshed))
+	}
+
+	byID := map[string]SavedInstallation{}
+	for _, inst := range refreshed {
+		byID[inst.ID] = inst
+	}
+
+	idea := byID[first.ID]
+	if idea.Status != InstallationStatusOK || !idea.Upgraded || idea.PreviousVersion != "241.1" || !idea.VMOptionsReset {
+		t.Errorf("升级检测不正确: %+v", idea)
+	}
+	svc.markInstallationManaged(upgraded, "/cfg")
+	if idea, _ := svc.findInstallation(first.ID); idea.Upgraded || idea.PreviousVersion != "" {
+		t.Errorf("重新应用配置后应清除升级标记: %+v", idea)
+	}
+
+	goland := byID[second.ID]
+	if goland.Status != InstallationStatusMoved || goland.Path != newLocation || goland.PreviousPath != moved {
+		t.Errorf("移动检测不正确: %+v", goland)
+	}
+
+	if err := os.RemoveAll(newLocation); err != nil {
+		t.Fatal(err)
+	}
+	refreshed, err = svc.refreshInstallations([]string{root})
+	if err != nil {
+		t.Fatalf("刷新失败: %v", err)
+	}
+	for _, inst := range refreshed {
+		if inst.ID == second.ID && inst.Status != InstallationStatusMissing {
+			t.Errorf("删除的安装应标记为 missing，实际 %s", inst.Status)
+		}
+	}
+
+	if err := svc.RemoveInstallation(second.ID); err != nil {
+		t.Errorf("删除安装记录失败: %v", err)
+	}
+	if got := len(svc.ListInstallations()); got != 1 {
+		t.Errorf("删除后应剩余 1 个安装，实际 %d", got)
+	}
+}
+
+// TestSubmitPathsBatch 测试按产品批量应用配置时单个安装失败不影响其他安装
+func TestSubmitPathsBatch(t *testing.T) {
+	// 用户级和系统级环境变量来源都指向临时目录，避免扫描并修改真实的 shell 配置文件和 /etc
+	t.Setenv("HOME", t.TempDir())
+	envRoot := t.TempDir()
+	environment := filepath.Join(envRoot, "etc", "environment")
+	if err := os.MkdirAll(filepath.Dir(environment), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(environment, []byte("GOLAND_VM_OPTIONS=/opt/goland.vmoptions\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	svc := NewConfigService(
+		WithSettingsPath(filepath.Join(t.TempDir(), settingsFileName)),
+		WithEnvRoot(envRoot))
+
+	configDir := filepath.Join(root, "config")
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "ja-netfilter.jar"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"goland-1", "goland-2", "idea"} {
+		code := "GO"
+		if name == "idea" {
+			code = "IU"
+		}
+		createTestInstallation(t, filepath.Join(root, name), code, "241.1", "-Xmx2048m\n")
+		if _, err := svc.AddInstallation(filepath.Join(root, name), ""); err != nil {
+			t.Fatalf("保存安装失败: %v", err)
+		}
+	}
+
+	// 删除其中一个安装的 vmoptions 使其处理失败
+	if err := os.Remove(filepath.Join(root, "goland-2", "bin", "idea64.vmoptions")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := svc.SubmitPathsBatch(InstallationSelector{ProductCode: "go"}, configDir)
+	if err != nil {
+		t.Fatalf("批量应用失败: %v", err)
+	}
+	if report.Total != 2 || report.Succeeded != 1 || report.Failed != 1 {
+		t.Errorf("批量结果不正确: %+v", report)
+	}
+
+	content, err := os.ReadFile(filepath.Join(root, "goland-1", "bin", "idea64.vmoptions"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "ja-netfilter.jar") {
+		t.Error("成功的安装应写入 javaagent 配置")
+	}
+	paths := svc.GetSettings().Paths
+	if paths.LastConfigPath != configDir || !slices.Equal(paths.RecentInstallPaths, []string{filepath.Join(root, "goland-1")}) {
+		t.Errorf("只有成功的安装应记录为最近使用的路径: %+v", paths)
+	}
+
+	// 应用配置时只报告环境变量定义，注释需要用户单独确认
+	content, err = os.ReadFile(environment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.HasPrefix(string(content), envDisabledPrefix) || !strings.Contains(report.EnvWarning, environment) {
+		t.Errorf("应用配置时不应修改环境变量定义，只给出提示: %q\n%s", report.EnvWarning, content)
+	}
+	if _, err := svc.DisableEnvVarDefinitions(); err != nil {
+		t.Fatalf("注释环境变量定义失败: %v", err)
+	}
+	if content, _ = os.ReadFile(environment); !strings.HasPrefix(string(content), envDisabledPrefix) {
+		t.Errorf("临时根目录下的系统级定义应被注释:\n%s", content)
+	}
+
+	if _, err := svc.ClearConfigBatch(InstallationSelector{}); !errors.Is(err, ErrNoInstallationSelected) {
+		t.Errorf("空选择应返回 ErrNoInstallationSelected，实际 %v", err)
+	}
+}
+
+// TestCheckManagedOptions 测试 IDE 更新覆盖 vmoptions 后的通知和自动重新应用
+func TestCheckManagedOptions(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("HOME", root)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+
+	var events []ManagedOptionsLost
+	svc := NewConfigService(
+		WithSettingsPath(filepath.Join(t.TempDir(), settingsFileName)),
+		WithEventEmitter(func(name string, data any) {
+			if name == EventManagedOptionsLost {
+				events = append(events, data.(ManagedOptionsLost))
+			}
+		}))
+
+	home := filepath.Join(root, "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xmx2048m\n-javaagent:/cfg/ja-netfilter.jar=jetbrains\n")
+	if _, err := svc.AddInstallation(home, ""); err != nil {
+		t.Fatalf("保存安装失败: %v", err)
+	}
+	svc.markInstallationManaged(home, "/cfg")
+
+	reported := make(map[string]bool)
+	if lost := svc.checkManagedOptions(false, reported); len(lost) != 0 {
+		t.Fatalf("配置完整时不应通知: %+v", lost)
+	}
+
+	// 模拟 IDE 补丁更新替换 vmoptions
+	createTestInstallation(t, home, "IU", "241.2", "-Xmx4096m\n")
+	svc.checkManagedOptions(false, reported)
+	svc.checkManagedOptions(false, reported)
+	if len(events) != 1 || events[0].Reapplied || events[0].Version != "241.2" {
+		t.Fatalf("同一次覆盖应只通知一次且不自动应用: %+v", events)
+	}
+	if !svc.ListInstallations()[0].VMOptionsReset {
+		t.Error("安装记录应标记 vmoptions 已被覆盖")
+	}
+
+	delete(reported, svc.ListInstallations()[0].ID)
+	lost := svc.checkManagedOptions(true, reported)
+	if len(lost) != 1 || !lost[0].Reapplied || lost[0].Files != 1 {
+		t.Fatalf("应自动重新应用配置: %+v", lost)
+	}
+
+	vmFile := filepath.Join(home, "bin", "idea64.vmoptions")
+	content, err := os.ReadFile(vmFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "-Xmx4096m") || !strings.Contains(string(content), "/cfg/ja-netfilter.jar") {
+		t.Errorf("重新应用后应保留新文件内容并添加配置: %s", content)
+	}
+	if len(listBackups(vmFile)) == 0 {
+		t.Error("重新应用前应备份 IDE 更新后的文件")
+	}
+	if svc.ListInstallations()[0].VMOptionsReset {
+		t.Error("重新应用后应清除覆盖标记")
+	}
+
+	// 用户清除配置期间后台检查等待安装锁，之后按最新的安装记录判断，不会重新应用
+	unlock := svc.lockInstallation(home)
+	done := make(chan []ManagedOptionsLost)
+	go func() { done <- svc.checkManagedOptions(true, map[string]bool{}) }()
+	if _, _, err := svc.applyManagedConfigLocked(home, "", "清除"); err != nil {
+		t.Fatalf("清除配置失败: %v", err)
+	}
+	unlock()
+	if lost := <-done; len(lost) != 0 {
+		t.Errorf("清除配置后不应自动重新应用: %+v", lost)
+	}
+	if content, _ := os.ReadFile(vmFile); strings.Contains(string(content), "ja-netfilter.jar") {
+		t.Errorf("清除的配置被后台任务重新写入:\n%s", content)
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// 运行实例的识别来源
+const (
+	InstanceSourceProcess  = "process"
+	InstanceSourceLockFile = "lockFile"
+)
+
+// ideLockFiles IDE 运行时在 config/system 目录中创建的锁文件和端口文件
+var ideLockFiles = []string{".lock", ".port", "port", "port.lock"}
+
+// RunningInstance 描述一个正在运行的 IDE 实例
+type RunningInstance struct {
+	PID         int    `json:"pid"`
+	Executable  string `json:"executable"`
+	CommandLine string `json:"commandLine"`
+	Source      string `json:"source"`
+	LockFile    string `json:"lockFile,omitempty"`
+}
+
+// InstanceReport 汇总一个安装的运行状态
+// 进程列表可用时，没有对应进程的锁文件视为上次异常退出遗留，不判定为运行中
+type InstanceReport struct {
+	Running    bool              `json:"running"`
+	Instances  []RunningInstance `json:"instances"`
+	StaleLocks []string          `json:"staleLocks"`
+}
+
+// processInfo 描述系统中的一个进程，由平台相关的 listProcesses 返回
+type processInfo struct {
+	PID        int
+	Executable string
+	Args       []string
+}
+
+// ideMainClasses IDE 通过 java 命令启动时的主类
+var ideMainClasses = []string{"com.intellij.idea.Main"}
+
+// ideLaunchers 返回安装中启动器的路径：product-info.json 中的 launcherPath 以及产品目录中的启动器名
+func ideLaunchers(home string) []string {
+	var launchers []string
+	var product ProductDefinition
+	var ok bool
+	if info, err := readProductInfo(home); err == nil {
+		for _, launch := range info.Launch {
+			if launch.LauncherPath != "" {
+				launchers = append(launchers, filepath.Join(home, filepath.FromSlash(launch.LauncherPath)))
+			}
+		}
+		product, ok = productByCode(info.ProductCode)
+	}
+	if !ok {
+		product, _ = productForBinDir(filepath.Join(home, "bin"))
+	}
+	for _, name := range product.Launchers {
+		// macOS 的启动器位于 *.app/Contents/MacOS
+		launchers = append(launchers, filepath.Join(home, "bin", name), filepath.Join(home, "MacOS", name))
+	}
+	return launchers
+}
+
+// processBelongsTo 判断进程是否为该安装的 IDE：进程是安装的启动器、-Didea.home.path 指向安装目录，
+// 或以 IDE 主类启动且 java 或类路径位于安装目录中
+// 使用自带 JBR 的 Gradle/Kotlin 守护进程、打开安装目录中文件的编辑器等进程不算在内
+func processBelongsTo(proc processInfo, home string, launchers []string) bool {
+	isLauncher := func(path string) bool {
+		return path != "" && slices.ContainsFunc(launchers, func(l string) bool { return sameFilePath(l, path) })
+	}
+	if isLauncher(proc.Executable) || (len(proc.Args) > 0 && isLauncher(proc.Args[0])) {
+		return true
+	}
+
+	mainClass := false
+	inHome := proc.Executable != "" && isSubPath(home, proc.Executable)
+	for i, arg := range proc.Args {
+		if value, ok := strings.CutPrefix(arg, "-Didea.home.path="); ok {
+			if sameFilePath(strings.Trim(value, `"`), home) {
+				return true
+			}
+			continue
+		}
+		if slices.Contains(ideMainClasses, arg) {
+			mainClass = true
+		}
+		if (arg == "-cp" || arg == "-classpath" || arg == "--class-path") && i+1 < len(proc.Args) {
+			for _, entry := range filepath.SplitList(proc.Args[i+1]) {
+				inHome = inHome || isSubPath(home, entry)
+			}
+		}
+	}
+	return mainClass && inHome
+}
+
+// sameFilePath 判断两个路径是否指向同一位置，Windows 上忽略大小写
+func sameFilePath(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// detectRunningInstances 通过进程列表和 config/system 目录中的锁文件检测运行中的实例
+func detectRunningInstances(home string) InstanceReport {
+	report := InstanceReport{Instances: []RunningInstance{}, StaleLocks: []string{}}
+
+	procs, supported := listProcesses()
+	launchers := ideLaunchers(home)
+	for _, proc := range procs {
+		if processBelongsTo(proc, home, launchers) {
+			report.Instances = append(report.Instances, RunningInstance{
+				PID:         proc.PID,
+				Executable:  proc.Executable,
+				CommandLine: strings.Join(proc.Args, " "),
+				Source:      InstanceSourceProcess,
+			})
+		}
+	}
+
+	var locks []string
+	if dirs, err := ideDirLocations(home); err == nil {
+		for _, dir := range dirs[:2] {
+			for _, name := range ideLockFiles {
+				if path := filepath.Join(dir.Path, name); fileExists(path) {
+					locks = append(locks, path)
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(report.Instances) > 0:
+		// 已找到进程，锁文件只是同一实例的佐证
+	case supported:
+		report.StaleLocks = append(report.StaleLocks, locks...)
+	default:
+		for _, lock := range locks {
+			report.Instances = append(report.Instances, RunningInstance{Source: InstanceSourceLockFile, LockFile: lock})
+		}
+	}
+
+	report.Running = len(report.Instances) > 0
+	return report
+}
+
+// describe 生成运行实例的简短描述，用于错误和提示信息
+func (r InstanceReport) describe() string {
+	parts := make([]string, 0, len(r.Instances))
+	for _, inst := range r.Instances {
+		if inst.PID > 0 {
+			parts = append(parts, fmt.Sprintf("PID %d", inst.PID))
+		} else {
+			parts = append(parts, inst.LockFile)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ensureNotRunning 在执行可能损坏 IDE 状态的操作前确认 IDE 未在运行
+func (c *ConfigService) ensureNotRunning(home string) error {
+	report := detectRunningInstances(home)
+	if !report.Running {
+		return nil
+	}
+	c.logger.Warn("IDE 正在运行，拒绝操作", slog.String("home", home), slog.String("instances", report.describe()))
+	return fmt.Errorf("%w: %s", ErrIDERunning, report.describe())
+}
+
+// restartNotice 返回需要重启的实例提示，IDE 未运行时返回空字符串
+func restartNotice(home string) (string, []RunningInstance) {
+	report := detectRunningInstances(home)
+	if !report.Running {
+		return "", nil
+	}
+	return fmt.Sprintf("IDE 正在运行（%s），需重启后生效", report.describe()), report.Instances
+}
+
+// GetRunningInstances 返回指定安装正在运行的实例
+func (c *ConfigService) GetRunningInstances(installPath string) InstanceReport {
+	return detectRunningInstances(installationHome(installPath))
+}
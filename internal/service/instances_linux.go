@@ -0,0 +1,63 @@
+//go:build linux
+// +build linux
+
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// listProcesses 读取 /proc/*/cmdline 列出当前用户可见的进程
+// 无法读取 /proc 时返回 false，调用方将只依据锁文件判断
+func listProcesses() ([]processInfo, bool) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, false
+	}
+
+	self := os.Getpid()
+	var procs []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+
+		var args []string
+		for _, arg := range bytes.Split(bytes.TrimRight(cmdline, "\x00"), []byte{0}) {
+			args = append(args, string(arg))
+		}
+		exe, _ := os.Readlink(filepath.Join("/proc", entry.Name(), "exe"))
+		procs = append(procs, processInfo{
+			PID:        pid,
+			Executable: strings.TrimSuffix(exe, " (deleted)"),
+			Args:       args,
+		})
+	}
+	return procs, true
+}
+
+// processResidentBytes 读取 /proc/<pid>/statm 中进程当前占用的物理内存，无法读取时返回 0
+func processResidentBytes(pid int) int64 {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "statm"))
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * int64(os.Getpagesize())
+}
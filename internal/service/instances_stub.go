@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package service
+
+// listProcesses 非 Linux 平台不枚举进程，只依据锁文件判断 IDE 是否运行
+func listProcesses() ([]processInfo, bool) {
+	return nil, false
+}
+
+// processResidentBytes 非 Linux 平台不枚举进程，无法获取进程占用的内存
+func processResidentBytes(pid int) int64 {
+	return 0
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// TestProcessBelongsTo 测试按启动器、idea.home.path 和 IDE 主类判断进程所属安装
+func TestProcessBelongsTo(t *testing.T) {
+	home := filepath.Join(t.TempDir(), "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xmx2048m\n")
+	launchers := ideLaunchers(home)
+	java := filepath.Join(home, "jbr", "bin", "java")
+
+	tests := []struct {
+		name string
+		proc processInfo
+		want bool
+	}{
+		{"native launcher", processInfo{Executable: filepath.Join(home, "bin", "idea"), Args: []string{filepath.Join(home, "bin", "idea")}}, true},
+		{"launcher script", processInfo{Executable: "/usr/bin/bash", Args: []string{filepath.Join(home, "bin", "idea.sh")}}, true},
+		{"home property", processInfo{Executable: "/usr/bin/java", Args: []string{"java", "-Didea.home.path=" + home}}, true},
+		{"main class on bundled jbr", processInfo{Executable: java, Args: []string{java, "com.intellij.idea.Main"}}, true},
+		{"main class with classpath", processInfo{Executable: "/usr/bin/java", Args: []string{"java", "-cp", filepath.Join(home, "lib", "app.jar"), "com.intellij.idea.Main"}}, true},
+		{"gradle daemon on bundled jbr", processInfo{Executable: java, Args: []string{java, "-cp", filepath.Join(home, "plugins", "gradle", "lib", "gradle.jar"), "org.gradle.launcher.daemon.bootstrap.GradleDaemon"}}, false},
+		{"editor on vmoptions", processInfo{Executable: "/usr/bin/vim", Args: []string{"vim", filepath.Join(home, "bin", "idea64.vmoptions")}}, false},
+		{"other home property", processInfo{Executable: "/usr/bin/java", Args: []string{"java", "-Didea.home.path=" + home + "-2024"}}, false},
+		{"sibling install", processInfo{Executable: home + "-2024", Args: []string{"java"}}, false},
+		{"unrelated", processInfo{Executable: "/usr/bin/bash", Args: []string{"bash"}}, false},
+	}
+	for _, tt := range tests {
+		if got := processBelongsTo(tt.proc, home, launchers); got != tt.want {
+			t.Errorf("%s: processBelongsTo = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDetectRunningInstancesStaleLock 测试 Linux 上没有对应进程的锁文件被识别为遗留文件
+func TestDetectRunningInstancesStaleLock(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("仅 Linux 支持枚举进程")
+	}
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+
+	home := filepath.Join(base, "goland")
+	createTestInstallation(t, home, "GO", "241.1", "-Xmx2048m\n")
+	configDir, err := ideConfigDir(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, ".lock"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := detectRunningInstances(home)
+	if report.Running || len(report.StaleLocks) != 1 {
+		t.Errorf("遗留锁文件不应判定为运行中: %+v", report)
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// jvmCheckTimeout 单次 JVM 参数验证的超时时间
+const jvmCheckTimeout = 30 * time.Second
+
+// jvmFailurePatterns 匹配 JVM 因参数错误无法启动时的输出
+var jvmFailurePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`Unrecognized VM option '[^']*'`),
+	regexp.MustCompile(`Unrecognized option: \S+`),
+	regexp.MustCompile(`Invalid (maximum heap|initial heap|thread stack) size: \S+`),
+	regexp.MustCompile(`Improperly specified VM option '[^']*'`),
+	regexp.MustCompile(`Missing \+/- setting for VM option '[^']*'`),
+	regexp.MustCompile(`Error opening zip file or JAR manifest missing : .*`),
+	regexp.MustCompile(`Error occurred during initialization of VM.*`),
+	regexp.MustCompile(`Could not reserve enough space for .*`),
+	regexp.MustCompile(`Initial heap size set to a larger value than the maximum heap size`),
+	regexp.MustCompile(`Conflicting collector combinations in option list.*`),
+}
+
+// jvmFlagPattern 匹配 -XX:+PrintFlagsFinal 的输出行，如 "size_t MaxHeapSize = 2147483648 {product} {ergonomic}"
+var jvmFlagPattern = regexp.MustCompile(`^\s*(\S+)\s+(\w+)\s+:?=(.*?)\{(.*)\}\s*$`)
+
+// jvmKeyFlags 验证结果中返回给界面的关键参数
+var jvmKeyFlags = []string{
+	"MaxHeapSize", "InitialHeapSize", "ReservedCodeCacheSize", "MaxMetaspaceSize", "SoftRefLRUPolicyMSPerMB",
+	"UseG1GC", "UseZGC", "UseShenandoahGC", "UseParallelGC", "UseSerialGC", "CICompilerCount",
+}
+
+// jvmCheckSkippedOptions 验证时跳过的参数：会预先占用全部堆内存，或依赖 IDE 的类路径
+var jvmCheckSkippedOptions = []string{"-XX:+AlwaysPreTouch", "-Djava.system.class.loader"}
+
+// JVM 参数的类别，experimental 和 diagnostic 参数需要先解锁
+const (
+	jvmFlagKindProduct      = "product"
+	jvmFlagKindExperimental = "experimental"
+	jvmFlagKindDiagnostic   = "diagnostic"
+)
+
+// jvmFlag 对应 -XX:+PrintFlagsFinal 输出中的一个参数
+type jvmFlag struct {
+	Type  string `json:"type"`
+	Kind  string `json:"kind"`
+	Value string `json:"value,omitempty"`
+}
+
+// jvmFlagKind 从 "{C2 experimental} {default}" 这类属性中取出参数类别
+func jvmFlagKind(attributes string) string {
+	first, _, _ := strings.Cut(attributes, "}")
+	switch {
+	case strings.Contains(first, jvmFlagKindExperimental):
+		return jvmFlagKindExperimental
+	case strings.Contains(first, jvmFlagKindDiagnostic):
+		return jvmFlagKindDiagnostic
+	}
+	return jvmFlagKindProduct
+}
+
+// JVMCheckResult 描述一次 JVM 参数验证的结果
+type JVMCheckResult struct {
+	OK          bool              `json:"ok"`
+	Java        string            `json:"java"`
+	JavaVersion string            `json:"javaVersion"`
+	Arguments   []string          `json:"arguments"`
+	Problems    []string          `json:"problems"`
+	Flags       map[string]string `json:"flags"`
+	Output      string            `json:"output"`
+	DurationMs  int64             `json:"durationMs"`
+}
+
+// parseFlagsFinal 解析 -XX:+PrintFlagsFinal 的输出，返回参数名到类型和值的映射
+func parseFlagsFinal(output string) map[string]jvmFlag {
+	flags := make(map[string]jvmFlag)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if m := jvmFlagPattern.FindStringSubmatch(scanner.Text()); m != nil {
+			flags[m[2]] = jvmFlag{Type: m[1], Kind: jvmFlagKind(m[4]), Value: strings.TrimSpace(m[3])}
+		}
+	}
+	return flags
+}
+
+// jvmProblems 从 JVM 输出中提取参数错误
+func jvmProblems(output string) []string {
+	var problems []string
+	for _, pattern := range jvmFailurePatterns {
+		for _, match := range pattern.FindAllString(output, -1) {
+			match = strings.TrimSpace(match)
+			if !slices.Contains(problems, match) {
+				problems = append(problems, match)
+			}
+		}
+	}
+	return problems
+}
+
+// ideJavaExecutable 返回 IDE 启动时使用的 java：优先 .jdk 文件选择的运行时，其次自带 JBR
+func ideJavaExecutable(home string) (string, error) {
+	if ctx, err := loadRuntimeContext(home); err == nil {
+		if content, err := os.ReadFile(ctx.jdkFile); err == nil {
+			if rt, err := readJavaRuntime(strings.TrimSpace(string(content))); err == nil {
+				return rt.JavaBinary, nil
+			}
+		}
+	}
+
+	var candidates []string
+	if info, err := readProductInfo(home); err == nil {
+		if launch, ok := info.launchFor(runtime.GOOS); ok && launch.JavaExecutablePath != "" {
+			candidates = append(candidates, filepath.Join(home, filepath.FromSlash(launch.JavaExecutablePath)))
+		}
+	}
+	for _, jbr := range []string{filepath.Join(home, "jbr"), filepath.Join(home, "jbr", "Contents", "Home")} {
+		if rt, err := readJavaRuntime(jbr); err == nil {
+			candidates = append(candidates, rt.JavaBinary)
+		}
+	}
+	for _, candidate := range candidates {
+		if fileExists(candidate) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrRuntimeNotFound, home)
+}
+
+// jvmCheckArguments 从解析后的 JVM 参数中取出需要验证的部分
+// product-info.json 中的参数由 IDE 自带且依赖完整类路径，不参与验证
+func jvmCheckArguments(effective EffectiveOptions) []string {
+	var args []string
+	for _, opt := range effective.Options {
+		if opt.Overridden || opt.Source == OptionSourceProductInfo {
+			continue
+		}
+		if slices.ContainsFunc(jvmCheckSkippedOptions, func(skip string) bool { return jvmOptionKey(opt.Option) == jvmOptionKey(skip) }) {
+			continue
+		}
+		// 与启动器一致，参数中的引号不属于参数值
+		args = append(args, strings.ReplaceAll(opt.Option, `"`, ""))
+	}
+	return args
+}
+
+// sandboxEnv 返回去掉 JVM 参数相关环境变量的环境，避免外部设置影响验证结果
+func sandboxEnv() []string {
+	blocked := append(jetbrainsEnvVarNames(), "JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS", "_JAVA_OPTIONS")
+	return slices.DeleteFunc(os.Environ(), func(kv string) bool {
+		name, _, _ := strings.Cut(kv, "=")
+		return slices.ContainsFunc(blocked, func(b string) bool { return strings.EqualFold(b, name) })
+	})
+}
+
+// runSandboxedJava 在临时目录中以去掉 JVM 参数环境变量的环境运行 java，返回合并后的输出
+// 超时返回 context.DeadlineExceeded
+func runSandboxedJava(java string, args []string) ([]byte, error) {
+	sandbox, err := os.MkdirTemp("", appDataDirName+"-jvmcheck-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(sandbox)
+
+	ctx, cancel := context.WithTimeout(context.Background(), jvmCheckTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, java, args...)
+	cmd.Dir = sandbox
+	cmd.Env = sandboxEnv()
+
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return output, ctx.Err()
+	}
+	return output, err
+}
+
+// verifyJVMOptions 在临时目录中以 IDE 的 JVM 参数运行 java -XX:+PrintFlagsFinal -version
+func verifyJVMOptions(home string) (JVMCheckResult, error) {
+	java, err := ideJavaExecutable(home)
+	if err != nil {
+		return JVMCheckResult{}, err
+	}
+	effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+	if err != nil {
+		return JVMCheckResult{}, err
+	}
+
+	result := JVMCheckResult{Java: java, Arguments: jvmCheckArguments(effective), Problems: []string{}, Flags: map[string]string{}}
+	args := append(slices.Clone(result.Arguments), "-XX:+PrintFlagsFinal", "-version")
+
+	started := time.Now()
+	output, runErr := runSandboxedJava(java, args)
+	result.DurationMs = time.Since(started).Milliseconds()
+	if errors.Is(runErr, context.DeadlineExceeded) {
+		return result, fmt.Errorf("%w: 超时", ErrVerificationFailed)
+	}
+
+	flags := parseFlagsFinal(string(output))
+	for _, name := range jvmKeyFlags {
+		if flag, ok := flags[name]; ok {
+			result.Flags[name] = flag.Value
+		}
+	}
+
+	// 去掉参数列表，只保留版本信息和错误输出
+	var rest []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if jvmFlagPattern.MatchString(line) || strings.HasPrefix(strings.TrimSpace(line), "[Global flags]") {
+			continue
+		}
+		if line = strings.TrimRight(line, "\r"); strings.TrimSpace(line) != "" {
+			rest = append(rest, line)
+			if result.JavaVersion == "" && strings.Contains(line, " version ") {
+				result.JavaVersion = strings.TrimSpace(line)
+			}
+		}
+	}
+	result.Output = strings.Join(rest, "\n")
+
+	result.Problems = append(result.Problems, jvmProblems(result.Output)...)
+	if runErr != nil && len(result.Problems) == 0 {
+		var exitErr *exec.ExitError
+		if !errors.As(runErr, &exitErr) {
+			return result, fmt.Errorf("运行 %s 失败: %w", java, runErr)
+		}
+		result.Problems = append(result.Problems, fmt.Sprintf("java 退出码 %d", exitErr.ExitCode()))
+	}
+	result.OK = runErr == nil && len(result.Problems) == 0
+	return result, nil
+}
+
+// snapshotFiles 读取文件的当前内容，用于验证失败时回滚
+func snapshotFiles(paths []string) map[string][]byte {
+	snapshot := make(map[string][]byte, len(paths))
+	for _, path := range paths {
+		if data, err := os.ReadFile(path); err == nil {
+			snapshot[path] = data
+		}
+	}
+	return snapshot
+}
+
+// restoreSnapshot 将文件恢复为快照中的内容
+func restoreSnapshot(snapshot map[string][]byte) error {
+	var errs []error
+	for path, data := range snapshot {
+		if err := writeFileAtomic(path, data, 0644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// processVMOptionsVerified 在安装锁内修改 vmoptions 并验证，见 editVMOptionsVerified
+func (c *ConfigService) processVMOptionsVerified(projectPath string, operation VMOptionsOperation, operationName string) (int, string, error) {
+	defer c.lockInstallation(projectPath)()
+	return c.editVMOptionsVerified(projectPath, operation, operationName)
+}
+
+// editVMOptionsVerified 修改 vmoptions 后验证 JVM 能否以新参数启动，修改引入新的问题时回滚到修改前的内容
+// 修改前先以原参数验证一次，原参数已存在的问题不会导致回滚；返回的 notice 说明跳过验证的原因或原有的问题，
+// 找不到 IDE 使用的运行时、无法运行 java 或验证超时时跳过验证，不影响修改结果
+func (c *ConfigService) editVMOptionsVerified(projectPath string, operation VMOptionsOperation, operationName string) (int, string, error) {
+	home := installationHome(projectPath)
+	var snapshot map[string][]byte
+	if binDir, err := validateIntelliJPath(projectPath); err == nil {
+		if files, err := findVMOptionsFiles(binDir); err == nil {
+			snapshot = snapshotFiles(files)
+		}
+	}
+
+	baseline, baselineErr := verifyJVMOptions(home)
+
+	count, err := c.processVMOptionsFilesGeneric(projectPath, operation, operationName)
+	if err != nil {
+		// 处理到一半失败时，已修改的文件也恢复原状
+		if restoreErr := restoreSnapshot(snapshot); restoreErr != nil {
+			c.logger.Error("回滚 vmoptions 失败", slog.Any("error", restoreErr))
+		}
+		return 0, "", err
+	}
+
+	if baselineErr != nil {
+		c.logger.Warn("跳过 JVM 参数验证", slog.String("home", home), slog.Any("error", baselineErr))
+		return count, fmt.Sprintf("未验证 JVM 参数: %v", baselineErr), nil
+	}
+	result, err := verifyJVMOptions(home)
+	if err != nil {
+		c.logger.Warn("跳过 JVM 参数验证", slog.String("home", home), slog.Any("error", err))
+		return count, fmt.Sprintf("未验证 JVM 参数: %v", err), nil
+	}
+	if result.OK {
+		c.logger.Info("JVM 参数验证通过", slog.String("home", home), slog.Int64("durationMs", result.DurationMs))
+		return count, "", nil
+	}
+
+	introduced := slices.DeleteFunc(slices.Clone(result.Problems), func(problem string) bool {
+		return slices.Contains(baseline.Problems, problem)
+	})
+	if !baseline.OK && len(introduced) == 0 {
+		c.logger.Warn("JVM 参数在修改前已验证失败，保留修改", slog.String("home", home), slog.Any("problems", result.Problems))
+		return count, fmt.Sprintf("修改前的 JVM 参数已存在问题: %s", strings.Join(baseline.Problems, "; ")), nil
+	}
+	if len(introduced) == 0 {
+		introduced = result.Problems
+	}
+
+	c.logger.Error("JVM 参数验证失败，回滚修改", slog.String("home", home), slog.Any("problems", introduced))
+	if err := restoreSnapshot(snapshot); err != nil {
+		return 0, "", fmt.Errorf("%w: %s；回滚失败: %v", ErrVerificationFailed, strings.Join(introduced, "; "), err)
+	}
+	return 0, "", fmt.Errorf("%w: %s，已回滚修改", ErrVerificationFailed, strings.Join(introduced, "; "))
+}
+
+// VerifyInstallation 以 IDE 当前的 JVM 参数运行其 Java 运行时，检查参数是否有效
+func (c *ConfigService) VerifyInstallation(installPath string) (JVMCheckResult, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return JVMCheckResult{}, ErrEmptyPath
+	}
+	result, err := verifyJVMOptions(home)
+	if err != nil {
+		c.logger.Error("JVM 参数验证出错", slog.String("home", home), slog.Any("error", err))
+		return result, err
+	}
+	c.logger.Info("JVM 参数验证完成", slog.String("home", home), slog.Bool("ok", result.OK))
+	return result, nil
+}
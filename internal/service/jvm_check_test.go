@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeJava 模拟 java：参数中包含 -Xmx 非法值时按 JVM 的格式报错，否则输出版本和 PrintFlagsFinal
+const fakeJava = `#!/bin/sh
+for arg in "$@"; do
+  case "$arg" in
+    -Xmx*[!0-9gmk]*|-Xmx)
+      echo "Invalid maximum heap size: $arg" >&2
+      echo "Error: Could not create the Java Virtual Machine." >&2
+      echo "Error: A fatal exception has occurred. Program will exit." >&2
+      exit 1;;
+    -XX:+Bogus*)
+      echo "Unrecognized VM option '${arg#-XX:+}'" >&2
+      exit 1;;
+  esac
+done
+echo "[Global flags]"
+echo "   size_t MaxHeapSize                              = 4294967296                                {product} {command line}"
+echo "     bool UseG1GC                                  = true                                      {product} {ergonomic}"
+echo 'openjdk version "21.0.5" 2024-10-15' >&2
+`
+
+// TestVerifyJVMOptions 测试 JVM 参数验证和验证失败后的回滚
+func TestVerifyJVMOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用 shell 脚本模拟 java")
+	}
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xms128m\n-Xmx4g\n-XX:+AlwaysPreTouch\n")
+	createTestRuntime(t, filepath.Join(home, "jbr"), "21.0.5", "JetBrains s.r.o.")
+	if err := os.WriteFile(filepath.Join(home, "jbr", "bin", "java"), []byte(fakeJava), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	result, err := svc.VerifyInstallation(home)
+	if err != nil {
+		t.Fatalf("验证出错: %v", err)
+	}
+	if !result.OK || result.Flags["MaxHeapSize"] != "4294967296" || result.Flags["UseG1GC"] != "true" {
+		t.Errorf("验证结果不正确: %+v", result)
+	}
+	if !strings.Contains(result.JavaVersion, "21.0.5") {
+		t.Errorf("未解析到 Java 版本: %q", result.JavaVersion)
+	}
+	if strings.Contains(strings.Join(result.Arguments, " "), "AlwaysPreTouch") {
+		t.Errorf("验证时应跳过 AlwaysPreTouch: %v", result.Arguments)
+	}
+
+	vmFile := filepath.Join(home, "bin", "idea64.vmoptions")
+	original, _ := os.ReadFile(vmFile)
+	_, _, err = svc.processVMOptionsVerified(home, func(path string) error {
+		return processVMOptionsFileGeneric(path, func(line string) bool {
+			return strings.HasPrefix(line, "-Xmx")
+		}, []string{"-Xmx4gb", "-XX:+BogusOption"}, svc.logger)
+	}, "测试")
+	if !errors.Is(err, ErrVerificationFailed) || !strings.Contains(err.Error(), "Invalid maximum heap size: -Xmx4gb") {
+		t.Fatalf("非法参数应验证失败，得到 %v", err)
+	}
+	if current, _ := os.ReadFile(vmFile); string(current) != string(original) {
+		t.Errorf("验证失败后应回滚:\n%s", current)
+	}
+
+	// 修改前已存在的问题不由本次修改引入，不应回滚
+	if err := os.WriteFile(vmFile, []byte("-Xmx4g\n-XX:+BogusOld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, notice, err := svc.processVMOptionsVerified(home, func(path string) error {
+		return processVMOptionsFileGeneric(path, func(line string) bool {
+			return strings.HasPrefix(line, "-Xmx")
+		}, []string{"-Xmx2g"}, svc.logger)
+	}, "测试")
+	if err != nil || !strings.Contains(notice, "BogusOld") {
+		t.Fatalf("原有问题不应导致回滚，得到 %q, %v", notice, err)
+	}
+	if current, _ := os.ReadFile(vmFile); !strings.Contains(string(current), "-Xmx2g") {
+		t.Errorf("修改应被保留:\n%s", current)
+	}
+
+	// 找不到运行时时跳过验证并在结果中说明
+	other := filepath.Join(base, "goland")
+	createTestInstallation(t, other, "GO", "241.1", "-Xmx2g\n")
+	count, notice, err := svc.processVMOptionsVerified(other, func(path string) error {
+		return processVMOptionsFileGeneric(path, func(string) bool { return false }, []string{"-Xss2m"}, svc.logger)
+	}, "测试")
+	if err != nil || count != 1 || !strings.Contains(notice, "未验证") {
+		t.Errorf("跳过验证时应返回说明，得到 %d, %q, %v", count, notice, err)
+	}
+}
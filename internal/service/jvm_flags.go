@@ -0,0 +1,273 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// jvmFlagCacheDirName 参数表缓存目录名，位于用户缓存目录下
+const jvmFlagCacheDirName = "jvm-flags"
+
+// 参数检查问题的严重程度
+const (
+	FlagIssueError   = "error"
+	FlagIssueWarning = "warning"
+)
+
+// jvmUnlockFlags 使用对应类别的参数前需要开启的解锁参数
+var jvmUnlockFlags = map[string]string{
+	jvmFlagKindExperimental: "UnlockExperimentalVMOptions",
+	jvmFlagKindDiagnostic:   "UnlockDiagnosticVMOptions",
+}
+
+// jvmPseudoFlags 由启动参数解析直接处理、不在参数表中的 -XX 参数
+var jvmPseudoFlags = []string{"Flags", "VMOptionsFile"}
+
+// jvmIntegerPattern 匹配整数参数值，允许 k/m/g/t 单位和十六进制
+var jvmIntegerPattern = regexp.MustCompile(`^(0[xX][0-9a-fA-F]+|[0-9]+[kKmMgGtT]?)$`)
+
+// jvmFlagTable 保存某个构建号的 IDE 运行时支持的 -XX 参数
+type jvmFlagTable struct {
+	Key   string             `json:"key"`
+	Java  string             `json:"java"`
+	Flags map[string]jvmFlag `json:"flags"`
+}
+
+// jvmFlagCache 按产品构建号缓存参数表，同一构建只运行一次 java
+type jvmFlagCache struct {
+	// dir 为空时只缓存在内存中
+	dir    string
+	mu     sync.Mutex
+	tables map[string]*jvmFlagTable
+}
+
+// FlagIssue 描述 vmoptions 中一个 -XX 参数的问题
+type FlagIssue struct {
+	Option   string `json:"option"`
+	Flag     string `json:"flag"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Problem  string `json:"problem"`
+}
+
+// FlagValidation 汇总一个安装的 -XX 参数检查结果
+type FlagValidation struct {
+	Valid       bool        `json:"valid"`
+	BuildNumber string      `json:"buildNumber"`
+	Java        string      `json:"java"`
+	Cached      bool        `json:"cached"`
+	Checked     int         `json:"checked"`
+	Issues      []FlagIssue `json:"issues"`
+}
+
+// jvmFlagCacheDir 返回参数表的磁盘缓存目录
+func jvmFlagCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取缓存目录: %w", err)
+	}
+	return filepath.Join(cacheDir, appDataDirName, jvmFlagCacheDirName), nil
+}
+
+// newJVMFlagCache 创建参数表缓存
+func newJVMFlagCache(dir string) *jvmFlagCache {
+	return &jvmFlagCache{dir: dir, tables: make(map[string]*jvmFlagTable)}
+}
+
+// path 返回缓存键对应的缓存文件
+func (f *jvmFlagCache) path(key string) string {
+	return filepath.Join(f.dir, key+".json")
+}
+
+// table 返回安装对应构建的参数表，缓存中没有或运行时已更换时运行 java 重新读取
+// 第二个返回值表示结果来自缓存
+func (f *jvmFlagCache) table(home string, logger *slog.Logger) (*jvmFlagTable, bool, error) {
+	info, err := readProductInfo(home)
+	if err != nil {
+		return nil, false, err
+	}
+	java, err := ideJavaExecutable(home)
+	if err != nil {
+		return nil, false, err
+	}
+	key := info.ProductCode + "-" + info.BuildNumber
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if table, ok := f.tables[key]; ok && table.Java == java {
+		return table, true, nil
+	}
+	if f.dir != "" {
+		if data, err := os.ReadFile(f.path(key)); err == nil {
+			var table jvmFlagTable
+			if err := json.Unmarshal(data, &table); err == nil && table.Java == java && len(table.Flags) > 0 {
+				f.tables[key] = &table
+				return &table, true, nil
+			}
+		}
+	}
+
+	// 解锁后 PrintFlagsFinal 才会列出 experimental 和 diagnostic 参数
+	output, err := runSandboxedJava(java, []string{
+		"-XX:+UnlockDiagnosticVMOptions", "-XX:+UnlockExperimentalVMOptions", "-XX:+PrintFlagsFinal", "-version",
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("运行 %s 失败: %w", java, err)
+	}
+	flags := parseFlagsFinal(string(output))
+	if len(flags) == 0 {
+		return nil, false, fmt.Errorf("%w: 未能读取 %s 支持的参数", ErrRuntimeNotFound, java)
+	}
+	// 只缓存参数名、类型和类别，默认值与当前机器有关
+	for name, flag := range flags {
+		flag.Value = ""
+		flags[name] = flag
+	}
+	table := &jvmFlagTable{Key: key, Java: java, Flags: flags}
+	f.tables[key] = table
+
+	if f.dir != "" {
+		data, err := json.Marshal(table)
+		if err == nil {
+			if err = os.MkdirAll(f.dir, 0755); err == nil {
+				err = writeFileAtomic(f.path(key), data, 0644)
+			}
+		}
+		if err != nil {
+			logger.Warn("保存 JVM 参数表缓存失败", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+	logger.Info("已读取 JVM 参数表", slog.String("key", key), slog.Int("flags", len(flags)))
+	return table, false, nil
+}
+
+// checkFlagValue 检查参数值是否符合参数类型，返回问题描述
+func checkFlagValue(flag jvmFlag, value string) string {
+	switch flag.Type {
+	case "bool":
+		return ""
+	case "int", "intx":
+		if jvmIntegerPattern.MatchString(strings.TrimPrefix(value, "-")) {
+			return ""
+		}
+	case "uint", "uintx", "uint64_t", "size_t":
+		if jvmIntegerPattern.MatchString(value) {
+			return ""
+		}
+	case "double":
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return ""
+		}
+	default:
+		// ccstr、ccstrlist 等字符串参数不检查取值
+		return ""
+	}
+	return fmt.Sprintf("参数值 %q 不是有效的 %s", value, flag.Type)
+}
+
+// checkFlagOption 检查单个 -XX 参数，unlocked 为已开启的解锁参数
+func checkFlagOption(option string, flags map[string]jvmFlag, unlocked map[string]bool) (string, string, string) {
+	body := strings.TrimPrefix(option, "-XX:")
+	name, value, hasValue := strings.Cut(body, "=")
+	boolForm := strings.HasPrefix(name, "+") || strings.HasPrefix(name, "-")
+	if boolForm {
+		name = name[1:]
+	}
+	if slices.Contains(jvmPseudoFlags, name) {
+		return name, "", ""
+	}
+
+	flag, ok := flags[name]
+	if !ok {
+		return name, FlagIssueError, "当前运行时不支持该参数"
+	}
+	switch {
+	case flag.Type == "bool" && !boolForm:
+		return name, FlagIssueError, "布尔参数应写作 -XX:+" + name + " 或 -XX:-" + name
+	case flag.Type != "bool" && (boolForm || !hasValue):
+		return name, FlagIssueError, fmt.Sprintf("%s 类型的参数应写作 -XX:%s=值", flag.Type, name)
+	case flag.Type != "bool":
+		if problem := checkFlagValue(flag, value); problem != "" {
+			return name, FlagIssueError, problem
+		}
+	}
+	if unlock, ok := jvmUnlockFlags[flag.Kind]; ok && !unlocked[unlock] {
+		return name, FlagIssueError, fmt.Sprintf("%s 参数需要先添加 -XX:+%s", flag.Kind, unlock)
+	}
+	return name, "", ""
+}
+
+// validateJVMFlags 按参数表检查 vmoptions 中每个 -XX 参数的名称、类型和解锁要求
+func validateJVMFlags(effective EffectiveOptions, flags map[string]jvmFlag) []FlagIssue {
+	unlocked := make(map[string]bool)
+	for _, arg := range effective.Arguments {
+		for _, unlock := range jvmUnlockFlags {
+			if arg == "-XX:+"+unlock {
+				unlocked[unlock] = true
+			}
+		}
+	}
+
+	issues := []FlagIssue{}
+	for _, opt := range effective.Options {
+		if opt.Source == OptionSourceProductInfo || !strings.HasPrefix(opt.Option, "-XX:") {
+			continue
+		}
+		name, severity, problem := checkFlagOption(strings.ReplaceAll(opt.Option, `"`, ""), flags, unlocked)
+		if severity == "" && opt.Overridden {
+			severity, problem = FlagIssueWarning, "已被 "+opt.OverriddenBy+" 覆盖，不会生效"
+		}
+		if severity != "" {
+			issues = append(issues, FlagIssue{
+				Option: opt.Option, Flag: name, File: opt.File, Line: opt.Line, Severity: severity, Problem: problem,
+			})
+		}
+	}
+	return issues
+}
+
+// ValidateJVMFlags 用 IDE 运行时支持的参数表检查 vmoptions 中的 -XX 参数
+// 参数表按产品构建号缓存，同一构建只需运行一次 java
+func (c *ConfigService) ValidateJVMFlags(installPath string) (FlagValidation, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return FlagValidation{}, ErrEmptyPath
+	}
+	effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+	if err != nil {
+		return FlagValidation{}, err
+	}
+	table, cached, err := c.flagCache.table(home, c.logger)
+	if err != nil {
+		c.logger.Error("读取 JVM 参数表失败", slog.String("home", home), slog.Any("error", err))
+		return FlagValidation{}, err
+	}
+
+	result := FlagValidation{Valid: true, Java: table.Java, Cached: cached, Issues: validateJVMFlags(effective, table.Flags)}
+	if info, err := readProductInfo(home); err == nil {
+		result.BuildNumber = info.BuildNumber
+	}
+	for _, opt := range effective.Options {
+		if opt.Source != OptionSourceProductInfo && strings.HasPrefix(opt.Option, "-XX:") {
+			result.Checked++
+		}
+	}
+	for _, issue := range result.Issues {
+		if issue.Severity == FlagIssueError {
+			result.Valid = false
+		}
+	}
+	c.logger.Info("JVM 参数检查完成", slog.String("home", home), slog.Int("checked", result.Checked), slog.Int("issues", len(result.Issues)))
+	return result, nil
+}
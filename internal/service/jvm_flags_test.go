@@ -0,0 +1,121 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeFlagsJava 模拟输出参数表的 java，每次运行在 runs 文件中追加一行
+const fakeFlagsJava = `#!/bin/sh
+echo run >> "$(dirname "$0")/runs"
+echo "[Global flags]"
+echo "     bool UseG1GC                                  = true                                      {product} {ergonomic}"
+echo "     bool UseZGC                                   = false                                     {product} {default}"
+echo "     intx SoftRefLRUPolicyMSPerMB                  = 1000                                      {product} {default}"
+echo "   size_t MaxHeapSize                              = 4294967296                                {product} {ergonomic}"
+echo "    uintx ReservedCodeCacheSize                    = 251658240                              {pd product} {ergonomic}"
+echo "     bool UnlockExperimentalVMOptions              = true                                 {experimental} {command line}"
+echo "     bool UseJVMCICompiler                         = false                              {JVMCI experimental} {default}"
+echo "     bool UnlockDiagnosticVMOptions                = true                                   {diagnostic} {command line}"
+echo "     bool PrintCompilation2                        = false                               {C2 diagnostic} {default}"
+echo "    ccstr ErrorFile                                =                                           {product} {default}"
+echo 'openjdk version "21.0.5" 2024-10-15' >&2
+`
+
+// TestParseFlagsFinalKinds 测试参数类别解析
+func TestParseFlagsFinalKinds(t *testing.T) {
+	output := "    uintx ReservedCodeCacheSize                    := 251658240                           {pd product}\n" +
+		"     bool UseJVMCICompiler                         = false                              {JVMCI experimental} {default}\n" +
+		"     bool PrintCompilation2                        = false                               {C2 diagnostic} {default}\n" +
+		"    ccstr ErrorFile                                =                                           {product} {default}\r\n"
+	flags := parseFlagsFinal(output)
+	expected := map[string]jvmFlag{
+		"ReservedCodeCacheSize": {Type: "uintx", Kind: jvmFlagKindProduct, Value: "251658240"},
+		"UseJVMCICompiler":      {Type: "bool", Kind: jvmFlagKindExperimental, Value: "false"},
+		"PrintCompilation2":     {Type: "bool", Kind: jvmFlagKindDiagnostic, Value: "false"},
+		"ErrorFile":             {Type: "ccstr", Kind: jvmFlagKindProduct, Value: ""},
+	}
+	for name, want := range expected {
+		if got := flags[name]; got != want {
+			t.Errorf("%s: 期望 %+v，实际 %+v", name, want, got)
+		}
+	}
+}
+
+// TestValidateJVMFlags 测试 -XX 参数检查和参数表缓存
+func TestValidateJVMFlags(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用 shell 脚本模拟 java")
+	}
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.1", strings.Join([]string{
+		"-Xmx4g",
+		"-XX:+UseG1GC",
+		"-XX:SoftRefLRUPolicyMSPerMB=50",
+		"-XX:ReservedCodeCacheSize=512m",
+		"-XX:+UseJVMCICompiler",
+		"-XX:UseZGC=true",
+		"-XX:MaxHeapSize=lots",
+		"-XX:+UseBogusGC",
+		"-XX:SoftRefLRUPolicyMSPerMB=100",
+		"-XX:ErrorFile=$USER_HOME/java_error_in_idea_%p.log",
+	}, "\n")+"\n")
+	createTestRuntime(t, filepath.Join(home, "jbr"), "21.0.5", "JetBrains s.r.o.")
+	if err := os.WriteFile(filepath.Join(home, "jbr", "bin", "java"), []byte(fakeFlagsJava), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	result, err := svc.ValidateJVMFlags(home)
+	if err != nil {
+		t.Fatalf("检查出错: %v", err)
+	}
+	if result.Valid || result.Cached || result.Checked != 9 || result.BuildNumber != "241.1" {
+		t.Errorf("检查结果不正确: %+v", result)
+	}
+
+	issues := make(map[string]FlagIssue)
+	for _, issue := range result.Issues {
+		issues[issue.Option] = issue
+	}
+	expected := map[string]string{
+		"-XX:+UseJVMCICompiler":          "UnlockExperimentalVMOptions",
+		"-XX:UseZGC=true":                "布尔参数",
+		"-XX:MaxHeapSize=lots":           "size_t",
+		"-XX:+UseBogusGC":                "不支持",
+		"-XX:SoftRefLRUPolicyMSPerMB=50": "覆盖",
+	}
+	for option, problem := range expected {
+		issue, ok := issues[option]
+		if !ok || !strings.Contains(issue.Problem, problem) {
+			t.Errorf("%s: 期望问题包含 %q，实际 %+v", option, problem, issue)
+		}
+	}
+	if issue := issues["-XX:+UseBogusGC"]; issue.Line != 8 || issue.Severity != FlagIssueError {
+		t.Errorf("问题位置或级别不正确: %+v", issue)
+	}
+	if issue := issues["-XX:SoftRefLRUPolicyMSPerMB=50"]; issue.Severity != FlagIssueWarning {
+		t.Errorf("被覆盖的参数应为警告: %+v", issue)
+	}
+	if len(result.Issues) != len(expected) {
+		t.Errorf("期望 %d 个问题，实际 %+v", len(expected), result.Issues)
+	}
+
+	// 新的服务实例从磁盘缓存读取，不再运行 java
+	again, err := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName))).ValidateJVMFlags(home)
+	if err != nil || !again.Cached {
+		t.Fatalf("应使用缓存的参数表: %+v, %v", again, err)
+	}
+	runs, _ := os.ReadFile(filepath.Join(home, "jbr", "bin", "runs"))
+	if count := strings.Count(string(runs), "run"); count != 1 {
+		t.Errorf("同一构建应只运行一次 java，实际 %d 次", count)
+	}
+}
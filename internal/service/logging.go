@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logFileName    = "intellijapp.log"
+	maxLogFileSize = 5 << 20
+	maxLogFiles    = 5
+	logRingSize    = 2000
+)
+
+// 可选的日志格式和级别
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+var (
+	supportedLogFormats = []string{"", LogFormatText, LogFormatJSON}
+	supportedLogLevels  = []string{"", "debug", "info", "warn", "error"}
+)
+
+// LogEntry 描述内存中保留的一条日志
+type LogEntry struct {
+	Time    string            `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// LogFilter 定义 GetRecentLogs 的筛选条件，零值返回全部日志
+type LogFilter struct {
+	Level string `json:"level"`
+	Query string `json:"query"`
+	Limit int    `json:"limit"`
+}
+
+// DefaultLogDir 返回平台默认的日志目录
+// Linux 遵循 XDG_STATE_HOME（默认 ~/.local/state），macOS 使用 ~/Library/Logs，Windows 使用 %LOCALAPPDATA%
+func DefaultLogDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("无法获取日志目录: %w", err)
+		}
+		return filepath.Join(cacheDir, appDataDirName, "logs"), nil
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("无法获取日志目录: %w", err)
+		}
+		return filepath.Join(home, "Library", "Logs", appDataDirName), nil
+	default:
+		if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+			return filepath.Join(stateHome, appDataDirName, "logs"), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("无法获取日志目录: %w", err)
+		}
+		return filepath.Join(home, ".local", "state", appDataDirName, "logs"), nil
+	}
+}
+
+// parseLogLevel 将设置中的级别名转换为 slog.Level，空字符串为 info
+// 只接受设置允许的 debug、info、warn、error（不区分大小写），不接受 slog 的 "INFO+2" 等写法
+func parseLogLevel(name string) (slog.Level, error) {
+	name = normalizeLogLevel(name)
+	if !slices.Contains(supportedLogLevels, name) {
+		return slog.LevelInfo, fmt.Errorf("%w: 不支持的日志级别 %q", ErrInvalidSettings, name)
+	}
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return slog.LevelInfo, fmt.Errorf("%w: 不支持的日志级别 %q", ErrInvalidSettings, name)
+	}
+	return level, nil
+}
+
+// normalizeLogLevel 将级别名转换为设置中保存的小写形式
+func normalizeLogLevel(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// rotatingWriter 按大小轮转的日志文件，保留 maxFiles 个历史文件（name.1 为最近一个）
+type rotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+	closed   bool
+}
+
+// newRotatingWriter 打开（追加）日志文件
+func newRotatingWriter(path string, maxSize int64, maxFiles int) (*rotatingWriter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建日志目录失败: %w", err)
+	}
+	w := &rotatingWriter{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+	w.file = file
+	w.size = info.Size()
+	return nil
+}
+
+// rotate 关闭当前文件，删除最旧的文件并依次重命名 name.N-1 -> name.N，当前文件变为 name.1
+// 无论重命名是否成功都会重新打开日志文件，重命名失败时继续追加到原文件，避免之后的写入全部失败
+func (w *rotatingWriter) rotate() error {
+	closeErr := w.file.Close()
+	w.file = nil
+	_ = os.Remove(fmt.Sprintf("%s.%d", w.path, w.maxFiles))
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", w.path, i), fmt.Sprintf("%s.%d", w.path, i+1))
+	}
+	renameErr := os.Rename(w.path, w.path+".1")
+	if err := w.open(); err != nil {
+		return errors.Join(closeErr, renameErr, err)
+	}
+	return errors.Join(closeErr, renameErr)
+}
+
+// Write 实现 io.Writer，写入前超过大小限制时先轮转
+// 轮转失败但文件已重新打开时仍写入当前文件，错误输出到标准错误
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+	if w.file == nil {
+		// 上次轮转后未能重新打开，重试
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			if w.file == nil {
+				return 0, fmt.Errorf("轮转日志文件失败: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "轮转日志文件失败: %v\n", err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close 关闭日志文件，之后的写入返回 os.ErrClosed
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// homeRedactor 将日志中的用户主目录替换为 ~，避免导出日志时泄露用户名
+// 只替换完整的目录路径，主目录为 /home/al 时不会替换 /home/alice 中的部分
+type homeRedactor struct {
+	homes []string
+}
+
+func newHomeRedactor(home string) *homeRedactor {
+	if home == "" || home == string(filepath.Separator) {
+		return &homeRedactor{}
+	}
+	homes := []string{home}
+	if slashed := filepath.ToSlash(home); slashed != home {
+		homes = append(homes, slashed)
+	}
+	return &homeRedactor{homes: homes}
+}
+
+// redact 替换字符串中的主目录
+func (r *homeRedactor) redact(s string) string {
+	for _, home := range r.homes {
+		s = replacePathPrefix(s, home, "~")
+	}
+	return s
+}
+
+// replaceAttr 作为 slog.HandlerOptions.ReplaceAttr 使用，处理消息和所有字符串、错误类型的属性
+func (r *homeRedactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindString:
+		a.Value = slog.StringValue(r.redact(a.Value.String()))
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case error:
+			a.Value = slog.StringValue(r.redact(v.Error()))
+		case fmt.Stringer:
+			a.Value = slog.StringValue(r.redact(v.String()))
+		}
+	}
+	return a
+}
+
+// logRing 在内存中保留最近的日志，供界面中的日志查看器读取
+type logRing struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int
+	full    bool
+}
+
+func newLogRing(size int) *logRing {
+	return &logRing{entries: make([]LogEntry, size)}
+}
+
+func (r *logRing) add(entry LogEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot 按时间顺序返回所有日志
+func (r *logRing) snapshot() []LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return slices.Clone(r.entries[:r.next])
+	}
+	return append(slices.Clone(r.entries[r.next:]), r.entries[:r.next]...)
+}
+
+// ringHandler 将日志记录写入 logRing
+type ringHandler struct {
+	ring     *logRing
+	level    slog.Leveler
+	redactor *homeRedactor
+	attrs    []slog.Attr
+	group    string
+}
+
+func (h *ringHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ringHandler) Handle(_ context.Context, record slog.Record) error {
+	entry := LogEntry{
+		Time:    record.Time.Format(time.RFC3339Nano),
+		Level:   record.Level.String(),
+		Message: h.redactor.redact(record.Message),
+	}
+	add := func(a slog.Attr) bool {
+		a = h.redactor.replaceAttr(nil, a)
+		if entry.Attrs == nil {
+			entry.Attrs = make(map[string]string)
+		}
+		key := a.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		entry.Attrs[key] = a.Value.String()
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	record.Attrs(add)
+	h.ring.add(entry)
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(slices.Clone(h.attrs), attrs...)
+	return &clone
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
+
+// fanoutHandler 将日志同时交给多个 Handler
+type fanoutHandler []slog.Handler
+
+func (h fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slices.ContainsFunc(h, func(handler slog.Handler) bool { return handler.Enabled(ctx, level) })
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	out := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		out[i] = handler.WithGroup(name)
+	}
+	return out
+}
+
+// newFormatHandler 按格式创建写入 w 的 Handler
+func newFormatHandler(w io.Writer, format string, opts *slog.HandlerOptions) slog.Handler {
+	if format == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// initLogging 根据设置创建日志记录器：写入内存、标准错误输出，指定日志目录时同时写入轮转文件
+// 日志格式在启动时确定，级别可通过 SetLogLevel 在运行时切换
+func (c *ConfigService) initLogging(settings LoggingSettings) {
+	level, err := parseLogLevel(settings.Level)
+	if err != nil {
+		level = slog.LevelInfo
+	}
+	c.logLevel.Set(level)
+
+	home, _ := os.UserHomeDir()
+	redactor := newHomeRedactor(home)
+	opts := &slog.HandlerOptions{Level: &c.logLevel, ReplaceAttr: redactor.replaceAttr}
+
+	c.logRing = newLogRing(logRingSize)
+	handlers := fanoutHandler{
+		&ringHandler{ring: c.logRing, level: &c.logLevel, redactor: redactor},
+		newFormatHandler(os.Stderr, LogFormatText, opts),
+	}
+
+	if c.logDir != "" {
+		writer, err := newRotatingWriter(filepath.Join(c.logDir, logFileName), maxLogFileSize, maxLogFiles)
+		if err != nil {
+			slog.Default().Warn("无法创建日志文件，仅输出到标准错误", slog.Any("error", err))
+		} else {
+			c.logFile = writer
+			handlers = append(handlers, newFormatHandler(writer, settings.Format, opts))
+		}
+	}
+
+	c.logger = slog.New(handlers)
+	if c.logFile != nil {
+		// 写入文件时作为应用的默认日志记录器，main 包和包级函数的日志也进入同一文件
+		slog.SetDefault(c.logger)
+	}
+}
+
+// GetRecentLogs 返回内存中保留的最近日志，按时间倒序，可按级别和关键字筛选
+func (c *ConfigService) GetRecentLogs(filter LogFilter) ([]LogEntry, error) {
+	minLevel := slog.LevelDebug
+	if filter.Level != "" {
+		level, err := parseLogLevel(filter.Level)
+		if err != nil {
+			return nil, err
+		}
+		minLevel = level
+	}
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+
+	entries := c.logRing.snapshot()
+	result := make([]LogEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(entry.Level)); err == nil && level < minLevel {
+			continue
+		}
+		if query != "" && !logEntryMatches(entry, query) {
+			continue
+		}
+		result = append(result, entry)
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// logEntryMatches 判断日志消息或属性值是否包含关键字（不区分大小写）
+func logEntryMatches(entry LogEntry, query string) bool {
+	if strings.Contains(strings.ToLower(entry.Message), query) {
+		return true
+	}
+	for key, value := range entry.Attrs {
+		if strings.Contains(strings.ToLower(key+"="+value), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLogLevel 立即切换日志级别并保存到设置
+func (c *ConfigService) SetLogLevel(level string) (AppSettings, error) {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return AppSettings{}, err
+	}
+	c.logLevel.Set(parsed)
+
+	updated, err := c.updateSettings(func(s *AppSettings) error {
+		s.Logging.Level = normalizeLogLevel(level)
+		return nil
+	})
+	if err != nil {
+		return AppSettings{}, err
+	}
+	c.logger.Info("日志级别已切换", slog.String("level", parsed.String()))
+	return updated, nil
+}
+
+// GetLogDir 返回日志文件所在目录，未写入文件时返回空字符串
+func (c *ConfigService) GetLogDir() string {
+	if c.logFile == nil {
+		return ""
+	}
+	return c.logDir
+}
+
+// ServiceShutdown 在应用退出时由 Wails 调用，关闭日志文件
+func (c *ConfigService) ServiceShutdown() error {
+	if c.logFile == nil {
+		return nil
+	}
+	return c.logFile.Close()
+}
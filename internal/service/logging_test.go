@@ -0,0 +1,150 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRotatingWriter 测试日志文件超过大小后轮转并只保留指定数量的历史文件
+func TestRotatingWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", logFileName)
+	w, err := newRotatingWriter(path, 100, 2)
+	if err != nil {
+		t.Fatalf("创建日志文件失败: %v", err)
+	}
+	defer w.Close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := fmt.Fprintf(w, "line %02d %s\n", i, strings.Repeat("x", 40)); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("缺少日志文件 %s: %v", name, err)
+		}
+		if info.Size() > 100 {
+			t.Errorf("%s 超过大小限制: %d", name, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("超出数量的历史文件应被删除: %v", err)
+	}
+	content, _ := os.ReadFile(path)
+	if !strings.Contains(string(content), "line 09") {
+		t.Errorf("当前文件应包含最新的日志: %s", content)
+	}
+}
+
+// TestRotatingWriterRecovers 测试轮转失败后仍能继续写入，关闭后写入返回 os.ErrClosed
+func TestRotatingWriterRecovers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), logFileName)
+	w, err := newRotatingWriter(path, 10, 1)
+	if err != nil {
+		t.Fatalf("创建日志文件失败: %v", err)
+	}
+	// name.1 为非空目录时无法删除也无法覆盖，轮转失败
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := fmt.Fprintf(w, "line %d %s\n", i, strings.Repeat("x", 10)); err != nil {
+			t.Fatalf("轮转失败后写入失败: %v", err)
+		}
+	}
+	if content, _ := os.ReadFile(path); strings.Count(string(content), "line") != 3 {
+		t.Errorf("轮转失败时应继续写入原文件: %s", content)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("关闭后写入应返回 os.ErrClosed，得到 %v", err)
+	}
+}
+
+// TestHomeRedactor 测试只替换完整的主目录路径
+func TestHomeRedactor(t *testing.T) {
+	home := filepath.Join(string(filepath.Separator)+"home", "al")
+	r := newHomeRedactor(home)
+	tests := map[string]string{
+		home:                                "~",
+		filepath.Join(home, "idea"):         filepath.Join("~", "idea"),
+		"open " + home + ": denied":         "open ~: denied",
+		filepath.Join(home+"ice", "idea"):   filepath.Join(home+"ice", "idea"),
+		filepath.Join("/srv", home, "idea"): filepath.Join("/srv", home, "idea"),
+	}
+	for value, want := range tests {
+		if got := r.redact(value); got != want {
+			t.Errorf("redact(%q) = %q，期望 %q", value, got, want)
+		}
+	}
+}
+
+// TestGetRecentLogs 测试日志的主目录脱敏、级别筛选和关键字筛选
+func TestGetRecentLogs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(t.TempDir(), settingsFileName)))
+	svc.logger.Debug("调试信息")
+	svc.logger.Info("读取文件", slog.String("path", filepath.Join(home, "idea", "bin")))
+	svc.logger.Warn("写入失败", slog.Any("error", errors.New("open "+filepath.Join(home, "x.vmoptions")+": denied")))
+
+	all, err := svc.GetRecentLogs(LogFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].Message != "写入失败" {
+		t.Fatalf("默认 info 级别下应有 2 条日志且按时间倒序: %+v", all)
+	}
+	for _, entry := range all {
+		for _, value := range entry.Attrs {
+			if strings.Contains(value, home) {
+				t.Errorf("主目录未脱敏: %q", value)
+			}
+		}
+	}
+	if got := all[1].Attrs["path"]; got != filepath.Join("~", "idea", "bin") {
+		t.Errorf("脱敏结果不正确: %q", got)
+	}
+
+	warnings, _ := svc.GetRecentLogs(LogFilter{Level: "warn"})
+	if len(warnings) != 1 {
+		t.Errorf("warn 级别应只返回 1 条: %+v", warnings)
+	}
+	matched, _ := svc.GetRecentLogs(LogFilter{Query: "IDEA"})
+	if len(matched) != 1 || matched[0].Message != "读取文件" {
+		t.Errorf("关键字筛选不正确: %+v", matched)
+	}
+
+	for _, level := range []string{"verbose", "INFO+2"} {
+		if _, err := svc.SetLogLevel(level); !errors.Is(err, ErrInvalidSettings) {
+			t.Errorf("无效级别 %q 应返回 ErrInvalidSettings: %v", level, err)
+		}
+	}
+	settings, err := svc.SetLogLevel("WARN")
+	if err != nil || settings.Logging.Level != "warn" {
+		t.Fatalf("级别应以小写保存: %+v, %v", settings.Logging, err)
+	}
+	if _, err := svc.UpdateSettings(svc.GetSettings()); err != nil {
+		t.Errorf("切换级别后应能再次保存设置: %v", err)
+	}
+	settings, err = svc.SetLogLevel("debug")
+	if err != nil || settings.Logging.Level != "debug" {
+		t.Fatalf("切换日志级别失败: %+v, %v", settings.Logging, err)
+	}
+	svc.logger.Debug("调试信息")
+	if debug, _ := svc.GetRecentLogs(LogFilter{Query: "调试"}); len(debug) != 1 {
+		t.Errorf("切换到 debug 后应记录调试日志: %+v", debug)
+	}
+}
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// heapBudgetRatio 所有 IDE 的堆内存合计不宜超过物理内存的该比例，其余留给系统、构建和浏览器等进程
+	heapBudgetRatio = 0.5
+	// jvmOverheadRatio 估算 JVM 进程占用时在堆内存之外增加的比例（元空间、代码缓存、线程栈等）
+	jvmOverheadRatio = 0.3
+	// minSuggestedHeap 建议的最小堆内存，低于该值 IDE 会频繁垃圾回收
+	minSuggestedHeap = 1 << 30
+	// heapSuggestionStep 建议值按该粒度向下取整
+	heapSuggestionStep = 256 << 20
+)
+
+// 内存检查结果的级别
+const (
+	MemoryLevelOK       = "ok"
+	MemoryLevelWarning  = "warning"
+	MemoryLevelCritical = "critical"
+)
+
+// systemMemoryInfo 保存物理内存总量和可用量，单位为字节
+type systemMemoryInfo struct {
+	Total     uint64
+	Available uint64
+}
+
+// InstallationMemory 描述一个已登记安装的堆内存配置
+type InstallationMemory struct {
+	InstallationID string `json:"installationId"`
+	Label          string `json:"label"`
+	Path           string `json:"path"`
+	Xmx            string `json:"xmx"`
+	HeapBytes      int64  `json:"heapBytes"`
+	// DefaultHeap 表示 vmoptions 未设置 -Xmx，按 JVM 默认的物理内存 1/4 估算
+	DefaultHeap bool `json:"defaultHeap"`
+	Running     bool `json:"running"`
+	// ResidentBytes 为运行中的进程当前占用的物理内存，已计入系统的已用内存；无法获取时为 0
+	ResidentBytes  int64  `json:"residentBytes"`
+	SuggestedXmx   string `json:"suggestedXmx"`
+	SuggestedBytes int64  `json:"suggestedBytes"`
+	Error          string `json:"error,omitempty"`
+}
+
+// MemoryAdvice 汇总物理内存与各 IDE 堆内存配置的检查结果
+type MemoryAdvice struct {
+	Level               string               `json:"level"`
+	TotalBytes          int64                `json:"totalBytes"`
+	AvailableBytes      int64                `json:"availableBytes"`
+	HeapBudgetBytes     int64                `json:"heapBudgetBytes"`
+	ConfiguredHeapBytes int64                `json:"configuredHeapBytes"`
+	RunningHeapBytes    int64                `json:"runningHeapBytes"`
+	Warnings            []string             `json:"warnings"`
+	Installations       []InstallationMemory `json:"installations"`
+}
+
+// parseMeminfo 解析 /proc/meminfo，缺少 MemAvailable 的旧内核用 MemFree 代替
+func parseMeminfo(r io.Reader) (systemMemoryInfo, error) {
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, rest, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "kB") {
+			n *= 1024
+		}
+		values[name] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return systemMemoryInfo{}, err
+	}
+
+	info := systemMemoryInfo{Total: values["MemTotal"], Available: values["MemAvailable"]}
+	if _, ok := values["MemAvailable"]; !ok {
+		info.Available = values["MemFree"]
+	}
+	if info.Total == 0 {
+		return systemMemoryInfo{}, fmt.Errorf("%w: meminfo 缺少 MemTotal", ErrMemoryUnknown)
+	}
+	return info, nil
+}
+
+// formatJVMSize 将字节数格式化为 -Xmx 使用的大小，整 GB 使用 g，否则使用 m
+func formatJVMSize(bytes int64) string {
+	if bytes%(1<<30) == 0 {
+		return strconv.FormatInt(bytes>>30, 10) + "g"
+	}
+	return strconv.FormatInt(bytes>>20, 10) + "m"
+}
+
+// jvmFootprint 估算堆内存为 heap 的 JVM 进程的内存占用
+func jvmFootprint(heap int64) int64 {
+	return heap + int64(float64(heap)*jvmOverheadRatio)
+}
+
+// adviseMemory 根据物理内存检查各安装的堆内存合计，超出预算时按配置比例给出每个 IDE 的建议值
+func adviseMemory(mem systemMemoryInfo, installs []InstallationMemory) MemoryAdvice {
+	total := int64(mem.Total)
+	advice := MemoryAdvice{
+		Level:           MemoryLevelOK,
+		TotalBytes:      total,
+		AvailableBytes:  int64(mem.Available),
+		HeapBudgetBytes: int64(float64(total) * heapBudgetRatio),
+		Warnings:        []string{},
+		Installations:   installs,
+	}
+
+	// 运行中的 IDE 当前占用的内存已不在可用内存中，只比较继续增长到 -Xmx 还需要的内存
+	var runningFootprint, runningGrowth int64
+	for _, inst := range installs {
+		advice.ConfiguredHeapBytes += inst.HeapBytes
+		if inst.Running {
+			footprint := jvmFootprint(inst.HeapBytes)
+			advice.RunningHeapBytes += inst.HeapBytes
+			runningFootprint += footprint
+			runningGrowth += max(footprint-inst.ResidentBytes, 0)
+		}
+	}
+
+	overcommitted := advice.ConfiguredHeapBytes > advice.HeapBudgetBytes
+	if overcommitted {
+		advice.Level = MemoryLevelWarning
+		advice.Warnings = append(advice.Warnings, fmt.Sprintf("%d 个 IDE 的堆内存合计 %s，超过物理内存 %s 的一半，同时运行时会频繁换页",
+			len(installs), formatJVMSize(advice.ConfiguredHeapBytes), formatJVMSize(total)))
+	}
+	if runningGrowth > advice.AvailableBytes {
+		advice.Level = MemoryLevelCritical
+		advice.Warnings = append(advice.Warnings, fmt.Sprintf("运行中的 IDE 预计占用 %s 内存，继续增长所需的 %s 超过当前可用内存 %s",
+			formatJVMSize(runningFootprint), formatJVMSize(runningGrowth), formatJVMSize(advice.AvailableBytes)))
+	}
+
+	for i := range advice.Installations {
+		inst := &advice.Installations[i]
+		suggested := inst.HeapBytes
+		if overcommitted || suggested > advice.HeapBudgetBytes {
+			if overcommitted {
+				suggested = int64(float64(advice.HeapBudgetBytes) * float64(inst.HeapBytes) / float64(advice.ConfiguredHeapBytes))
+			}
+			// 单个 IDE 也不宜超过预算；建议值只会调小，不低于 minSuggestedHeap
+			suggested = min(suggested, advice.HeapBudgetBytes)
+			suggested = max(suggested/heapSuggestionStep*heapSuggestionStep, minSuggestedHeap)
+			suggested = min(suggested, inst.HeapBytes)
+		}
+		inst.SuggestedBytes = suggested
+		inst.SuggestedXmx = formatJVMSize(suggested)
+
+		if inst.HeapBytes > advice.HeapBudgetBytes {
+			if advice.Level == MemoryLevelOK {
+				advice.Level = MemoryLevelWarning
+			}
+			advice.Warnings = append(advice.Warnings, fmt.Sprintf("%s 的 -Xmx%s 超过物理内存的一半", inst.Label, inst.Xmx))
+		}
+	}
+	return advice
+}
+
+// maxHeapPrefixes 设置最大堆内存的参数，-XX:MaxHeapSize= 与 -Xmx 等价，以最后出现的为准
+var maxHeapPrefixes = []string{"-Xmx", "-XX:MaxHeapSize="}
+
+// installationHeap 读取安装生效的 -Xmx 或 -XX:MaxHeapSize=，未设置时按 JVM 默认值估算
+func installationHeap(inst SavedInstallation, total uint64) InstallationMemory {
+	home := installationHome(inst.Path)
+	result := InstallationMemory{
+		InstallationID: inst.ID, Label: inst.Label, Path: inst.Path,
+	}
+	instances := detectRunningInstances(home)
+	result.Running = instances.Running
+	for _, proc := range instances.Instances {
+		if proc.PID > 0 {
+			result.ResidentBytes += processResidentBytes(proc.PID)
+		}
+	}
+	if result.Label == "" {
+		result.Label = inst.ProductName
+	}
+
+	effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	for _, arg := range effective.Arguments {
+		for _, prefix := range maxHeapPrefixes {
+			if !strings.HasPrefix(arg, prefix) {
+				continue
+			}
+			if heap, ok := parseJVMSize(strings.TrimPrefix(arg, prefix)); ok {
+				result.Xmx = strings.TrimPrefix(arg, prefix)
+				result.HeapBytes = heap
+			}
+		}
+	}
+	if result.HeapBytes == 0 {
+		result.DefaultHeap = true
+		result.HeapBytes = int64(total / 4)
+		result.Xmx = formatJVMSize(result.HeapBytes)
+	}
+	return result
+}
+
+// GetMemoryAdvice 检查已登记安装的堆内存配置与物理内存是否匹配，并给出每个 IDE 的建议 -Xmx
+func (c *ConfigService) GetMemoryAdvice() (MemoryAdvice, error) {
+	mem, err := systemMemory()
+	if err != nil {
+		c.logger.Error("读取系统内存失败", slog.Any("error", err))
+		return MemoryAdvice{}, err
+	}
+
+	installs := []InstallationMemory{}
+	for _, inst := range c.currentSettings().Installations {
+		installs = append(installs, installationHeap(inst, mem.Total))
+	}
+	advice := adviseMemory(mem, installs)
+	c.logger.Info("内存检查完成", slog.String("level", advice.Level), slog.Int64("configuredHeap", advice.ConfiguredHeapBytes))
+	return advice, nil
+}
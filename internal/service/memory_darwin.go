@@ -0,0 +1,27 @@
+//go:build darwin
+// +build darwin
+
+package service
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// systemMemory 通过 sysctl 读取物理内存
+// 可用内存只统计空闲页和可回收的预读页，比活动监视器显示的可用内存偏小
+func systemMemory() (systemMemoryInfo, error) {
+	total, err := unix.SysctlUint64("hw.memsize")
+	if err != nil {
+		return systemMemoryInfo{}, fmt.Errorf("%w: %v", ErrMemoryUnknown, err)
+	}
+	info := systemMemoryInfo{Total: total}
+	pageSize := uint64(unix.Getpagesize())
+	for _, name := range []string{"vm.page_free_count", "vm.page_speculative_count"} {
+		if pages, err := unix.SysctlUint32(name); err == nil {
+			info.Available += uint64(pages) * pageSize
+		}
+	}
+	return info, nil
+}
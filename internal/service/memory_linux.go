@@ -0,0 +1,19 @@
+//go:build linux
+// +build linux
+
+package service
+
+import (
+	"fmt"
+	"os"
+)
+
+// systemMemory 从 /proc/meminfo 读取物理内存
+func systemMemory() (systemMemoryInfo, error) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return systemMemoryInfo{}, fmt.Errorf("%w: %v", ErrMemoryUnknown, err)
+	}
+	defer file.Close()
+	return parseMeminfo(file)
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package service
+
+// systemMemory 其他平台不支持读取物理内存
+func systemMemory() (systemMemoryInfo, error) {
+	return systemMemoryInfo{}, ErrMemoryUnknown
+}
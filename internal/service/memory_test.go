@@ -0,0 +1,107 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParseMeminfo 测试 /proc/meminfo 解析
+func TestParseMeminfo(t *testing.T) {
+	meminfo := "MemTotal:       16318508 kB\nMemFree:         1033144 kB\nMemAvailable:    9437184 kB\nHugePages_Total:       0\n"
+	info, err := parseMeminfo(strings.NewReader(meminfo))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if info.Total != 16318508*1024 || info.Available != 9437184*1024 {
+		t.Errorf("解析结果不正确: %+v", info)
+	}
+
+	info, err = parseMeminfo(strings.NewReader("MemTotal: 2048 kB\nMemFree: 1024 kB\n"))
+	if err != nil || info.Available != 1024*1024 {
+		t.Errorf("缺少 MemAvailable 时应使用 MemFree: %+v, %v", info, err)
+	}
+	if _, err := parseMeminfo(strings.NewReader("MemFree: 1024 kB\n")); err == nil {
+		t.Error("缺少 MemTotal 应报错")
+	}
+}
+
+// TestAdviseMemory 测试堆内存合计超出物理内存时的建议值
+func TestAdviseMemory(t *testing.T) {
+	const gb = int64(1 << 30)
+	mem := systemMemoryInfo{Total: uint64(16 * gb), Available: uint64(6 * gb)}
+
+	installs := []InstallationMemory{
+		{Label: "IDEA", Xmx: "8g", HeapBytes: 8 * gb, Running: true},
+		{Label: "GoLand", Xmx: "8g", HeapBytes: 8 * gb, Running: true},
+		{Label: "PyCharm", Xmx: "8g", HeapBytes: 8 * gb},
+	}
+	advice := adviseMemory(mem, installs)
+	if advice.Level != MemoryLevelCritical || advice.ConfiguredHeapBytes != 24*gb || advice.RunningHeapBytes != 16*gb {
+		t.Errorf("检查结果不正确: %+v", advice)
+	}
+	for _, inst := range advice.Installations {
+		if inst.SuggestedXmx != "2560m" {
+			t.Errorf("%s: 期望建议 2560m，实际 %s", inst.Label, inst.SuggestedXmx)
+		}
+	}
+
+	advice = adviseMemory(mem, []InstallationMemory{
+		{Label: "IDEA", Xmx: "3000m", HeapBytes: 3000 << 20},
+		{Label: "GoLand", Xmx: "750m", HeapBytes: 750 << 20},
+	})
+	if advice.Level != MemoryLevelOK || len(advice.Warnings) != 0 {
+		t.Errorf("未超出预算时不应警告: %+v", advice)
+	}
+	if advice.Installations[0].SuggestedXmx != "3000m" || advice.Installations[1].SuggestedXmx != "750m" {
+		t.Errorf("未超出预算时应保留原值: %+v", advice.Installations)
+	}
+
+	advice = adviseMemory(mem, []InstallationMemory{{Label: "IDEA", Xmx: "12g", HeapBytes: 12 * gb}})
+	if advice.Level != MemoryLevelWarning || advice.Installations[0].SuggestedXmx != "8g" {
+		t.Errorf("单个 IDE 超出预算时应建议调小: %+v", advice)
+	}
+
+	low := systemMemoryInfo{Total: uint64(16 * gb), Available: uint64(2 * gb)}
+	advice = adviseMemory(low, []InstallationMemory{{Label: "IDEA", Xmx: "2g", HeapBytes: 2 * gb, Running: true}})
+	if advice.Level != MemoryLevelCritical {
+		t.Errorf("运行中的 IDE 预计占用超过可用内存时应为 critical: %+v", advice)
+	}
+
+	// 已占用大部分堆内存的 IDE 不会再从可用内存中占用太多
+	advice = adviseMemory(low, []InstallationMemory{
+		{Label: "IDEA", Xmx: "2g", HeapBytes: 2 * gb, Running: true, ResidentBytes: 2 * gb},
+		{Label: "GoLand", Xmx: "2g", HeapBytes: 2 * gb, Running: true, ResidentBytes: 2 * gb},
+	})
+	if advice.Level != MemoryLevelOK || len(advice.Warnings) != 0 {
+		t.Errorf("已驻留的 IDE 不应重复计算: %+v", advice)
+	}
+}
+
+// TestInstallationHeap 测试读取安装生效的 -Xmx
+func TestInstallationHeap(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xms128m\n-Xmx2048m\n-Xmx3g\n")
+	inst := installationHeap(SavedInstallation{ID: "1", Label: "IDEA", Path: home}, 16<<30)
+	if inst.Xmx != "3g" || inst.HeapBytes != 3<<30 || inst.DefaultHeap {
+		t.Errorf("应使用最后一个 -Xmx: %+v", inst)
+	}
+
+	other := filepath.Join(base, "goland")
+	createTestInstallation(t, other, "GO", "241.1", "-Xms128m\n")
+	inst = installationHeap(SavedInstallation{ID: "2", ProductName: "GoLand", Path: other}, 16<<30)
+	if !inst.DefaultHeap || inst.Xmx != "4g" || inst.Label != "GoLand" {
+		t.Errorf("未设置 -Xmx 时应按物理内存的 1/4 估算: %+v", inst)
+	}
+
+	tuned := filepath.Join(base, "pycharm")
+	createTestInstallation(t, tuned, "PY", "241.1", "-Xmx2g\n-XX:MaxHeapSize=6g\n")
+	inst = installationHeap(SavedInstallation{ID: "3", Label: "PyCharm", Path: tuned}, 16<<30)
+	if inst.Xmx != "6g" || inst.HeapBytes != 6<<30 || inst.DefaultHeap {
+		t.Errorf("应识别 -XX:MaxHeapSize=: %+v", inst)
+	}
+}
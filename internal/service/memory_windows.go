@@ -0,0 +1,36 @@
+//go:build windows
+// +build windows
+
+package service
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGlobalMemoryStatusEx = windows.NewLazySystemDLL("kernel32.dll").NewProc("GlobalMemoryStatusEx")
+
+// memoryStatusEx 对应 Win32 的 MEMORYSTATUSEX 结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// systemMemory 通过 GlobalMemoryStatusEx 读取物理内存
+func systemMemory() (systemMemoryInfo, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+	if ret, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); ret == 0 {
+		return systemMemoryInfo{}, fmt.Errorf("%w: %v", ErrMemoryUnknown, err)
+	}
+	return systemMemoryInfo{Total: status.TotalPhys, Available: status.AvailPhys}, nil
+}
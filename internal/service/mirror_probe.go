@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// 镜像类型
+const (
+	mirrorKindWeb = "web"
+	mirrorKindAPI = "api"
+)
+
+const (
+	mirrorProbeTimeout = 5 * time.Second
+	mirrorCacheTTL     = 10 * time.Minute
+)
+
+// MirrorStatus 保存单个镜像站点的探测结果
+type MirrorStatus struct {
+	URL       string `json:"url"`
+	Kind      string `json:"kind"`
+	Healthy   bool   `json:"healthy"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt string `json:"checkedAt"`
+	// priority 为镜像在配置列表中的位置，延迟相同时按配置顺序排序
+	priority int
+}
+
+// mirrorCache 缓存镜像探测结果，避免每次转换 URL 都重新探测
+type mirrorCache struct {
+	ttl    time.Duration
+	client *http.Client
+
+	// probeMu 保证同一时间只有一次探测，其他调用方等待探测结果
+	probeMu sync.Mutex
+	mu      sync.RWMutex
+	results []MirrorStatus
+	expires time.Time
+	// generation 在 reset 时递增，探测期间设置发生变更时丢弃该次结果
+	generation uint64
+}
+
+// newMirrorCache 创建镜像探测缓存
+func newMirrorCache(ttl time.Duration, client *http.Client) *mirrorCache {
+	return &mirrorCache{
+		ttl:    ttl,
+		client: client,
+	}
+}
+
+// cached 返回未过期的探测结果
+func (m *mirrorCache) cached() ([]MirrorStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.results == nil || time.Now().After(m.expires) {
+		return nil, false
+	}
+	return slices.Clone(m.results), true
+}
+
+// status 返回排序后的探测结果，缓存过期或 force 为 true 时重新并发探测所有镜像
+func (m *mirrorCache) status(force bool, webMirrors, apiMirrors []string, logger *slog.Logger) []MirrorStatus {
+	if !force {
+		if results, ok := m.cached(); ok {
+			return results
+		}
+	}
+
+	m.probeMu.Lock()
+	defer m.probeMu.Unlock()
+
+	// 等待锁期间其他调用方可能已完成探测
+	if !force {
+		if results, ok := m.cached(); ok {
+			return results
+		}
+	}
+
+	targets := make([]MirrorStatus, 0, len(webMirrors)+len(apiMirrors))
+	for i, url := range webMirrors {
+		targets = append(targets, MirrorStatus{URL: url, Kind: mirrorKindWeb, priority: i})
+	}
+	for i, url := range apiMirrors {
+		targets = append(targets, MirrorStatus{URL: url, Kind: mirrorKindAPI, priority: i})
+	}
+
+	m.mu.RLock()
+	client, generation := m.client, m.generation
+	m.mu.RUnlock()
+
+	results := probeMirrors(context.Background(), client, targets)
+	rankMirrors(results)
+
+	m.mu.Lock()
+	// 探测使用的是变更前的客户端和镜像列表，结果只返回给本次调用方，不写入缓存
+	stale := m.generation != generation
+	if !stale {
+		m.results = results
+		m.expires = time.Now().Add(m.ttl)
+	}
+	m.mu.Unlock()
+	if stale {
+		logger.Debug("探测期间网络设置已变更，丢弃探测结果")
+		return slices.Clone(results)
+	}
+
+	logger.Debug("镜像探测完成", slog.Int("count", len(results)))
+	return slices.Clone(results)
+}
+
+// reset 更换探测使用的 HTTP 客户端并清除缓存，镜像或代理设置变更后调用
+func (m *mirrorCache) reset(client *http.Client) {
+	m.mu.Lock()
+	m.client = client
+	m.results = nil
+	m.generation++
+	m.mu.Unlock()
+}
+
+// probeMirrors 并发探测所有镜像，总耗时不超过单个镜像的超时时间
+func probeMirrors(ctx context.Context, client *http.Client, targets []MirrorStatus) []MirrorStatus {
+	results := slices.Clone(targets)
+
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(status *MirrorStatus) {
+			defer wg.Done()
+			probeMirror(ctx, client, status)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probeMirror 使用 HEAD 请求探测单个镜像并记录延迟
+func probeMirror(ctx context.Context, client *http.Client, status *MirrorStatus) {
+	start := time.Now()
+	defer func() {
+		status.CheckedAt = time.Now().Format(time.RFC3339)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, status.URL, nil)
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+
+	resp, err := client.Do(req)
+	status.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		status.Error = err.Error()
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		status.Error = http.StatusText(resp.StatusCode)
+		return
+	}
+	status.Healthy = true
+}
+
+// rankMirrors 排序探测结果：可用的镜像在前，按延迟从低到高；不可用的保持配置顺序
+func rankMirrors(results []MirrorStatus) {
+	slices.SortStableFunc(results, func(a, b MirrorStatus) int {
+		if a.Kind != b.Kind {
+			if a.Kind == mirrorKindWeb {
+				return -1
+			}
+			return 1
+		}
+		if a.Healthy != b.Healthy {
+			if a.Healthy {
+				return -1
+			}
+			return 1
+		}
+		if a.Healthy && a.LatencyMs != b.LatencyMs {
+			return int(a.LatencyMs - b.LatencyMs)
+		}
+		return a.priority - b.priority
+	})
+}
+
+// rankedMirrors 返回指定类型的镜像 URL，可用的镜像按延迟排在前面，不可用的排在最后作为兜底
+func rankedMirrors(results []MirrorStatus, kind string) []string {
+	var urls []string
+	for _, status := range results {
+		if status.Kind == kind {
+			urls = append(urls, status.URL)
+		}
+	}
+	return urls
+}
+
+// bestMirror 返回指定类型中延迟最低的可用镜像
+func bestMirror(results []MirrorStatus, kind string) (string, bool) {
+	for _, status := range results {
+		if status.Kind == kind && status.Healthy {
+			return status.URL, true
+		}
+	}
+	return "", false
+}
+
+// GetMirrorStatus 返回所有镜像站点的探测结果（含延迟和可用性），结果在 TTL 内缓存
+func (c *ConfigService) GetMirrorStatus() []MirrorStatus {
+	network := c.networkSettings()
+	return c.mirrors.status(false, network.WebMirrors, network.APIMirrors, c.logger)
+}
+
+// RefreshMirrorStatus 忽略缓存，重新探测所有镜像站点
+func (c *ConfigService) RefreshMirrorStatus() []MirrorStatus {
+	network := c.networkSettings()
+	return c.mirrors.status(true, network.WebMirrors, network.APIMirrors, c.logger)
+}
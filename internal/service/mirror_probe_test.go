@@ -0,0 +1,121 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestRankMirrors 测试镜像排序：可用的按延迟排序，不可用的保持配置顺序
+func TestRankMirrors(t *testing.T) {
+	results := []MirrorStatus{
+		{URL: "api-1", Kind: mirrorKindAPI, Healthy: true, LatencyMs: 50, priority: 0},
+		{URL: "web-1", Kind: mirrorKindWeb, Healthy: false, priority: 0},
+		{URL: "web-2", Kind: mirrorKindWeb, Healthy: true, LatencyMs: 300, priority: 1},
+		{URL: "web-3", Kind: mirrorKindWeb, Healthy: true, LatencyMs: 80, priority: 2},
+		{URL: "web-4", Kind: mirrorKindWeb, Healthy: false, priority: 3},
+	}
+
+	rankMirrors(results)
+
+	expected := []string{"web-3", "web-2", "web-1", "web-4", "api-1"}
+	for i, url := range expected {
+		if results[i].URL != url {
+			t.Fatalf("位置 %d 期望 %s，实际 %s", i, url, results[i].URL)
+		}
+	}
+
+	if best, ok := bestMirror(results, mirrorKindWeb); !ok || best != "web-3" {
+		t.Errorf("最佳镜像应为 web-3，实际 %s", best)
+	}
+	if urls := rankedMirrors(results, mirrorKindAPI); len(urls) != 1 || urls[0] != "api-1" {
+		t.Errorf("API 镜像列表不正确: %v", urls)
+	}
+}
+
+// TestMirrorCacheProbesConcurrently 测试并发探测与结果缓存
+func TestMirrorCacheProbesConcurrently(t *testing.T) {
+	var requests atomic.Int32
+	delay := 200 * time.Millisecond
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(delay)
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer fast.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := newMirrorCache(time.Minute, &http.Client{Timeout: mirrorProbeTimeout})
+	webMirrors := []string{broken.URL, slow.URL, fast.URL}
+
+	start := time.Now()
+	results := cache.status(false, webMirrors, nil, logger)
+	elapsed := time.Since(start)
+
+	// 并发探测时总耗时约等于最慢的镜像，而不是所有镜像之和
+	if elapsed >= 2*delay {
+		t.Errorf("探测耗时过长，可能未并发执行: %v", elapsed)
+	}
+
+	if best, ok := bestMirror(results, mirrorKindWeb); !ok || best != fast.URL {
+		t.Errorf("最佳镜像应为响应最快的站点，实际 %s", best)
+	}
+	if results[len(results)-1].URL != broken.URL || results[len(results)-1].Healthy {
+		t.Error("返回错误状态的镜像应被标记为不可用并排在最后")
+	}
+
+	cache.status(false, webMirrors, nil, logger)
+	if got := requests.Load(); got != 3 {
+		t.Errorf("缓存有效期内不应重复探测，请求次数: %d", got)
+	}
+
+	cache.status(true, webMirrors, nil, logger)
+	if got := requests.Load(); got != 6 {
+		t.Errorf("强制刷新应重新探测，请求次数: %d", got)
+	}
+}
+
+// TestMirrorCacheResetDuringProbe 测试探测期间重置缓存时丢弃旧设置的探测结果
+func TestMirrorCacheResetDuringProbe(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var once sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+	}))
+	defer server.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cache := newMirrorCache(time.Minute, &http.Client{Timeout: mirrorProbeTimeout})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.status(false, []string{server.URL}, nil, logger)
+	}()
+
+	<-started
+	cache.reset(&http.Client{Timeout: mirrorProbeTimeout})
+	close(release)
+	<-done
+
+	if results, ok := cache.cached(); ok {
+		t.Errorf("重置前开始的探测结果不应写入缓存: %+v", results)
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// 代理模式
+const (
+	ProxyModeSystem = "system" // 使用 HTTP_PROXY/HTTPS_PROXY/NO_PROXY 环境变量
+	ProxyModeNone   = "none"   // 直连
+	ProxyModeManual = "manual" // 使用 ProxyURL 指定的 HTTP 或 SOCKS5 代理
+)
+
+// NetworkSettings 保存检查更新使用的镜像和代理设置
+type NetworkSettings struct {
+	WebMirrors []string `json:"webMirrors"`
+	APIMirrors []string `json:"apiMirrors"`
+	ProxyMode  string   `json:"proxyMode"`
+	ProxyURL   string   `json:"proxyUrl"`
+}
+
+// MirrorValidation 保存镜像站点的验证结果
+type MirrorValidation struct {
+	URL     string `json:"url"`
+	Kind    string `json:"kind"`
+	Valid   bool   `json:"valid"`
+	Version string `json:"version,omitempty"`
+	Message string `json:"message"`
+}
+
+// defaultNetworkSettings 返回默认的网络设置
+func defaultNetworkSettings() NetworkSettings {
+	return NetworkSettings{
+		WebMirrors: slices.Clone(githubMirrors),
+		APIMirrors: slices.Clone(githubAPIMirrors),
+		ProxyMode:  ProxyModeSystem,
+	}
+}
+
+// withDefaults 补全缺失的字段
+func (n NetworkSettings) withDefaults() NetworkSettings {
+	if len(n.WebMirrors) == 0 {
+		n.WebMirrors = slices.Clone(githubMirrors)
+	}
+	if len(n.APIMirrors) == 0 {
+		n.APIMirrors = slices.Clone(githubAPIMirrors)
+	}
+	if n.ProxyMode == "" {
+		n.ProxyMode = ProxyModeSystem
+	}
+	return n
+}
+
+// normalizeMirrorList 规范化镜像列表：去除空白和末尾斜杠，去重并校验 URL 格式
+func normalizeMirrorList(mirrors []string) ([]string, error) {
+	var result []string
+	for _, mirror := range mirrors {
+		mirror = strings.TrimRight(strings.TrimSpace(mirror), "/")
+		if mirror == "" {
+			continue
+		}
+
+		parsed, err := url.Parse(mirror)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMirrorURL, mirror)
+		}
+
+		if !slices.Contains(result, mirror) {
+			result = append(result, mirror)
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要保留一个镜像", ErrInvalidMirrorURL)
+	}
+	return result, nil
+}
+
+// validate 校验并规范化网络设置
+func (n NetworkSettings) validate() (NetworkSettings, error) {
+	var err error
+	if n.WebMirrors, err = normalizeMirrorList(n.WebMirrors); err != nil {
+		return n, err
+	}
+	if n.APIMirrors, err = normalizeMirrorList(n.APIMirrors); err != nil {
+		return n, err
+	}
+
+	n.ProxyURL = strings.TrimSpace(n.ProxyURL)
+	switch n.ProxyMode {
+	case ProxyModeSystem, ProxyModeNone:
+	case ProxyModeManual:
+		if _, err := parseProxyURL(n.ProxyURL); err != nil {
+			return n, err
+		}
+	default:
+		return n, fmt.Errorf("%w: 未知的代理模式 %q", ErrInvalidProxy, n.ProxyMode)
+	}
+	return n, nil
+}
+
+// parseProxyURL 解析代理地址，支持 http、https 和 socks5 协议
+func parseProxyURL(raw string) (*url.URL, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProxy, raw)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+		return parsed, nil
+	default:
+		return nil, fmt.Errorf("%w: 不支持的协议 %q", ErrInvalidProxy, parsed.Scheme)
+	}
+}
+
+// newTransport 根据网络设置创建 HTTP Transport
+func newTransport(network NetworkSettings) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	switch network.ProxyMode {
+	case ProxyModeNone:
+		transport.Proxy = nil
+	case ProxyModeManual:
+		if proxyURL, err := parseProxyURL(network.ProxyURL); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	default:
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	return transport
+}
+
+// networkSettings 返回当前生效的网络设置
+func (c *ConfigService) networkSettings() NetworkSettings {
+	return c.currentSettings().Network
+}
+
+// httpClient 返回应用网络设置的 HTTP 客户端
+func (c *ConfigService) httpClient() *http.Client {
+	return &http.Client{Transport: newTransport(c.networkSettings()), Timeout: httpTimeout}
+}
+
+// GetNetworkSettings 返回检查更新使用的镜像和代理设置
+func (c *ConfigService) GetNetworkSettings() NetworkSettings {
+	return c.networkSettings()
+}
+
+// UpdateNetworkSettings 校验并保存镜像和代理设置
+// 新增的 API 镜像必须能返回兼容 GitHub 的 Release JSON 才会被接受
+func (c *ConfigService) UpdateNetworkSettings(network NetworkSettings) (NetworkSettings, error) {
+	network, err := network.validate()
+	if err != nil {
+		return NetworkSettings{}, err
+	}
+
+	if err := c.validateNewAPIMirrors(network); err != nil {
+		return NetworkSettings{}, err
+	}
+
+	if _, err := c.updateSettings(func(s *AppSettings) error {
+		s.Network = network
+		return nil
+	}); err != nil {
+		return NetworkSettings{}, err
+	}
+
+	c.logger.Info("网络设置已更新",
+		slog.Int("webMirrors", len(network.WebMirrors)),
+		slog.Int("apiMirrors", len(network.APIMirrors)),
+		slog.String("proxyMode", network.ProxyMode))
+	return network, nil
+}
+
+// validateNewAPIMirrors 使用新的代理设置验证新增的 API 镜像
+func (c *ConfigService) validateNewAPIMirrors(network NetworkSettings) error {
+	current := c.networkSettings()
+	client := &http.Client{Transport: newTransport(network), Timeout: httpTimeout}
+	for _, mirror := range network.APIMirrors {
+		if slices.Contains(current.APIMirrors, mirror) {
+			continue
+		}
+		if result := validateAPIMirror(client, mirror); !result.Valid {
+			return fmt.Errorf("%w: %s: %s", ErrInvalidMirrorURL, mirror, result.Message)
+		}
+	}
+	return nil
+}
+
+// ValidateMirror 使用当前代理设置验证镜像站点是否可用
+// kind 为 "api" 时要求返回兼容 GitHub 的 Release JSON，为 "web" 时要求能访问本项目的 Release 页面
+func (c *ConfigService) ValidateMirror(kind, mirror string) MirrorValidation {
+	mirror = strings.TrimRight(strings.TrimSpace(mirror), "/")
+	client := c.httpClient()
+
+	if kind == mirrorKindAPI {
+		return validateAPIMirror(client, mirror)
+	}
+	return validateWebMirror(client, mirror)
+}
+
+// validateAPIMirror 请求镜像的 latest release 接口，检查返回内容是否为 GitHub Release JSON
+func validateAPIMirror(client *http.Client, mirror string) MirrorValidation {
+	result := MirrorValidation{URL: mirror, Kind: mirrorKindAPI}
+
+	endpoint := fmt.Sprintf("%s/repos/%s/%s/releases/latest", mirror, repoOwner, repoName)
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		result.Message = fmt.Sprintf("请求失败: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		result.Message = fmt.Sprintf("返回状态码 %d", resp.StatusCode)
+		return result
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSizeBytes))
+	if err != nil {
+		result.Message = fmt.Sprintf("读取响应失败: %v", err)
+		return result
+	}
+
+	var release gitHubRelease
+	if err := json.Unmarshal(body, &release); err != nil {
+		result.Message = "返回内容不是有效的 JSON"
+		return result
+	}
+	if release.TagName == "" || release.HTMLURL == "" {
+		result.Message = "返回内容缺少 tag_name 或 html_url，不是 GitHub Release 格式"
+		return result
+	}
+
+	result.Valid = true
+	result.Version = strings.TrimPrefix(release.TagName, "v")
+	result.Message = "镜像可用"
+	return result
+}
+
+// validateWebMirror 检查镜像能否访问本项目的 Release 页面
+func validateWebMirror(client *http.Client, mirror string) MirrorValidation {
+	result := MirrorValidation{URL: mirror, Kind: mirrorKindWeb}
+
+	resp, err := client.Head(fmt.Sprintf("%s/%s/%s/releases", mirror, repoOwner, repoName))
+	if err != nil {
+		result.Message = fmt.Sprintf("请求失败: %v", err)
+		return result
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		result.Message = fmt.Sprintf("返回状态码 %d", resp.StatusCode)
+		return result
+	}
+
+	result.Valid = true
+	result.Message = "镜像可用"
+	return result
+}
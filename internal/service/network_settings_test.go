@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// TestNetworkSettingsValidate 测试网络设置校验与规范化
+func TestNetworkSettingsValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings NetworkSettings
+		errType  error
+	}{
+		{
+			name: "有效的手动 SOCKS5 代理",
+			settings: NetworkSettings{
+				WebMirrors: []string{"https://github.com/"},
+				APIMirrors: []string{"https://api.github.com"},
+				ProxyMode:  ProxyModeManual,
+				ProxyURL:   "socks5://127.0.0.1:1080",
+			},
+		},
+		{
+			name: "镜像列表为空",
+			settings: NetworkSettings{
+				WebMirrors: []string{"  "},
+				APIMirrors: []string{"https://api.github.com"},
+				ProxyMode:  ProxyModeSystem,
+			},
+			errType: ErrInvalidMirrorURL,
+		},
+		{
+			name: "镜像地址协议无效",
+			settings: NetworkSettings{
+				WebMirrors: []string{"ftp://mirror.example"},
+				APIMirrors: []string{"https://api.github.com"},
+				ProxyMode:  ProxyModeSystem,
+			},
+			errType: ErrInvalidMirrorURL,
+		},
+		{
+			name: "代理协议不支持",
+			settings: NetworkSettings{
+				WebMirrors: []string{"https://github.com"},
+				APIMirrors: []string{"https://api.github.com"},
+				ProxyMode:  ProxyModeManual,
+				ProxyURL:   "ftp://127.0.0.1:21",
+			},
+			errType: ErrInvalidProxy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.settings.validate()
+			if tt.errType == nil {
+				if err != nil {
+					t.Errorf("不期望错误: %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.errType) {
+				t.Errorf("期望错误 %v，实际 %v", tt.errType, err)
+			}
+		})
+	}
+
+	normalized, err := NetworkSettings{
+		WebMirrors: []string{"https://github.com/", "https://github.com", " https://2git.xyz "},
+		APIMirrors: []string{"https://api.github.com"},
+		ProxyMode:  ProxyModeNone,
+	}.validate()
+	if err != nil {
+		t.Fatalf("不期望错误: %v", err)
+	}
+	if len(normalized.WebMirrors) != 2 || normalized.WebMirrors[1] != "https://2git.xyz" {
+		t.Errorf("镜像列表未正确去重和规范化: %v", normalized.WebMirrors)
+	}
+}
+
+// TestUpdateNetworkSettingsValidatesAPIMirror 测试新增 API 镜像必须返回 GitHub Release JSON
+func TestUpdateNetworkSettingsValidatesAPIMirror(t *testing.T) {
+	releasePath := fmt.Sprintf("/repos/%s/%s/releases/latest", repoOwner, repoName)
+
+	compatible := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != releasePath {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"tag_name":"v2.1.0","html_url":"https://github.com/XgzK/intellijapp/releases/tag/v2.1.0","assets":[]}`)
+	}))
+	defer compatible.Close()
+
+	incompatible := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html>not json</html>`)
+	}))
+	defer incompatible.Close()
+
+	settingsPath := filepath.Join(t.TempDir(), settingsFileName)
+	c := NewConfigService(WithSettingsPath(settingsPath))
+
+	network := c.GetNetworkSettings()
+	network.ProxyMode = ProxyModeNone
+	network.APIMirrors = append(network.APIMirrors, incompatible.URL)
+	if _, err := c.UpdateNetworkSettings(network); !errors.Is(err, ErrInvalidMirrorURL) {
+		t.Fatalf("不兼容的 API 镜像应被拒绝，实际: %v", err)
+	}
+
+	network.APIMirrors = []string{compatible.URL, "https://api.github.com"}
+	if _, err := c.UpdateNetworkSettings(network); err != nil {
+		t.Fatalf("兼容的 API 镜像应被接受: %v", err)
+	}
+
+	// 重新加载后设置应保持一致
+	reloaded := NewConfigService(WithSettingsPath(settingsPath)).GetNetworkSettings()
+	if len(reloaded.APIMirrors) != 2 || reloaded.APIMirrors[0] != compatible.URL {
+		t.Errorf("API 镜像未正确保存: %v", reloaded.APIMirrors)
+	}
+	if reloaded.ProxyMode != ProxyModeNone {
+		t.Errorf("代理模式未正确保存: %s", reloaded.ProxyMode)
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed catalog/options.json
+var embeddedOptionCatalog []byte
+
+// 参数的风险等级
+const (
+	OptionRiskLow    = "low"
+	OptionRiskMedium = "medium"
+	OptionRiskHigh   = "high"
+)
+
+// 参数值的类型，flag 表示不带值的参数，如 -ea
+const (
+	optionTypeSize   = "size"
+	optionTypeInt    = "int"
+	optionTypeBool   = "bool"
+	optionTypeString = "string"
+	optionTypeFlag   = "flag"
+)
+
+var optionTypes = []string{optionTypeSize, optionTypeInt, optionTypeBool, optionTypeString, optionTypeFlag}
+
+// OptionInfo 描述参数知识库中的一个 JVM 参数
+type OptionInfo struct {
+	// Key 与 jvmOptionKey 的结果一致，如 -Xmx、-XX:UseG1GC、-Dfile.encoding
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Default     string `json:"default,omitempty"`
+	Min         string `json:"min,omitempty"`
+	Max         string `json:"max,omitempty"`
+	Recommended string `json:"recommended,omitempty"`
+	Risk        string `json:"risk"`
+	Docs        string `json:"docs"`
+	// DeprecatedIn 和 RemovedIn 为弃用和移除该参数的 JDK 主版本号，0 表示未弃用或未移除
+	DeprecatedIn int    `json:"deprecatedIn,omitempty"`
+	RemovedIn    int    `json:"removedIn,omitempty"`
+	Replacement  string `json:"replacement,omitempty"`
+}
+
+// OptionCatalog 是内置的参数知识库
+type OptionCatalog struct {
+	Version int          `json:"version"`
+	Options []OptionInfo `json:"options"`
+}
+
+// OptionDescription 是 DescribeOption 的结果
+type OptionDescription struct {
+	Option string `json:"option"`
+	Key    string `json:"key"`
+	// Value 为参数中的取值，布尔参数为 true 或 false
+	Value          string      `json:"value"`
+	Known          bool        `json:"known"`
+	Info           *OptionInfo `json:"info,omitempty"`
+	JavaMajor      int         `json:"javaMajor,omitempty"`
+	Deprecated     bool        `json:"deprecated"`
+	Removed        bool        `json:"removed"`
+	Warnings       []string    `json:"warnings"`
+	CatalogVersion int         `json:"catalogVersion"`
+}
+
+// optionCatalog 首次使用时解析内置知识库
+var optionCatalog = sync.OnceValues(func() (*OptionCatalog, error) {
+	return parseOptionCatalog(embeddedOptionCatalog)
+})
+
+// parseOptionCatalog 解析参数知识库 JSON 并检查每个条目
+func parseOptionCatalog(data []byte) (*OptionCatalog, error) {
+	var catalog OptionCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	seen := make(map[string]bool)
+	for i, opt := range catalog.Options {
+		switch {
+		case opt.Key == "" || opt.Name == "" || jvmOptionKey(opt.Key) != opt.Key:
+			return nil, fmt.Errorf("%w: 第 %d 个参数的 key 无效", ErrInvalidCatalog, i+1)
+		case seen[opt.Key]:
+			return nil, fmt.Errorf("%w: 参数 %s 重复", ErrInvalidCatalog, opt.Key)
+		case !slices.Contains(optionTypes, opt.Type):
+			return nil, fmt.Errorf("%w: 参数 %s 的类型无效", ErrInvalidCatalog, opt.Key)
+		case opt.Risk != OptionRiskLow && opt.Risk != OptionRiskMedium && opt.Risk != OptionRiskHigh:
+			return nil, fmt.Errorf("%w: 参数 %s 的风险等级无效", ErrInvalidCatalog, opt.Key)
+		}
+		seen[opt.Key] = true
+	}
+	return &catalog, nil
+}
+
+// lookupOption 按参数的比较键查找知识库条目
+func (c *OptionCatalog) lookupOption(key string) (*OptionInfo, bool) {
+	for i := range c.Options {
+		if c.Options[i].Key == key {
+			return &c.Options[i], true
+		}
+	}
+	return nil, false
+}
+
+// parseJVMSize 解析 512m、2g 这类内存大小，返回字节数
+func parseJVMSize(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	multiplier := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		multiplier = 1 << 10
+	case 'm', 'M':
+		multiplier = 1 << 20
+	case 'g', 'G':
+		multiplier = 1 << 30
+	case 't', 'T':
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// optionValue 取出参数中的值：-Xmx2g 为 2g，-XX:+Foo 为 true，-Dkey=value 为 value
+func optionValue(option, key string) string {
+	if strings.HasPrefix(option, "-XX:") {
+		body := strings.TrimPrefix(option, "-XX:")
+		switch {
+		case strings.HasPrefix(body, "+"):
+			return "true"
+		case strings.HasPrefix(body, "-"):
+			return "false"
+		}
+		_, value, _ := strings.Cut(body, "=")
+		return value
+	}
+	if strings.HasPrefix(option, "-D") {
+		_, value, _ := strings.Cut(option, "=")
+		return value
+	}
+	return strings.TrimPrefix(option, key)
+}
+
+// optionNumber 将 size 和 int 类型的取值解析为数值，size 类型为字节数
+func optionNumber(typ, value string) (int64, bool) {
+	switch typ {
+	case optionTypeSize:
+		return parseJVMSize(value)
+	case optionTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// describeOption 用知识库解释参数，javaMajor 为 0 时不判断参数在当前运行时是否已移除
+func describeOption(catalog *OptionCatalog, option string, javaMajor int) OptionDescription {
+	option = strings.Trim(strings.TrimSpace(option), `"`)
+	key := jvmOptionKey(option)
+	desc := OptionDescription{
+		Option: option, Key: key, Warnings: []string{}, JavaMajor: javaMajor, CatalogVersion: catalog.Version,
+	}
+	info, ok := catalog.lookupOption(key)
+	if !ok {
+		return desc
+	}
+	desc.Known = true
+	desc.Info = info
+	desc.Value = optionValue(option, key)
+
+	if info.DeprecatedIn > 0 && (javaMajor == 0 || javaMajor >= info.DeprecatedIn) {
+		desc.Deprecated = true
+		desc.Warnings = append(desc.Warnings, fmt.Sprintf("自 JDK %d 起已弃用", info.DeprecatedIn))
+	}
+	if info.RemovedIn > 0 && javaMajor >= info.RemovedIn {
+		desc.Removed = true
+		desc.Warnings = append(desc.Warnings, fmt.Sprintf("JDK %d 起已移除，当前运行时为 JDK %d", info.RemovedIn, javaMajor))
+	}
+	if (desc.Deprecated || desc.Removed) && info.Replacement != "" {
+		desc.Warnings = append(desc.Warnings, "建议改用 "+info.Replacement)
+	}
+
+	if info.Type == optionTypeSize || info.Type == optionTypeInt {
+		value, ok := optionNumber(info.Type, desc.Value)
+		if !ok {
+			desc.Warnings = append(desc.Warnings, fmt.Sprintf("参数值 %q 无效", desc.Value))
+		}
+		if minValue, minOK := optionNumber(info.Type, info.Min); ok && minOK && value < minValue {
+			desc.Warnings = append(desc.Warnings, fmt.Sprintf("参数值小于建议的最小值 %s", info.Min))
+		}
+		if maxValue, maxOK := optionNumber(info.Type, info.Max); ok && maxOK && value > maxValue {
+			desc.Warnings = append(desc.Warnings, fmt.Sprintf("参数值大于允许的最大值 %s", info.Max))
+		}
+	}
+	if info.Risk == OptionRiskHigh && !desc.Deprecated {
+		desc.Warnings = append(desc.Warnings, "高风险参数，修改前请确认用途")
+	}
+	return desc
+}
+
+// ideJavaMajor 返回 IDE 使用的 Java 运行时的主版本号，无法确定时返回 0
+func ideJavaMajor(home string) int {
+	java, err := ideJavaExecutable(home)
+	if err != nil {
+		return 0
+	}
+	rt, err := readJavaRuntime(filepath.Dir(filepath.Dir(java)))
+	if err != nil {
+		return 0
+	}
+	return rt.Major
+}
+
+// GetOptionCatalog 返回内置的参数知识库
+func (c *ConfigService) GetOptionCatalog() (OptionCatalog, error) {
+	catalog, err := optionCatalog()
+	if err != nil {
+		return OptionCatalog{}, err
+	}
+	return *catalog, nil
+}
+
+// DescribeOption 返回参数的说明、取值范围和风险提示
+// installPath 可为空；指定安装时按其使用的 Java 运行时判断参数是否已移除
+func (c *ConfigService) DescribeOption(option, installPath string) (OptionDescription, error) {
+	if !strings.HasPrefix(strings.Trim(strings.TrimSpace(option), `"`), "-") {
+		return OptionDescription{}, fmt.Errorf("%w: %q", ErrInvalidOption, option)
+	}
+	catalog, err := optionCatalog()
+	if err != nil {
+		return OptionDescription{}, err
+	}
+	javaMajor := 0
+	if home := installationHome(installPath); home != "" {
+		javaMajor = ideJavaMajor(home)
+	}
+	return describeOption(catalog, option, javaMajor), nil
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOptionCatalogEmbedded 测试内置参数知识库可以解析
+func TestOptionCatalogEmbedded(t *testing.T) {
+	catalog, err := optionCatalog()
+	if err != nil {
+		t.Fatalf("解析内置知识库失败: %v", err)
+	}
+	if catalog.Version < 1 || len(catalog.Options) == 0 {
+		t.Fatalf("内置知识库为空: %+v", catalog)
+	}
+	for _, opt := range catalog.Options {
+		if opt.Description == "" || !strings.HasPrefix(opt.Docs, "https://") {
+			t.Errorf("%s 缺少说明或文档链接", opt.Key)
+		}
+	}
+
+	_, err = parseOptionCatalog([]byte(`{"version":1,"options":[{"key":"-XX:+UseG1GC","name":"G1","type":"bool","risk":"low"}]}`))
+	if !errors.Is(err, ErrInvalidCatalog) {
+		t.Errorf("未规范化的 key 应报错，得到 %v", err)
+	}
+}
+
+// TestParseJVMSize 测试内存大小解析
+func TestParseJVMSize(t *testing.T) {
+	tests := map[string]int64{"512": 512, "256k": 256 << 10, "2048m": 2048 << 20, "2G": 2 << 30}
+	for value, want := range tests {
+		if got, ok := parseJVMSize(value); !ok || got != want {
+			t.Errorf("parseJVMSize(%q) = %d, %v，期望 %d", value, got, ok, want)
+		}
+	}
+	for _, value := range []string{"", "m", "2gb", "-1g"} {
+		if _, ok := parseJVMSize(value); ok {
+			t.Errorf("parseJVMSize(%q) 应失败", value)
+		}
+	}
+}
+
+// TestDescribeOption 测试参数说明和警告
+func TestDescribeOption(t *testing.T) {
+	catalog, err := optionCatalog()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := describeOption(catalog, "-XX:SoftRefLRUPolicyMSPerMB=50", 21)
+	if !desc.Known || desc.Key != "-XX:SoftRefLRUPolicyMSPerMB" || desc.Value != "50" || len(desc.Warnings) != 0 {
+		t.Errorf("说明不正确: %+v", desc)
+	}
+
+	desc = describeOption(catalog, "-XX:+UseConcMarkSweepGC", 21)
+	if !desc.Deprecated || !desc.Removed || desc.Value != "true" {
+		t.Errorf("CMS 在 JDK 21 中应已移除: %+v", desc)
+	}
+	if !strings.Contains(strings.Join(desc.Warnings, "；"), "-XX:+UseG1GC") {
+		t.Errorf("应提示替代参数: %v", desc.Warnings)
+	}
+	if desc = describeOption(catalog, "-XX:+UseConcMarkSweepGC", 0); !desc.Deprecated || desc.Removed {
+		t.Errorf("未知运行时只提示弃用: %+v", desc)
+	}
+	if desc = describeOption(catalog, "-XX:MaxPermSize=512m", 17); !desc.Removed {
+		t.Errorf("MaxPermSize 在 JDK 17 中应已移除: %+v", desc)
+	}
+	desc = describeOption(catalog, "-XX:+UseParallelOldGC", 15)
+	if !desc.Known || !desc.Removed || !strings.Contains(strings.Join(desc.Warnings, "；"), "-XX:+UseParallelGC") {
+		t.Errorf("UseParallelOldGC 在 JDK 15 中应已移除并提示替代参数: %+v", desc)
+	}
+
+	tests := map[string]string{
+		"-Xmx256m":                         "最小值",
+		"-XX:ReservedCodeCacheSize=4g":     "最大值",
+		"-Xmx2gb":                          "无效",
+		"-XX:+IgnoreUnrecognizedVMOptions": "高风险",
+	}
+	for option, warning := range tests {
+		desc := describeOption(catalog, option, 21)
+		joined := strings.Join(desc.Warnings, "；")
+		if warning != "" && !strings.Contains(joined, warning) {
+			t.Errorf("%s: 期望警告包含 %q，实际 %v", option, warning, desc.Warnings)
+		}
+	}
+
+	if desc := describeOption(catalog, "-Dsome.unknown=1", 21); desc.Known || desc.Info != nil {
+		t.Errorf("未收录的参数应返回 Known=false: %+v", desc)
+	}
+}
+
+// TestDescribeOptionBinding 测试 DescribeOption 按安装的运行时判断参数是否已移除
+func TestDescribeOptionBinding(t *testing.T) {
+	base := t.TempDir()
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.1", "-Xmx2g\n")
+	createTestRuntime(t, filepath.Join(home, "jbr"), "17.0.9", "JetBrains s.r.o.")
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	desc, err := svc.DescribeOption("-XX:+UseConcMarkSweepGC", filepath.Join(home, "bin"))
+	if err != nil {
+		t.Fatalf("DescribeOption 失败: %v", err)
+	}
+	if desc.JavaMajor != 17 || !desc.Removed {
+		t.Errorf("应按 JDK 17 判断: %+v", desc)
+	}
+
+	if _, err := svc.DescribeOption("UseG1GC", ""); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("缺少 - 前缀应报错，得到 %v", err)
+	}
+}
@@ -79,6 +79,10 @@ func validateIntelliJPath(softwarePath string) (string, error) {
 	}
 
 	if !hasVMOptions {
+		// 能根据启动器识别出产品时，说明是 IDE 安装目录但 vmoptions 文件缺失
+		if product, ok := productForBinDir(candidateBin); ok {
+			return "", fmt.Errorf("%w: %s 的 bin 目录", ErrNoVMOptions, product.Name)
+		}
 		return "", ErrNotIntelliJDir
 	}
 
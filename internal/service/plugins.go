@@ -0,0 +1,346 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// disabledPluginsFileName 配置目录中记录已禁用插件 ID 的文件，每行一个
+const disabledPluginsFileName = "disabled_plugins.txt"
+
+// pluginDescriptorPath 插件描述文件在 jar 或插件目录中的位置
+const pluginDescriptorPath = "META-INF/plugin.xml"
+
+// 插件来源
+const (
+	PluginSourceBundled = "bundled"
+	PluginSourceUser    = "user"
+)
+
+// pluginDescriptor 对应 plugin.xml 中用到的字段
+type pluginDescriptor struct {
+	ID      string `xml:"id"`
+	Name    string `xml:"name"`
+	Version string `xml:"version"`
+	Vendor  string `xml:"vendor"`
+	Compat  struct {
+		SinceBuild string `xml:"since-build,attr"`
+		UntilBuild string `xml:"until-build,attr"`
+	} `xml:"idea-version"`
+}
+
+// PluginInfo 描述一个自带或用户安装的插件
+type PluginInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Vendor     string `json:"vendor"`
+	Source     string `json:"source"`
+	Path       string `json:"path"`
+	Enabled    bool   `json:"enabled"`
+	SinceBuild string `json:"sinceBuild,omitempty"`
+	UntilBuild string `json:"untilBuild,omitempty"`
+	// Compatible 表示插件声明的 since/until-build 范围包含当前 IDE 的构建号
+	Compatible bool   `json:"compatible"`
+	Error      string `json:"error,omitempty"`
+}
+
+// PluginList 汇总一个 IDE 的插件和禁用列表
+type PluginList struct {
+	ConfigDir    string       `json:"configDir"`
+	PluginsDir   string       `json:"pluginsDir"`
+	DisabledFile string       `json:"disabledFile"`
+	BuildNumber  string       `json:"buildNumber"`
+	Plugins      []PluginInfo `json:"plugins"`
+	// UnknownDisabled 为禁用列表中找不到对应插件的 ID，通常是已卸载的插件
+	UnknownDisabled []string `json:"unknownDisabled"`
+}
+
+// parsePluginDescriptor 解析 plugin.xml，缺少 id 时与 IDE 一致使用 name 作为 ID
+func parsePluginDescriptor(data []byte) (pluginDescriptor, error) {
+	var desc pluginDescriptor
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// 部分插件的 plugin.xml 声明了 Go 不支持的编码，内容实际为 UTF-8
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := decoder.Decode(&desc); err != nil {
+		return desc, fmt.Errorf("%w: %v", ErrInvalidPlugin, err)
+	}
+	desc.ID = strings.TrimSpace(desc.ID)
+	desc.Name = strings.TrimSpace(desc.Name)
+	if desc.ID == "" {
+		desc.ID = desc.Name
+	}
+	if desc.ID == "" {
+		return desc, fmt.Errorf("%w: plugin.xml 缺少 id 和 name", ErrInvalidPlugin)
+	}
+	return desc, nil
+}
+
+// readJarDescriptor 从 jar 中读取 META-INF/plugin.xml，jar 中没有描述文件时返回 fs.ErrNotExist
+func readJarDescriptor(jarPath string) ([]byte, error) {
+	reader, err := zip.OpenReader(jarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	file, err := reader.Open(pluginDescriptorPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// readPluginDescriptor 读取插件的 plugin.xml：插件可以是单个 jar，或包含 lib/*.jar 的目录
+func readPluginDescriptor(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readJarDescriptor(path)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(path, filepath.FromSlash(pluginDescriptorPath))); err == nil {
+		return data, nil
+	}
+	jars, _ := filepath.Glob(filepath.Join(globEscape(filepath.Join(path, "lib")), "*.jar"))
+	// 插件的主 jar 通常与插件目录同名，优先检查
+	mainJar := filepath.Join(path, "lib", filepath.Base(path)+".jar")
+	slices.SortStableFunc(jars, func(a, b string) int {
+		switch {
+		case a == mainJar:
+			return -1
+		case b == mainJar:
+			return 1
+		}
+		return 0
+	})
+	for _, jar := range jars {
+		data, err := readJarDescriptor(jar)
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("读取 %s 失败: %w", jar, err)
+		}
+	}
+	return nil, fmt.Errorf("%w: %s 中没有 %s", ErrInvalidPlugin, path, pluginDescriptorPath)
+}
+
+// pluginCompatible 判断构建号是否在插件声明的兼容范围内，until-build 中的 * 匹配任意数字
+func pluginCompatible(build, since, until string) bool {
+	if build == "" {
+		return true
+	}
+	if since != "" && compareVersions(build, since) < 0 {
+		return false
+	}
+	if until != "" && compareVersions(build, strings.ReplaceAll(until, "*", "999999")) > 0 {
+		return false
+	}
+	return true
+}
+
+// scanPlugins 列出目录中的插件，无法解析描述文件的插件也会返回并附带错误信息
+func scanPlugins(dir, source, build string) []PluginInfo {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var plugins []PluginInfo
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && !strings.EqualFold(filepath.Ext(entry.Name()), ".jar") {
+			continue
+		}
+		plugin := PluginInfo{Name: entry.Name(), Source: source, Path: path, Compatible: true}
+		data, err := readPluginDescriptor(path)
+		if err == nil {
+			var desc pluginDescriptor
+			if desc, err = parsePluginDescriptor(data); err == nil {
+				plugin.ID, plugin.Name, plugin.Version = desc.ID, desc.Name, strings.TrimSpace(desc.Version)
+				plugin.Vendor = strings.TrimSpace(desc.Vendor)
+				plugin.SinceBuild, plugin.UntilBuild = desc.Compat.SinceBuild, desc.Compat.UntilBuild
+				plugin.Compatible = pluginCompatible(build, plugin.SinceBuild, plugin.UntilBuild)
+				if plugin.Name == "" {
+					plugin.Name = plugin.ID
+				}
+			}
+		}
+		if err != nil {
+			plugin.Error = err.Error()
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+// readDisabledPlugins 读取禁用插件列表，文件不存在时返回空列表
+func readDisabledPlugins(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("读取 %s 失败: %w", path, err)
+	}
+	ids := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" && !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// pluginDirs 返回安装的配置目录和用户插件目录，已迁移或自定义的目录按 idea.properties 解析
+func pluginDirs(home string) (string, string, error) {
+	dirs, err := ideDirLocations(home)
+	if err != nil {
+		return "", "", err
+	}
+	var configDir, pluginsDir string
+	for _, dir := range dirs {
+		switch dir.Kind {
+		case IDEDirConfig:
+			configDir = dir.Path
+		case IDEDirPlugins:
+			pluginsDir = dir.Path
+		}
+	}
+	return configDir, pluginsDir, nil
+}
+
+// listPlugins 列出自带插件和用户安装的插件，并按禁用列表标记启用状态
+func listPlugins(home string) (PluginList, error) {
+	configDir, pluginsDir, err := pluginDirs(home)
+	if err != nil {
+		return PluginList{}, err
+	}
+	list := PluginList{
+		ConfigDir:       configDir,
+		PluginsDir:      pluginsDir,
+		DisabledFile:    filepath.Join(configDir, disabledPluginsFileName),
+		Plugins:         []PluginInfo{},
+		UnknownDisabled: []string{},
+	}
+	if info, err := readProductInfo(home); err == nil {
+		list.BuildNumber = info.BuildNumber
+	}
+
+	disabled, err := readDisabledPlugins(list.DisabledFile)
+	if err != nil {
+		return list, err
+	}
+
+	list.Plugins = append(list.Plugins, scanPlugins(filepath.Join(home, "plugins"), PluginSourceBundled, list.BuildNumber)...)
+	list.Plugins = append(list.Plugins, scanPlugins(pluginsDir, PluginSourceUser, list.BuildNumber)...)
+	known := make(map[string]bool)
+	for i := range list.Plugins {
+		plugin := &list.Plugins[i]
+		plugin.Enabled = plugin.ID == "" || !slices.Contains(disabled, plugin.ID)
+		known[plugin.ID] = true
+	}
+	for _, id := range disabled {
+		if !known[id] {
+			list.UnknownDisabled = append(list.UnknownDisabled, id)
+		}
+	}
+	return list, nil
+}
+
+// ListPlugins 列出 IDE 自带和用户安装的插件及其启用状态
+func (c *ConfigService) ListPlugins(installPath string) (PluginList, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return PluginList{}, ErrEmptyPath
+	}
+	list, err := listPlugins(home)
+	if err != nil {
+		c.logger.Error("读取插件列表失败", slog.String("home", home), slog.Any("error", err))
+		return list, err
+	}
+	return list, nil
+}
+
+// SetPluginsEnabled 通过修改 disabled_plugins.txt 启用或禁用插件，无需启动 IDE
+// 修改前备份原文件并以原子方式写入；IDE 运行时退出会覆盖该文件，因此要求先关闭 IDE
+func (c *ConfigService) SetPluginsEnabled(installPath string, ids []string, enabled bool) (PluginList, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return PluginList{}, ErrEmptyPath
+	}
+	if len(ids) == 0 {
+		return PluginList{}, fmt.Errorf("%w: 未指定插件", ErrInvalidPlugin)
+	}
+	if err := c.ensureNotRunning(home); err != nil {
+		return PluginList{}, err
+	}
+
+	configDir, _, err := pluginDirs(home)
+	if err != nil {
+		return PluginList{}, err
+	}
+	path := filepath.Join(configDir, disabledPluginsFileName)
+	disabled, err := readDisabledPlugins(path)
+	if err != nil {
+		return PluginList{}, err
+	}
+
+	changed := 0
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		index := slices.Index(disabled, id)
+		switch {
+		case enabled && index >= 0:
+			disabled = slices.Delete(disabled, index, index+1)
+			changed++
+		case !enabled && index < 0:
+			disabled = append(disabled, id)
+			changed++
+		}
+	}
+
+	if changed > 0 {
+		if fileExists(path) {
+			if err := checkFileWritePermission(path); err != nil {
+				return PluginList{}, err
+			}
+		} else if err := os.MkdirAll(configDir, 0755); err != nil {
+			return PluginList{}, fmt.Errorf("创建配置目录失败: %w", err)
+		}
+
+		var content strings.Builder
+		for _, id := range disabled {
+			content.WriteString(id + "\n")
+		}
+		backupPath, err := writeFileWithBackup(path, []byte(content.String()), 0644)
+		if err != nil {
+			c.logger.Error("写入 disabled_plugins.txt 失败", slog.String("file", path), slog.Any("error", err))
+			return PluginList{}, err
+		}
+		c.logger.Info("插件启用状态已更新",
+			slog.String("file", path),
+			slog.String("backup", backupPath),
+			slog.Bool("enabled", enabled),
+			slog.Int("changed", changed))
+	}
+	return c.ListPlugins(installPath)
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createTestPluginJar 写入包含 META-INF/plugin.xml 的 jar
+func createTestPluginJar(t *testing.T, path, pluginXML string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建 jar 失败: %v", err)
+	}
+	defer file.Close()
+	writer := zip.NewWriter(file)
+	if _, err := writer.Create("META-INF/"); err != nil {
+		t.Fatal(err)
+	}
+	entry, err := writer.Create(pluginDescriptorPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write([]byte(pluginXML)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPluginCompatible 测试插件兼容范围判断
+func TestPluginCompatible(t *testing.T) {
+	tests := []struct {
+		build, since, until string
+		want                bool
+	}{
+		{"241.15989.150", "233", "", true},
+		{"241.15989.150", "233", "241.*", true},
+		{"242.20224.300", "233", "241.*", false},
+		{"232.10300.40", "233.11799", "", false},
+		{"", "233", "241.*", true},
+	}
+	for _, tt := range tests {
+		if got := pluginCompatible(tt.build, tt.since, tt.until); got != tt.want {
+			t.Errorf("pluginCompatible(%q, %q, %q) = %v", tt.build, tt.since, tt.until, got)
+		}
+	}
+}
+
+// TestListAndTogglePlugins 测试插件列表和通过 disabled_plugins.txt 启用禁用插件
+func TestListAndTogglePlugins(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(base, "data"))
+	t.Setenv("APPDATA", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "idea")
+	createTestInstallation(t, home, "IU", "241.15989.150", "-Xmx2g\n")
+	createTestPluginJar(t, filepath.Join(home, "plugins", "Git4Idea", "lib", "Git4Idea.jar"),
+		`<?xml version="1.0" encoding="UTF-8"?><idea-plugin><id>Git4Idea</id><name>Git</name><vendor>JetBrains</vendor></idea-plugin>`)
+	if err := os.MkdirAll(filepath.Join(home, "plugins", "broken", "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	list, err := svc.ListPlugins(home)
+	if err != nil {
+		t.Fatalf("读取插件列表失败: %v", err)
+	}
+	createTestPluginJar(t, filepath.Join(list.PluginsDir, "old-theme.jar"),
+		`<idea-plugin><name>Old Theme</name><version>1.0</version><idea-version since-build="222" until-build="223.*"/></idea-plugin>`)
+	if err := os.MkdirAll(list.ConfigDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(list.DisabledFile, []byte("removed.plugin\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err = svc.ListPlugins(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugins := make(map[string]PluginInfo)
+	for _, p := range list.Plugins {
+		plugins[p.Path] = p
+	}
+	git := plugins[filepath.Join(home, "plugins", "Git4Idea")]
+	if git.ID != "Git4Idea" || git.Name != "Git" || git.Source != PluginSourceBundled || !git.Enabled || !git.Compatible {
+		t.Errorf("自带插件不正确: %+v", git)
+	}
+	if broken := plugins[filepath.Join(home, "plugins", "broken")]; broken.Error == "" {
+		t.Errorf("缺少 plugin.xml 的插件应返回错误: %+v", broken)
+	}
+	theme := plugins[filepath.Join(list.PluginsDir, "old-theme.jar")]
+	if theme.ID != "Old Theme" || theme.Source != PluginSourceUser || theme.Compatible {
+		t.Errorf("用户插件不正确: %+v", theme)
+	}
+	if len(list.UnknownDisabled) != 1 || list.UnknownDisabled[0] != "removed.plugin" {
+		t.Errorf("未知的禁用 ID 不正确: %v", list.UnknownDisabled)
+	}
+
+	list, err = svc.SetPluginsEnabled(home, []string{"Old Theme", "Git4Idea"}, false)
+	if err != nil {
+		t.Fatalf("禁用插件失败: %v", err)
+	}
+	content, _ := os.ReadFile(list.DisabledFile)
+	if string(content) != "removed.plugin\nOld Theme\nGit4Idea\n" {
+		t.Errorf("disabled_plugins.txt 内容不正确:\n%s", content)
+	}
+	if backups := listBackups(list.DisabledFile); len(backups) != 1 {
+		t.Errorf("修改前应备份原文件: %v", backups)
+	}
+
+	list, err = svc.SetPluginsEnabled(home, []string{"Git4Idea", "removed.plugin"}, true)
+	if err != nil {
+		t.Fatalf("启用插件失败: %v", err)
+	}
+	content, _ = os.ReadFile(list.DisabledFile)
+	if strings.TrimSpace(string(content)) != "Old Theme" {
+		t.Errorf("启用后 disabled_plugins.txt 内容不正确:\n%s", content)
+	}
+	for _, p := range list.Plugins {
+		if p.ID == "Git4Idea" && !p.Enabled {
+			t.Error("Git4Idea 应已启用")
+		}
+	}
+}
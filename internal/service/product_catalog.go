@@ -0,0 +1,191 @@
+package service
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// 用户自定义产品目录文件名，位于设置文件所在目录
+const userCatalogFileName = "products.json"
+
+//go:embed catalog/products.json
+var embeddedCatalog []byte
+
+// ProductDefinition 描述一个 JetBrains 产品的安装和配置约定
+type ProductDefinition struct {
+	Code            string   `json:"code"`
+	Name            string   `json:"name"`
+	Launchers       []string `json:"launchers"`
+	VMOptionsFiles  []string `json:"vmOptionsFiles"`
+	EnvVars         []string `json:"envVars"`
+	ConfigDirPrefix string   `json:"configDirPrefix"`
+	ToolboxID       string   `json:"toolboxId"`
+	Custom          bool     `json:"custom"`
+}
+
+// productCatalog 保存内置产品和用户自定义产品
+type productCatalog struct {
+	Products []ProductDefinition
+}
+
+var (
+	catalogMu      sync.RWMutex
+	currentCatalog *productCatalog
+)
+
+// parseCatalog 解析产品目录 JSON
+func parseCatalog(data []byte) ([]ProductDefinition, error) {
+	var products []ProductDefinition
+	if err := json.Unmarshal(data, &products); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCatalog, err)
+	}
+	for i, p := range products {
+		products[i].Code = strings.ToUpper(strings.TrimSpace(p.Code))
+		if products[i].Code == "" || strings.TrimSpace(p.Name) == "" {
+			return nil, fmt.Errorf("%w: 第 %d 个产品缺少 code 或 name", ErrInvalidCatalog, i+1)
+		}
+		for j, env := range p.EnvVars {
+			products[i].EnvVars[j] = strings.ToUpper(strings.TrimSpace(env))
+		}
+	}
+	return products, nil
+}
+
+// loadProductCatalog 读取内置目录，并用用户文件中的产品覆盖（按 code 匹配）或追加
+// 用户文件不存在时只使用内置目录
+func loadProductCatalog(userPath string) (*productCatalog, error) {
+	products, err := parseCatalog(embeddedCatalog)
+	if err != nil {
+		return nil, err
+	}
+
+	if userPath == "" {
+		return &productCatalog{Products: products}, nil
+	}
+
+	data, err := os.ReadFile(userPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &productCatalog{Products: products}, nil
+		}
+		return nil, fmt.Errorf("读取产品目录失败: %w", err)
+	}
+
+	custom, err := parseCatalog(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", userPath, err)
+	}
+	for _, p := range custom {
+		p.Custom = true
+		index := slices.IndexFunc(products, func(existing ProductDefinition) bool { return existing.Code == p.Code })
+		if index >= 0 {
+			products[index] = p
+		} else {
+			products = append(products, p)
+		}
+	}
+	return &productCatalog{Products: products}, nil
+}
+
+// userCatalogPath 返回用户自定义产品目录文件的路径
+func userCatalogPath() string {
+	settingsPath, err := settingsFilePath()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(settingsPath), userCatalogFileName)
+}
+
+// products 返回当前产品目录，首次调用时加载；用户文件无效时回退到内置目录
+func products() []ProductDefinition {
+	catalogMu.RLock()
+	catalog := currentCatalog
+	catalogMu.RUnlock()
+	if catalog != nil {
+		return catalog.Products
+	}
+
+	catalog, err := loadProductCatalog(userCatalogPath())
+	if err != nil {
+		slog.Default().Warn("加载自定义产品目录失败，使用内置目录", slog.Any("error", err))
+		catalog, _ = loadProductCatalog("")
+	}
+
+	catalogMu.Lock()
+	currentCatalog = catalog
+	catalogMu.Unlock()
+	return catalog.Products
+}
+
+// productByCode 按产品代码查找产品
+func productByCode(code string) (ProductDefinition, bool) {
+	for _, p := range products() {
+		if strings.EqualFold(p.Code, code) {
+			return p, true
+		}
+	}
+	return ProductDefinition{}, false
+}
+
+// productForBinDir 根据 bin 目录中的 vmoptions 文件和启动器识别产品
+// IntelliJ IDEA 等多个版本共用文件名时返回目录中的第一个匹配
+func productForBinDir(binDir string) (ProductDefinition, bool) {
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return ProductDefinition{}, false
+	}
+
+	for _, p := range products() {
+		for _, entry := range entries {
+			name := strings.ToLower(entry.Name())
+			if slices.ContainsFunc(p.VMOptionsFiles, func(f string) bool { return strings.EqualFold(f, name) }) ||
+				slices.ContainsFunc(p.Launchers, func(f string) bool { return strings.EqualFold(f, name) }) {
+				return p, true
+			}
+		}
+	}
+	return ProductDefinition{}, false
+}
+
+// jetbrainsEnvVarNames 返回产品目录中所有 *_VM_OPTIONS 环境变量名（去重）
+func jetbrainsEnvVarNames() []string {
+	var names []string
+	for _, p := range products() {
+		for _, env := range p.EnvVars {
+			if !slices.Contains(names, env) {
+				names = append(names, env)
+			}
+		}
+	}
+	return names
+}
+
+// GetProductCatalog 返回支持的 JetBrains 产品列表
+func (c *ConfigService) GetProductCatalog() []ProductDefinition {
+	return products()
+}
+
+// ReloadProductCatalog 重新读取用户自定义产品目录（设置目录下的 products.json）
+func (c *ConfigService) ReloadProductCatalog() ([]ProductDefinition, error) {
+	catalog, err := loadProductCatalog(userCatalogPath())
+	if err != nil {
+		c.logger.Error("加载自定义产品目录失败", slog.Any("error", err))
+		return nil, err
+	}
+
+	catalogMu.Lock()
+	currentCatalog = catalog
+	catalogMu.Unlock()
+
+	c.logger.Info("产品目录已重新加载", slog.Int("count", len(catalog.Products)))
+	return catalog.Products, nil
+}
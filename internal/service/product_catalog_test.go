@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestLoadProductCatalog 测试用户自定义产品覆盖内置产品或追加新产品
+func TestLoadProductCatalog(t *testing.T) {
+	builtin, err := loadProductCatalog("")
+	if err != nil {
+		t.Fatalf("加载内置目录失败: %v", err)
+	}
+	goland := slices.IndexFunc(builtin.Products, func(p ProductDefinition) bool { return p.Code == "GO" })
+	if goland < 0 || !slices.Contains(builtin.Products[goland].EnvVars, "GOLAND_VM_OPTIONS") {
+		t.Fatal("内置目录应包含 GoLand")
+	}
+
+	userPath := filepath.Join(t.TempDir(), userCatalogFileName)
+	custom := `[
+		{"code": "fl", "name": "Fleet", "launchers": ["fleet"], "envVars": ["fleet_vm_options"]},
+		{"code": "GO", "name": "GoLand EAP", "envVars": ["GOLAND_VM_OPTIONS"]}
+	]`
+	if err := os.WriteFile(userPath, []byte(custom), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := loadProductCatalog(userPath)
+	if err != nil {
+		t.Fatalf("加载自定义目录失败: %v", err)
+	}
+	if len(catalog.Products) != len(builtin.Products)+1 {
+		t.Errorf("应追加 1 个新产品，实际 %d -> %d", len(builtin.Products), len(catalog.Products))
+	}
+
+	fleet := catalog.Products[len(catalog.Products)-1]
+	if fleet.Code != "FL" || !fleet.Custom || fleet.EnvVars[0] != "FLEET_VM_OPTIONS" {
+		t.Errorf("自定义产品应被规范化: %+v", fleet)
+	}
+	if catalog.Products[goland].Name != "GoLand EAP" {
+		t.Errorf("同 code 的产品应被覆盖，实际 %q", catalog.Products[goland].Name)
+	}
+
+	if err := os.WriteFile(userPath, []byte(`[{"name": "无代码"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadProductCatalog(userPath); !errors.Is(err, ErrInvalidCatalog) {
+		t.Errorf("缺少 code 应返回 ErrInvalidCatalog，实际 %v", err)
+	}
+}
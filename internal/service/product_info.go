@@ -0,0 +1,120 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const productInfoFileName = "product-info.json"
+
+// productInfo 对应 IDE 安装目录中 product-info.json 的常用字段
+type productInfo struct {
+	Name              string              `json:"name"`
+	Version           string              `json:"version"`
+	BuildNumber       string              `json:"buildNumber"`
+	ProductCode       string              `json:"productCode"`
+	DataDirectoryName string              `json:"dataDirectoryName"`
+	Launch            []productInfoLaunch `json:"launch"`
+}
+
+// productInfoLaunch 对应 product-info.json 中各平台的启动配置
+type productInfoLaunch struct {
+	OS                     string   `json:"os"`
+	Arch                   string   `json:"arch"`
+	LauncherPath           string   `json:"launcherPath"`
+	JavaExecutablePath     string   `json:"javaExecutablePath"`
+	VMOptionsFilePath      string   `json:"vmOptionsFilePath"`
+	AdditionalJVMArguments []string `json:"additionalJvmArguments"`
+}
+
+// installationHome 将用户输入的路径（安装目录或 bin 目录）规范化为安装目录
+func installationHome(path string) string {
+	path = sanitizePath(path)
+	if strings.EqualFold(filepath.Base(path), "bin") {
+		return filepath.Dir(path)
+	}
+	return path
+}
+
+// productInfoPath 返回安装目录中 product-info.json 的路径
+// macOS 的安装目录为 *.app/Contents，product-info.json 位于 Resources 子目录
+func productInfoPath(home string) (string, bool) {
+	for _, candidate := range []string{
+		filepath.Join(home, productInfoFileName),
+		filepath.Join(home, "Resources", productInfoFileName),
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// readProductInfo 读取安装目录中的 product-info.json
+func readProductInfo(home string) (*productInfo, error) {
+	path, ok := productInfoPath(home)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProductInfoMissing, home)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 product-info.json 失败: %w", err)
+	}
+
+	var info productInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("解析 product-info.json 失败: %w", err)
+	}
+	return &info, nil
+}
+
+// launchFor 返回指定操作系统的启动配置，product-info.json 中 os 字段为 Linux/Windows/macOS
+func (p *productInfo) launchFor(goos string) (productInfoLaunch, bool) {
+	want := map[string]string{"linux": "linux", "windows": "windows", "darwin": "macos"}[goos]
+	for _, launch := range p.Launch {
+		if strings.EqualFold(launch.OS, want) {
+			return launch, true
+		}
+	}
+	if len(p.Launch) > 0 {
+		return p.Launch[0], true
+	}
+	return productInfoLaunch{}, false
+}
+
+// displayName 返回产品的显示名称，产品目录中有该产品时使用目录中的名称（区分 Ultimate 和 Community 等版本）
+func (p *productInfo) displayName() string {
+	if product, ok := productByCode(p.ProductCode); ok {
+		return product.Name
+	}
+	return p.Name
+}
+
+// ideConfigDir 返回 IDE 的用户配置目录，如 ~/.config/JetBrains/GoLand2024.1
+// 优先使用 product-info.json 中的 dataDirectoryName，旧版本按产品目录的前缀和版本号推断
+func ideConfigDir(home string) (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrConfigDirUnknown, err)
+	}
+
+	info, err := readProductInfo(home)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrConfigDirUnknown, err)
+	}
+
+	name := info.DataDirectoryName
+	if name == "" {
+		product, ok := productByCode(info.ProductCode)
+		parts := strings.SplitN(info.Version, ".", 3)
+		if !ok || product.ConfigDirPrefix == "" || len(parts) < 2 {
+			return "", fmt.Errorf("%w: %s", ErrConfigDirUnknown, home)
+		}
+		name = product.ConfigDirPrefix + parts[0] + "." + parts[1]
+	}
+	return filepath.Join(base, "JetBrains", name), nil
+}
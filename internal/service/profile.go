@@ -0,0 +1,496 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// profileSchemaVersion 当前调优配置文件的格式版本
+const profileSchemaVersion = 1
+
+// 调优配置中的路径占位符，导入时替换为目标机器上的对应目录
+const (
+	ProfileVarConfigDir = "${CONFIG_DIR}"
+	ProfileVarIDEHome   = "${IDE_HOME}"
+	ProfileVarHome      = "${HOME}"
+)
+
+// 导入预览中的修改类型
+const (
+	ProfileChangeVMOption = "vmoption"
+	ProfileChangeProperty = "property"
+	ProfileChangeRuntime  = "runtime"
+)
+
+// 导入预览中的修改动作
+const (
+	ProfileActionAdd       = "add"
+	ProfileActionUpdate    = "update"
+	ProfileActionUnchanged = "unchanged"
+	ProfileActionSkip      = "skip"
+)
+
+// dangerousOptionPrefixes 导入时需要用户确认的参数：加载外部代码或另一个参数文件，或在 JVM 出错时执行任意命令
+var dangerousOptionPrefixes = []string{
+	"-javaagent:",
+	"-agentpath:",
+	"-agentlib:",
+	"-Xbootclasspath",
+	"-XX:OnOutOfMemoryError=",
+	"-XX:OnError=",
+	"-Djava.system.class.loader=",
+	"-XX:Flags=",
+	"-XX:VMOptionsFile=",
+}
+
+// isDangerousOption 判断参数是否会加载外部代码或执行命令
+func isDangerousOption(option string) bool {
+	option = strings.Trim(strings.TrimSpace(option), `"`)
+	for _, prefix := range dangerousOptionPrefixes {
+		if strings.HasPrefix(option, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProfileRuntime 记录调优配置选择的运行时，路径不存在时按版本和厂商在目标机器上查找
+type ProfileRuntime struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	Major   int    `json:"major"`
+	Vendor  string `json:"vendor"`
+	IsJBR   bool   `json:"isJbr"`
+}
+
+// TuningProfile 是可在机器之间迁移的 JVM 参数、idea.properties 和运行时设置
+type TuningProfile struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	ProductCode   string            `json:"productCode"`
+	BuildNumber   string            `json:"buildNumber"`
+	ExportedAt    string            `json:"exportedAt"`
+	VMOptions     []string          `json:"vmOptions"`
+	Properties    map[string]string `json:"properties"`
+	Runtime       *ProfileRuntime   `json:"runtime,omitempty"`
+}
+
+// ProfileExportResult 是 ExportProfile 的结果
+type ProfileExportResult struct {
+	Path    string        `json:"path"`
+	Profile TuningProfile `json:"profile"`
+}
+
+// ProfileChange 描述导入调优配置时的一项修改
+type ProfileChange struct {
+	Kind    string `json:"kind"`
+	Key     string `json:"key"`
+	Current string `json:"current"`
+	Value   string `json:"value"`
+	Action  string `json:"action"`
+	Warning string `json:"warning,omitempty"`
+	// Dangerous 表示参数会加载外部代码或执行命令，导入时需要用户确认
+	Dangerous bool `json:"dangerous"`
+}
+
+// ProfileImportResult 是 ImportProfile 的结果，Applied 为 false 时只是预览
+type ProfileImportResult struct {
+	Applied  bool            `json:"applied"`
+	Profile  TuningProfile   `json:"profile"`
+	Changes  []ProfileChange `json:"changes"`
+	Warnings []string        `json:"warnings"`
+}
+
+// profileVars 返回占位符与本机目录的对应关系，按目录从深到浅排列，保证替换时优先匹配更具体的目录
+func profileVars(home string) [][2]string {
+	var vars [][2]string
+	if configDir, err := ideConfigDir(home); err == nil {
+		vars = append(vars, [2]string{ProfileVarConfigDir, configDir})
+	}
+	vars = append(vars, [2]string{ProfileVarIDEHome, home})
+	if userHome, err := os.UserHomeDir(); err == nil {
+		vars = append(vars, [2]string{ProfileVarHome, userHome})
+	}
+	return vars
+}
+
+// toPortable 将值中的本机目录替换为占位符，包含占位符的路径统一使用 /
+func toPortable(value string, vars [][2]string) string {
+	replaced := value
+	for _, v := range vars {
+		if v[1] == "" {
+			continue
+		}
+		replaced = replacePathPrefix(replaced, v[1], v[0])
+		replaced = replacePathPrefix(replaced, filepath.ToSlash(v[1]), v[0])
+	}
+	if replaced != value {
+		replaced = filepath.ToSlash(replaced)
+	}
+	return replaced
+}
+
+// isPathNameRune 判断字符是否可能属于文件名的一部分，用于确定路径的边界
+func isPathNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._-+~@", r)
+}
+
+// replacePathPrefix 只替换完整的目录路径：/home/al 不会替换 /home/alice 或 /x/home/al 中的部分
+func replacePathPrefix(value, dir, placeholder string) string {
+	var result strings.Builder
+	for {
+		index := strings.Index(value, dir)
+		if index < 0 {
+			break
+		}
+		end := index + len(dir)
+		before, _ := utf8.DecodeLastRuneInString(value[:index])
+		after, _ := utf8.DecodeRuneInString(value[end:])
+		if (index == 0 || !isPathNameRune(before)) && (end == len(value) || !isPathNameRune(after)) {
+			result.WriteString(value[:index] + placeholder)
+		} else {
+			result.WriteString(value[:end])
+		}
+		value = value[end:]
+	}
+	result.WriteString(value)
+	return result.String()
+}
+
+// fromPortable 将占位符替换为本机目录
+func fromPortable(value string, vars [][2]string) string {
+	for _, v := range vars {
+		value = strings.ReplaceAll(value, v[0], filepath.ToSlash(v[1]))
+	}
+	return value
+}
+
+// buildProfile 读取安装当前生效的调优设置
+// 本工具添加的参数、product-info.json 中的参数和 IDE 目录位置与机器相关，不写入配置
+func buildProfile(home string) (TuningProfile, error) {
+	info, err := readProductInfo(home)
+	if err != nil {
+		return TuningProfile{}, err
+	}
+	vars := profileVars(home)
+	profile := TuningProfile{
+		SchemaVersion: profileSchemaVersion,
+		ProductCode:   info.ProductCode,
+		BuildNumber:   info.BuildNumber,
+		ExportedAt:    time.Now().Format(time.RFC3339),
+		VMOptions:     []string{},
+		Properties:    map[string]string{},
+	}
+
+	effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+	if err != nil {
+		return TuningProfile{}, err
+	}
+	for _, opt := range effective.Options {
+		if opt.Overridden || opt.Source == OptionSourceProductInfo || isToolAddedLine(opt.Option) {
+			continue
+		}
+		profile.VMOptions = append(profile.VMOptions, toPortable(opt.Option, vars))
+	}
+
+	if file, err := loadPropertiesFile(home, PropertiesScopeUser); err == nil {
+		for _, entry := range file.Entries {
+			if ideDirKindOf(entry.Key) != "" {
+				continue
+			}
+			profile.Properties[entry.Key] = toPortable(entry.Value, vars)
+		}
+	}
+
+	if ctx, err := loadRuntimeContext(home); err == nil {
+		if content, err := os.ReadFile(ctx.jdkFile); err == nil {
+			if rt, err := readJavaRuntime(strings.TrimSpace(string(content))); err == nil {
+				profile.Runtime = &ProfileRuntime{
+					Path: toPortable(rt.Path, vars), Version: rt.Version, Major: rt.Major, Vendor: rt.Vendor, IsJBR: rt.IsJBR,
+				}
+			}
+		}
+	}
+	return profile, nil
+}
+
+// ideDirKindOf 返回 idea.properties 中目录属性对应的目录类型，其他属性返回空字符串
+func ideDirKindOf(key string) string {
+	for kind, property := range ideDirProperties {
+		if property == key {
+			return kind
+		}
+	}
+	return ""
+}
+
+// parseProfile 解析调优配置文件并检查格式版本
+func parseProfile(data []byte) (TuningProfile, error) {
+	var profile TuningProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return profile, fmt.Errorf("%w: %v", ErrInvalidProfile, err)
+	}
+	if profile.SchemaVersion < 1 || profile.SchemaVersion > profileSchemaVersion {
+		return profile, fmt.Errorf("%w: 不支持的格式版本 %d", ErrInvalidProfile, profile.SchemaVersion)
+	}
+	return profile, nil
+}
+
+// matchProfileRuntime 确定调优配置中的运行时在本机的位置：路径有效时直接使用，否则查找主版本号和厂商相同的运行时
+func matchProfileRuntime(rt *ProfileRuntime, vars [][2]string, candidates []JavaRuntime) (string, bool) {
+	if found, err := readJavaRuntime(fromPortable(rt.Path, vars)); err == nil {
+		return found.Path, true
+	}
+	for _, candidate := range candidates {
+		if candidate.Major == rt.Major && candidate.IsJBR == rt.IsJBR && candidate.Vendor == rt.Vendor {
+			return candidate.Path, true
+		}
+	}
+	return "", false
+}
+
+// planProfile 对比调优配置与安装的当前设置，返回每一项的修改动作
+func planProfile(home string, profile TuningProfile, runtimes func() []JavaRuntime) ([]ProfileChange, []string, error) {
+	vars := profileVars(home)
+	changes := []ProfileChange{}
+	warnings := []string{}
+	if info, err := readProductInfo(home); err != nil {
+		return nil, nil, err
+	} else if !strings.EqualFold(info.ProductCode, profile.ProductCode) {
+		warnings = append(warnings, fmt.Sprintf("配置导出自 %s，目标 IDE 为 %s", profile.ProductCode, info.ProductCode))
+	}
+
+	effective, err := resolveEffectiveOptions(home, os.LookupEnv, runtime.GOOS)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := make(map[string]EffectiveOption)
+	for _, opt := range effective.Options {
+		if !opt.Overridden {
+			current[opt.Key] = opt
+		}
+	}
+	for _, raw := range profile.VMOptions {
+		option := fromPortable(strings.TrimSpace(raw), vars)
+		if option == "" {
+			continue
+		}
+		change := ProfileChange{Kind: ProfileChangeVMOption, Key: jvmOptionKey(option), Value: option, Action: ProfileActionAdd}
+		if existing, ok := current[change.Key]; ok {
+			change.Current = existing.Option
+			change.Action = ProfileActionUpdate
+			if existing.Option == option {
+				change.Action = ProfileActionUnchanged
+			} else if existing.Source != OptionSourceBin {
+				change.Warning = fmt.Sprintf("该参数由 %s 设置，导入到 bin 目录后仍以该文件为准", existing.File)
+			}
+		}
+		if change.Action != ProfileActionUnchanged && isDangerousOption(option) {
+			change.Dangerous = true
+			change.Warning = "该参数会加载外部代码或参数文件，或在 JVM 出错时执行命令，需确认来源可信后才会导入"
+		}
+		changes = append(changes, change)
+	}
+
+	userProps := map[string]string{}
+	if file, err := loadPropertiesFile(home, PropertiesScopeUser); err == nil {
+		for _, entry := range file.Entries {
+			userProps[entry.Key] = entry.Value
+		}
+	}
+	keys := make([]string, 0, len(profile.Properties))
+	for key := range profile.Properties {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := fromPortable(profile.Properties[key], vars)
+		change := ProfileChange{Kind: ProfileChangeProperty, Key: key, Value: value, Action: ProfileActionAdd}
+		// 导出时不包含目录属性；导入的目录属性可把配置和插件目录指向任意位置，一律拒绝
+		if ideDirKindOf(key) != "" {
+			change.Action = ProfileActionSkip
+			change.Warning = "目录位置与机器相关，且会改变 IDE 加载配置和插件的目录，不支持导入"
+			changes = append(changes, change)
+			continue
+		}
+		if existing, ok := userProps[key]; ok {
+			change.Current = existing
+			change.Action = ProfileActionUpdate
+			if existing == value {
+				change.Action = ProfileActionUnchanged
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if profile.Runtime != nil {
+		change := ProfileChange{Kind: ProfileChangeRuntime, Key: "jdk", Action: ProfileActionAdd}
+		if ctx, err := loadRuntimeContext(home); err == nil {
+			if content, err := os.ReadFile(ctx.jdkFile); err == nil {
+				change.Current = strings.TrimSpace(string(content))
+				change.Action = ProfileActionUpdate
+			}
+		}
+		if path, ok := matchProfileRuntime(profile.Runtime, vars, runtimes()); ok {
+			change.Value = path
+			if path == change.Current {
+				change.Action = ProfileActionUnchanged
+			}
+		} else {
+			change.Value = fromPortable(profile.Runtime.Path, vars)
+			change.Action = ProfileActionSkip
+			change.Warning = fmt.Sprintf("本机没有找到 %s %s 运行时", profile.Runtime.Vendor, profile.Runtime.Version)
+		}
+		changes = append(changes, change)
+	}
+	return changes, warnings, nil
+}
+
+// defaultProfilePath 返回调优配置的默认保存位置
+func defaultProfilePath(productCode string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取主目录: %w", err)
+	}
+	dir := filepath.Join(home, "Downloads")
+	if _, err := os.Stat(dir); err != nil {
+		dir = home
+	}
+	name := fmt.Sprintf("%s-%s-profile-%s.json", appDataDirName, productCode, time.Now().Format("20060102-150405"))
+	return filepath.Join(dir, name), nil
+}
+
+// ExportProfile 将安装的 JVM 参数、用户级 idea.properties 和运行时选择导出为调优配置文件
+// 路径中的主目录、IDE 安装目录和配置目录替换为占位符，outputPath 为空时保存到下载目录
+func (c *ConfigService) ExportProfile(installPath, outputPath string) (ProfileExportResult, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return ProfileExportResult{}, ErrEmptyPath
+	}
+	profile, err := buildProfile(home)
+	if err != nil {
+		c.logger.Error("读取调优配置失败", slog.String("home", home), slog.Any("error", err))
+		return ProfileExportResult{}, err
+	}
+
+	output := sanitizePath(outputPath)
+	if output == "" {
+		if output, err = defaultProfilePath(profile.ProductCode); err != nil {
+			return ProfileExportResult{}, err
+		}
+	}
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return ProfileExportResult{}, fmt.Errorf("序列化调优配置失败: %w", err)
+	}
+	if err := writeFileAtomic(output, append(data, '\n'), 0644); err != nil {
+		return ProfileExportResult{}, err
+	}
+
+	c.logger.Info("调优配置已导出",
+		slog.String("file", output),
+		slog.Int("vmOptions", len(profile.VMOptions)),
+		slog.Int("properties", len(profile.Properties)))
+	return ProfileExportResult{Path: output, Profile: profile}, nil
+}
+
+// ImportProfile 将调优配置应用到安装，apply 为 false 时只返回将要进行的修改
+// JVM 参数写入 bin 目录的 vmoptions 并验证能否启动，属性写入用户级 idea.properties，修改前均会备份
+// -javaagent、-XX:OnError 等危险参数只有 allowDangerous 为 true 时才导入，否则跳过并在结果中标记
+func (c *ConfigService) ImportProfile(installPath, profilePath string, apply, allowDangerous bool) (ProfileImportResult, error) {
+	home := installationHome(installPath)
+	if home == "" {
+		return ProfileImportResult{}, ErrEmptyPath
+	}
+	data, err := os.ReadFile(sanitizePath(profilePath))
+	if err != nil {
+		return ProfileImportResult{}, fmt.Errorf("读取调优配置失败: %w", err)
+	}
+	profile, err := parseProfile(data)
+	if err != nil {
+		return ProfileImportResult{}, err
+	}
+
+	changes, warnings, err := planProfile(home, profile, c.ListRuntimes)
+	if err != nil {
+		return ProfileImportResult{}, err
+	}
+	result := ProfileImportResult{Profile: profile, Changes: changes, Warnings: warnings}
+	if !apply {
+		return result, nil
+	}
+	if err := c.ensureNotRunning(home); err != nil {
+		return result, err
+	}
+
+	var options []string
+	optionKeys := make(map[string]bool)
+	replaceGC := false
+	properties := make(map[string]string)
+	runtimePath := ""
+	for i, change := range changes {
+		if change.Action != ProfileActionAdd && change.Action != ProfileActionUpdate {
+			continue
+		}
+		if change.Dangerous && !allowDangerous {
+			changes[i].Action = ProfileActionSkip
+			result.Warnings = append(result.Warnings, fmt.Sprintf("未确认导入危险参数 %s，已跳过", change.Value))
+			continue
+		}
+		switch change.Kind {
+		case ProfileChangeVMOption:
+			options = append(options, change.Value)
+			optionKeys[change.Key] = true
+			replaceGC = replaceGC || userGCPattern.MatchString(change.Value)
+		case ProfileChangeProperty:
+			properties[change.Key] = change.Value
+		case ProfileChangeRuntime:
+			runtimePath = change.Value
+		}
+	}
+
+	if len(options) > 0 {
+		// 同键参数和导入的 GC 之外的 GC 选项先删除，避免 JVM 因回收器冲突无法启动
+		_, notice, err := c.processVMOptionsVerified(home, func(vmFile string) error {
+			return processVMOptionsFileGeneric(vmFile, func(line string) bool {
+				trimmed := strings.TrimSpace(line)
+				if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+					return false
+				}
+				return optionKeys[jvmOptionKey(trimmed)] || (replaceGC && userGCPattern.MatchString(trimmed))
+			}, options, c.logger)
+		}, "导入调优配置")
+		if err != nil {
+			return result, err
+		}
+		if notice != "" {
+			result.Warnings = append(result.Warnings, notice)
+		}
+	}
+	if len(properties) > 0 {
+		if _, err := c.SetProperties(home, PropertiesScopeUser, properties, nil); err != nil {
+			return result, err
+		}
+	}
+	if runtimePath != "" {
+		if _, err := c.SetRuntime(home, runtimePath); err != nil {
+			return result, err
+		}
+	}
+
+	result.Applied = true
+	c.logger.Info("调优配置已导入",
+		slog.String("home", home),
+		slog.Int("vmOptions", len(options)),
+		slog.Int("properties", len(properties)),
+		slog.Bool("runtime", runtimePath != ""))
+	return result, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestPortablePaths 测试路径占位符的替换和还原
+func TestPortablePaths(t *testing.T) {
+	vars := [][2]string{
+		{ProfileVarConfigDir, filepath.Join("/home/dev", ".config", "JetBrains", "IntelliJIdea2024.1")},
+		{ProfileVarIDEHome, "/opt/idea"},
+		{ProfileVarHome, "/home/dev"},
+	}
+	tests := map[string]string{
+		"-XX:HeapDumpPath=/home/dev/dumps":                         "-XX:HeapDumpPath=${HOME}/dumps",
+		"-Dlog.dir=/home/dev/.config/JetBrains/IntelliJIdea2024.1": "-Dlog.dir=${CONFIG_DIR}",
+		"-javaagent:/opt/idea/lib/agent.jar":                       "-javaagent:${IDE_HOME}/lib/agent.jar",
+		"-Xmx2g":                                                   "-Xmx2g",
+		"-Dother.dir=/home/developer/cache":                        "-Dother.dir=/home/developer/cache",
+		"-Dnested=/srv/home/dev/cache":                             "-Dnested=/srv/home/dev/cache",
+		"-Dpath=/home/dev:/opt/idea/lib":                           "-Dpath=${HOME}:${IDE_HOME}/lib",
+	}
+	for value, want := range tests {
+		got := toPortable(value, vars)
+		if got != want {
+			t.Errorf("toPortable(%q) = %q，期望 %q", value, got, want)
+		}
+		if back := fromPortable(got, vars); back != filepath.ToSlash(value) {
+			t.Errorf("fromPortable(%q) = %q，期望 %q", got, back, value)
+		}
+	}
+
+	if _, err := parseProfile([]byte(`{"schemaVersion":99}`)); !errors.Is(err, ErrInvalidProfile) {
+		t.Errorf("不支持的格式版本应报错，得到 %v", err)
+	}
+}
+
+// TestExportImportProfile 测试导出调优配置并导入到另一个 IDE
+func TestExportImportProfile(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, ".config"))
+	t.Setenv("APPDATA", filepath.Join(base, ".config"))
+
+	jbr := filepath.Join(base, "jdks", "jbr-21")
+	createTestRuntime(t, jbr, "21.0.5", "JetBrains s.r.o.")
+
+	source := filepath.Join(base, "idea")
+	createTestInstallation(t, source, "IU", "241.1", strings.Join([]string{
+		"-Xms128m",
+		"-Xmx4g",
+		"-XX:+UseZGC",
+		"-XX:HeapDumpPath=" + filepath.Join(base, "dumps"),
+		"--add-opens=java.base/jdk.internal.org.objectweb.asm=ALL-UNNAMED",
+		`-javaagent:"/cfg/ja-netfilter.jar"=jetbrains`,
+		"-agentpath:/opt/profiler/libagent.so",
+	}, "\n")+"\n")
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	if _, err := svc.SetProperties(source, PropertiesScopeUser, map[string]string{
+		"idea.max.intellisense.filesize": "5000",
+		"idea.system.path":               filepath.Join(base, "idea-system"),
+	}, nil); err != nil {
+		t.Fatalf("写入 idea.properties 失败: %v", err)
+	}
+	if _, err := svc.SetRuntime(source, jbr); err != nil {
+		t.Fatalf("设置运行时失败: %v", err)
+	}
+
+	exported, err := svc.ExportProfile(source, filepath.Join(base, "profile.json"))
+	if err != nil {
+		t.Fatalf("导出失败: %v", err)
+	}
+	profile := exported.Profile
+	wantOptions := []string{"-Xms128m", "-Xmx4g", "-XX:+UseZGC", "-XX:HeapDumpPath=${HOME}/dumps", "-agentpath:/opt/profiler/libagent.so"}
+	if !slices.Equal(profile.VMOptions, wantOptions) {
+		t.Errorf("导出的 JVM 参数不正确: %v", profile.VMOptions)
+	}
+	if len(profile.Properties) != 1 || profile.Properties["idea.max.intellisense.filesize"] != "5000" {
+		t.Errorf("导出的属性不正确: %v", profile.Properties)
+	}
+	if profile.Runtime == nil || profile.Runtime.Path != "${HOME}/jdks/jbr-21" || profile.Runtime.Major != 21 {
+		t.Errorf("导出的运行时不正确: %+v", profile.Runtime)
+	}
+
+	// 手工加入的目录属性和参数文件不应被直接导入
+	profile.Properties["idea.plugins.path"] = "/tmp/evil-plugins"
+	profile.VMOptions = append(profile.VMOptions, "-XX:VMOptionsFile=/tmp/evil.vmoptions")
+	crafted, _ := json.Marshal(profile)
+	if err := os.WriteFile(exported.Path, crafted, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(base, "goland")
+	createTestInstallation(t, target, "GO", "242.1", "-Xms128m\n-Xmx2g\n-XX:+UseG1GC\n")
+	preview, err := svc.ImportProfile(target, exported.Path, false, false)
+	if err != nil {
+		t.Fatalf("预览失败: %v", err)
+	}
+	if preview.Applied || len(preview.Warnings) != 1 {
+		t.Errorf("预览结果不正确: %+v", preview)
+	}
+	actions := make(map[string]string)
+	for _, change := range preview.Changes {
+		actions[change.Key] = change.Action
+		wantDangerous := strings.HasPrefix(change.Value, "-agentpath:") || strings.HasPrefix(change.Value, "-XX:VMOptionsFile=")
+		if change.Dangerous != wantDangerous {
+			t.Errorf("只有 -agentpath 和 -XX:VMOptionsFile 应标记为危险参数: %+v", change)
+		}
+	}
+	wantActions := map[string]string{
+		"-Xms":                           ProfileActionUnchanged,
+		"-Xmx":                           ProfileActionUpdate,
+		"-XX:UseZGC":                     ProfileActionAdd,
+		"-XX:HeapDumpPath":               ProfileActionAdd,
+		"idea.max.intellisense.filesize": ProfileActionAdd,
+		"jdk":                            ProfileActionAdd,
+		"idea.plugins.path":              ProfileActionSkip,
+	}
+	for key, action := range wantActions {
+		if actions[key] != action {
+			t.Errorf("%s: 期望 %s，实际 %s", key, action, actions[key])
+		}
+	}
+	vmFile := filepath.Join(target, "bin", "idea64.vmoptions")
+	if content, _ := os.ReadFile(vmFile); string(content) != "-Xms128m\n-Xmx2g\n-XX:+UseG1GC\n" {
+		t.Errorf("预览不应修改文件:\n%s", content)
+	}
+
+	imported, err := svc.ImportProfile(target, exported.Path, true, false)
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if !imported.Applied || imported.Changes[len(wantOptions)-1].Action != ProfileActionSkip {
+		t.Errorf("未确认时应跳过危险参数并导入其他修改: %+v", imported)
+	}
+	content, _ := os.ReadFile(vmFile)
+	want := "-Xms128m\n-Xmx4g\n-XX:+UseZGC\n-XX:HeapDumpPath=" + filepath.ToSlash(filepath.Join(base, "dumps")) + "\n"
+	if string(content) != want {
+		t.Errorf("导入后的 vmoptions 不正确:\n%s", content)
+	}
+	if _, err := svc.ImportProfile(target, exported.Path, true, true); err != nil {
+		t.Fatalf("确认后导入失败: %v", err)
+	}
+	if content, _ := os.ReadFile(vmFile); !strings.Contains(string(content), "-agentpath:/opt/profiler/libagent.so\n") ||
+		!strings.Contains(string(content), "-XX:VMOptionsFile=/tmp/evil.vmoptions\n") {
+		t.Errorf("确认后应导入危险参数:\n%s", content)
+	}
+	props, err := loadPropertiesFile(target, PropertiesScopeUser)
+	if err != nil || len(props.Entries) != 1 || props.Entries[0].Value != "5000" {
+		t.Errorf("确认后也不应导入目录属性: %+v, %v", props, err)
+	}
+	status, err := svc.GetRuntime(target)
+	if err != nil || status.SelectedPath != jbr {
+		t.Errorf("导入后的运行时不正确: %+v, %v", status, err)
+	}
+}
@@ -0,0 +1,434 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const ideaPropertiesFileName = "idea.properties"
+
+// idea.properties 所在位置
+const (
+	PropertiesScopeBin  = "bin"  // 安装目录 bin/idea.properties，对所有用户生效，IDE 更新时会被覆盖
+	PropertiesScopeUser = "user" // 配置目录下的 idea.properties，优先级高于 bin 目录
+)
+
+// PropertyEntry 为 properties 文件中的一个键值对
+type PropertyEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Line  int    `json:"line"`
+}
+
+// PropertiesFile 为一个 idea.properties 文件的内容
+type PropertiesFile struct {
+	Scope   string          `json:"scope"`
+	Path    string          `json:"path"`
+	Exists  bool            `json:"exists"`
+	Entries []PropertyEntry `json:"entries"`
+}
+
+// propertiesLine 为一个逻辑行，可能由多个以反斜杠续行的物理行组成
+// 未修改的逻辑行按原始内容写回，保证注释、空行和格式不丢失
+type propertiesLine struct {
+	raw     []string
+	key     string
+	value   string
+	isEntry bool
+	line    int
+}
+
+// propertiesDocument 为无损解析的 properties 文件
+type propertiesDocument struct {
+	lines        []propertiesLine
+	newline      string
+	noFinalBreak bool
+}
+
+// parseProperties 按 java.util.Properties 的规则解析内容，保留每个逻辑行的原始文本
+func parseProperties(content string) *propertiesDocument {
+	doc := &propertiesDocument{newline: "\n"}
+	if strings.Contains(content, "\r\n") {
+		doc.newline = "\r\n"
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	physical := strings.Split(content, "\n")
+	// 以换行结尾的文件会多出一个空元素，写回时由换行符恢复
+	if physical[len(physical)-1] == "" {
+		physical = physical[:len(physical)-1]
+	} else {
+		doc.noFinalBreak = true
+	}
+
+	for i := 0; i < len(physical); i++ {
+		start := i
+		trimmed := strings.TrimLeft(physical[i], " \t\f")
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			doc.lines = append(doc.lines, propertiesLine{raw: []string{physical[i]}, line: start + 1})
+			continue
+		}
+
+		logical := trimmed
+		for endsWithContinuation(physical[i]) && i+1 < len(physical) {
+			logical = logical[:len(logical)-1] + strings.TrimLeft(physical[i+1], " \t\f")
+			i++
+		}
+		if endsWithContinuation(logical) {
+			logical = logical[:len(logical)-1]
+		}
+
+		key, value := splitProperty(logical)
+		doc.lines = append(doc.lines, propertiesLine{
+			raw:     slices.Clone(physical[start : i+1]),
+			key:     unescapeProperty(key),
+			value:   unescapeProperty(value),
+			isEntry: true,
+			line:    start + 1,
+		})
+	}
+	return doc
+}
+
+// endsWithContinuation 判断行尾是否为未转义的反斜杠（奇数个反斜杠）
+func endsWithContinuation(line string) bool {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count%2 == 1
+}
+
+// splitProperty 拆分键和值：键以第一个未转义的 =、: 或空白结束
+func splitProperty(line string) (string, string) {
+	keyEnd := len(line)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			keyEnd = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[keyEnd:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return line[:keyEnd], rest
+}
+
+// unescapeProperty 处理 \t、\n、\r、\f、\uXXXX 等转义
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if r, ok := parseUnicodeEscape(s, i+1); ok {
+				i += 4
+				// UTF-16 代理对由两个连续的 \uXXXX 组成
+				if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+					if low, ok := parseUnicodeEscape(s, i+3); ok {
+						if combined := utf16.DecodeRune(r, low); combined != utf8.RuneError {
+							r = combined
+							i += 6
+						}
+					}
+				}
+				b.WriteRune(r)
+				continue
+			}
+			b.WriteByte('u')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// parseUnicodeEscape 解析 start 位置开始的 4 位十六进制数
+func parseUnicodeEscape(s string, start int) (rune, bool) {
+	if start+4 > len(s) {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(s[start:start+4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(r), true
+}
+
+// escapeProperty 转义键或值，非 ASCII 字符写为 \uXXXX 以兼容 ISO-8859-1 编码
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!', ' ':
+			if isKey || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				// 补充平面字符按 UTF-16 代理对写出
+				if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+					fmt.Fprintf(&b, `\u%04X\u%04X`, r1, r2)
+				} else {
+					fmt.Fprintf(&b, `\u%04X`, r)
+				}
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// entries 返回所有键值对，重复的键以最后一次出现为准
+func (d *propertiesDocument) entries() []PropertyEntry {
+	var result []PropertyEntry
+	for _, l := range d.lines {
+		if !l.isEntry {
+			continue
+		}
+		entry := PropertyEntry{Key: l.key, Value: l.value, Line: l.line}
+		if index := slices.IndexFunc(result, func(e PropertyEntry) bool { return e.Key == l.key }); index >= 0 {
+			result[index] = entry
+		} else {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// get 返回键对应的值，重复的键以最后一次出现为准
+func (d *propertiesDocument) get(key string) (string, bool) {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if d.lines[i].isEntry && d.lines[i].key == key {
+			return d.lines[i].value, true
+		}
+	}
+	return "", false
+}
+
+// set 修改键的值，值未变化时保留原始文本；键不存在时追加到文件末尾
+func (d *propertiesDocument) set(key, value string) {
+	formatted := escapeProperty(key, true) + "=" + escapeProperty(value, false)
+
+	found := false
+	for i := range d.lines {
+		l := &d.lines[i]
+		if !l.isEntry || l.key != key {
+			continue
+		}
+		if found {
+			// 重复定义只保留第一处，避免后面的旧值覆盖新值
+			l.raw, l.isEntry = nil, false
+			continue
+		}
+		found = true
+		if l.value != value {
+			l.raw, l.value = []string{formatted}, value
+		}
+	}
+
+	if !found {
+		d.endContinuation()
+		d.lines = append(d.lines, propertiesLine{raw: []string{formatted}, key: key, value: value, isEntry: true})
+	}
+}
+
+// endContinuation 删除文件末尾悬空的续行反斜杠，否则追加的行会被并入上一个值
+// 文件末尾的续行符不影响解析结果，删除后值不变
+func (d *propertiesDocument) endContinuation() {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		l := &d.lines[i]
+		if len(l.raw) == 0 {
+			continue
+		}
+		last := len(l.raw) - 1
+		if l.isEntry && endsWithContinuation(l.raw[last]) {
+			l.raw[last] = strings.TrimSuffix(l.raw[last], `\`)
+		}
+		return
+	}
+}
+
+// remove 删除键的所有定义
+func (d *propertiesDocument) remove(key string) {
+	d.lines = slices.DeleteFunc(d.lines, func(l propertiesLine) bool { return l.isEntry && l.key == key })
+}
+
+// String 按原始换行符写回，未修改的行保持原样
+func (d *propertiesDocument) String() string {
+	var raws []string
+	for _, l := range d.lines {
+		raws = append(raws, l.raw...)
+	}
+	if len(raws) == 0 {
+		return ""
+	}
+
+	content := strings.Join(raws, d.newline)
+	if !d.noFinalBreak {
+		content += d.newline
+	}
+	return content
+}
+
+// readPropertiesDocument 读取 properties 文件，文件不存在时返回空文档
+func readPropertiesDocument(path string) (*propertiesDocument, bool, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return parseProperties(""), false, nil
+		}
+		return nil, false, fmt.Errorf("读取文件失败: %w", err)
+	}
+	return parseProperties(string(content)), true, nil
+}
+
+// propertiesFilePath 返回安装对应的 idea.properties 路径
+func propertiesFilePath(installPath, scope string) (string, error) {
+	home := installationHome(installPath)
+	switch scope {
+	case PropertiesScopeBin:
+		binDir, err := validateIntelliJPath(home)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(binDir, ideaPropertiesFileName), nil
+	case PropertiesScopeUser:
+		configDir, err := ideConfigDir(home)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(configDir, ideaPropertiesFileName), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidPropertiesScope, scope)
+	}
+}
+
+// loadPropertiesFile 读取指定位置的 idea.properties
+func loadPropertiesFile(installPath, scope string) (PropertiesFile, error) {
+	path, err := propertiesFilePath(installPath, scope)
+	if err != nil {
+		return PropertiesFile{}, err
+	}
+	doc, exists, err := readPropertiesDocument(path)
+	if err != nil {
+		return PropertiesFile{}, err
+	}
+	return PropertiesFile{Scope: scope, Path: path, Exists: exists, Entries: doc.entries()}, nil
+}
+
+// GetProperties 返回安装目录 bin 下和用户配置目录下的 idea.properties 内容
+// 无法确定用户配置目录时只返回 bin 目录下的文件
+func (c *ConfigService) GetProperties(installPath string) ([]PropertiesFile, error) {
+	bin, err := loadPropertiesFile(installPath, PropertiesScopeBin)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []PropertiesFile{bin}
+	if user, err := loadPropertiesFile(installPath, PropertiesScopeUser); err == nil {
+		files = append(files, user)
+	} else {
+		c.logger.Debug("无法读取用户级 idea.properties", slog.Any("error", err))
+	}
+	return files, nil
+}
+
+// SetProperties 修改 idea.properties 中的属性，remove 中的键会被删除
+// 修改前备份原文件并以原子方式写入，未修改的行（包括注释）保持原样
+func (c *ConfigService) SetProperties(installPath, scope string, values map[string]string, remove []string) (PropertiesFile, error) {
+	if err := c.ensureNotRunning(installationHome(installPath)); err != nil {
+		return PropertiesFile{}, err
+	}
+
+	path, err := propertiesFilePath(installPath, scope)
+	if err != nil {
+		return PropertiesFile{}, err
+	}
+
+	doc, exists, err := readPropertiesDocument(path)
+	if err != nil {
+		return PropertiesFile{}, err
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		if strings.TrimSpace(key) == "" {
+			return PropertiesFile{}, fmt.Errorf("%w: 属性名不能为空", ErrInvalidProperty)
+		}
+		keys = append(keys, key)
+	}
+	// 按键名排序，保证新增属性的顺序稳定
+	slices.Sort(keys)
+	for _, key := range keys {
+		doc.set(key, values[key])
+	}
+	for _, key := range remove {
+		doc.remove(key)
+	}
+
+	if exists {
+		if err := checkFileWritePermission(path); err != nil {
+			return PropertiesFile{}, err
+		}
+	} else if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return PropertiesFile{}, fmt.Errorf("创建配置目录失败: %w", err)
+	}
+
+	backupPath, err := writeFileWithBackup(path, []byte(doc.String()), 0644)
+	if err != nil {
+		c.logger.Error("写入 idea.properties 失败", slog.String("file", path), slog.Any("error", err))
+		return PropertiesFile{}, err
+	}
+
+	c.logger.Info("idea.properties 已更新",
+		slog.String("file", path),
+		slog.String("backup", backupPath),
+		slog.Int("changed", len(values)),
+		slog.Int("removed", len(remove)))
+	return PropertiesFile{Scope: scope, Path: path, Exists: true, Entries: doc.entries()}, nil
+}
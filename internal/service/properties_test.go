@@ -0,0 +1,112 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestParsePropertiesLossless 测试解析转义、续行和 Unicode，且未修改时原样写回
+func TestParsePropertiesLossless(t *testing.T) {
+	content := strings.Join([]string{
+		"# 注释保留",
+		"! 另一种注释",
+		"",
+		"idea.max.intellisense.filesize=2500",
+		"idea.config.path = ${user.home}/.GoLand/config",
+		"key\\ with\\ spaces : value",
+		"multi.line=first, \\",
+		"    second, \\",
+		"    third",
+		"unicode=\\u4e2d\\u6587 \\uD83D\\uDE00",
+		"tab\\tkey\tescaped\\=value",
+		"last.line.without.newline=1",
+	}, "\r\n")
+
+	doc := parseProperties(content)
+	if doc.String() != content {
+		t.Fatalf("未修改的文件应原样写回:\n%q\n%q", doc.String(), content)
+	}
+
+	tests := map[string]string{
+		"idea.max.intellisense.filesize": "2500",
+		"idea.config.path":               "${user.home}/.GoLand/config",
+		"key with spaces":                "value",
+		"multi.line":                     "first, second, third",
+		"unicode":                        "中文 😀",
+		"tab\tkey":                       "escaped=value",
+	}
+	for key, want := range tests {
+		if got, ok := doc.get(key); !ok || got != want {
+			t.Errorf("%q: 期望 %q，实际 %q (存在: %v)", key, want, got, ok)
+		}
+	}
+
+	doc.set("multi.line", "单行")
+	doc.set("idea.cycle.buffer.size", "disabled")
+	doc.remove("unicode")
+
+	updated := doc.String()
+	if !strings.HasPrefix(updated, "# 注释保留\r\n! 另一种注释\r\n\r\nidea.max.intellisense.filesize=2500\r\n") {
+		t.Errorf("未修改的行应保持原样:\n%s", updated)
+	}
+	if !strings.Contains(updated, "multi.line=\\u5355\\u884C\r\n") || strings.Contains(updated, "second") {
+		t.Errorf("续行属性应被替换为单行:\n%s", updated)
+	}
+	if !strings.HasSuffix(updated, "idea.cycle.buffer.size=disabled") || strings.Contains(updated, "unicode=") {
+		t.Errorf("新增或删除属性不正确:\n%s", updated)
+	}
+
+	reparsed := parseProperties(updated)
+	if got, _ := reparsed.get("multi.line"); got != "单行" {
+		t.Errorf("重新解析后的值不正确: %q", got)
+	}
+}
+
+// TestSetAfterTrailingContinuation 测试文件以续行反斜杠结尾时追加的属性不会并入上一个值
+func TestSetAfterTrailingContinuation(t *testing.T) {
+	for _, content := range []string{"a=first, \\", "a=first, \\\n"} {
+		doc := parseProperties(content)
+		doc.set("b", "2")
+
+		reparsed := parseProperties(doc.String())
+		if got, _ := reparsed.get("a"); got != "first, " {
+			t.Errorf("%q: 原有的值不应改变，实际 %q", content, got)
+		}
+		if got, ok := reparsed.get("b"); !ok || got != "2" {
+			t.Errorf("%q: 追加的属性应单独成行，实际 %q\n%s", content, got, doc.String())
+		}
+	}
+}
+
+// TestSetProperties 测试修改 bin 目录下的 idea.properties 时创建备份
+func TestSetProperties(t *testing.T) {
+	home := t.TempDir()
+	createTestInstallation(t, home, "GO", "241.1", "-Xmx2048m\n")
+	propsPath := filepath.Join(home, "bin", ideaPropertiesFileName)
+	if err := os.WriteFile(propsPath, []byte("# 默认配置\nidea.max.intellisense.filesize=2500\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(t.TempDir(), settingsFileName)))
+	file, err := svc.SetProperties(home, PropertiesScopeBin, map[string]string{"idea.max.intellisense.filesize": "5000"}, nil)
+	if err != nil {
+		t.Fatalf("修改失败: %v", err)
+	}
+	if len(file.Entries) != 1 || file.Entries[0].Value != "5000" {
+		t.Errorf("返回的属性不正确: %+v", file.Entries)
+	}
+
+	content, _ := os.ReadFile(propsPath)
+	if string(content) != "# 默认配置\nidea.max.intellisense.filesize=5000\n" {
+		t.Errorf("文件内容不正确: %q", content)
+	}
+	if backups := listBackups(propsPath); len(backups) != 1 {
+		t.Errorf("应创建 1 个备份，实际 %v", backups)
+	}
+
+	if _, err := svc.SetProperties(home, "system", nil, nil); err == nil {
+		t.Error("无效的位置应返回错误")
+	}
+}
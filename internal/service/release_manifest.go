@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Release 中签名清单及其签名文件的名称
+const (
+	ReleaseManifestName    = "manifest.json"
+	ReleaseManifestSigName = "manifest.json.sig"
+
+	releaseManifestSchemaVersion = 1
+	maxManifestSizeBytes         = 1 << 20
+)
+
+// releaseSigningKey 是验证 Release 清单签名的 ed25519 公钥（base64 编码）
+// 密钥对由维护者用 cmd/releasesign -genkey 生成，仓库中只提交公钥；文件为空时应用内更新不可用
+// 生成和轮换密钥的步骤见 README 的“发布签名”一节
+//
+//go:embed keys/release-signing.pub
+var releaseSigningKey string
+
+// ReleaseManifest 是随 Release 发布的签名清单
+// 清单由 cmd/releasesign 在 CI 中生成并签名，客户端以其中的哈希为准，不信任 API 或镜像返回的内容
+type ReleaseManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	Version       string          `json:"version"`
+	Assets        []ManifestAsset `json:"assets"`
+}
+
+// ManifestAsset 保存清单中单个安装包的名称、哈希和大小
+type ManifestAsset struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// asset 按名称查找清单中的安装包
+func (m *ReleaseManifest) asset(name string) (ManifestAsset, bool) {
+	for _, asset := range m.Assets {
+		if asset.Name == name {
+			return asset, true
+		}
+	}
+	return ManifestAsset{}, false
+}
+
+// releasePublicKey 解析内置的签名公钥，未配置公钥时返回 ErrSigningKeyMissing
+func releasePublicKey() (ed25519.PublicKey, error) {
+	if strings.TrimSpace(releaseSigningKey) == "" {
+		return nil, ErrSigningKeyMissing
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(releaseSigningKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("内置的 Release 签名公钥无效")
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// verifyReleaseManifest 验证清单签名并解析清单内容
+// signature 为 base64 编码的 ed25519 签名，签名对象是清单文件的原始字节
+func verifyReleaseManifest(data, signature []byte, publicKey ed25519.PublicKey) (*ReleaseManifest, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: 签名格式错误", ErrManifestSignature)
+	}
+
+	if !ed25519.Verify(publicKey, data, sig) {
+		return nil, ErrManifestSignature
+	}
+
+	var manifest ReleaseManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("解析 Release 清单失败: %w", err)
+	}
+	if manifest.SchemaVersion != releaseManifestSchemaVersion {
+		return nil, fmt.Errorf("不支持的 Release 清单版本: %d", manifest.SchemaVersion)
+	}
+
+	for i, asset := range manifest.Assets {
+		manifest.Assets[i].SHA256 = strings.ToLower(asset.SHA256)
+	}
+	return &manifest, nil
+}
+
+// fetchSignedManifest 下载并验证 Release 的签名清单
+// 清单中的版本号必须与 API 返回的版本一致且比当前版本新，防止被替换为其他版本的清单
+func fetchSignedManifest(ctx context.Context, client *http.Client, release *ReleaseInfo, convertURL func(string) string) (*ReleaseManifest, error) {
+	publicKey, err := releasePublicKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var manifestAsset, sigAsset *AssetInfo
+	for i := range release.Assets {
+		switch release.Assets[i].Name {
+		case ReleaseManifestName:
+			manifestAsset = &release.Assets[i]
+		case ReleaseManifestSigName:
+			sigAsset = &release.Assets[i]
+		}
+	}
+	if manifestAsset == nil || sigAsset == nil {
+		return nil, ErrManifestMissing
+	}
+
+	data, err := fetchSmallAsset(ctx, client, convertURL(manifestAsset.DownloadURL))
+	if err != nil {
+		return nil, fmt.Errorf("下载 Release 清单失败: %w", err)
+	}
+	sig, err := fetchSmallAsset(ctx, client, convertURL(sigAsset.DownloadURL))
+	if err != nil {
+		return nil, fmt.Errorf("下载 Release 清单签名失败: %w", err)
+	}
+
+	manifest, err := verifyReleaseManifest(data, sig, publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkManifestVersion(manifest, release.Version, Version); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// checkManifestVersion 检查清单版本与 API 返回的版本一致且比当前版本新
+// 旧版本的清单同样带有有效签名，只检查签名无法阻止把旧版本重放为“更新”
+func checkManifestVersion(manifest *ReleaseManifest, releaseVersion, currentVersion string) error {
+	if compareVersions(manifest.Version, releaseVersion) != 0 {
+		return fmt.Errorf("%w: 清单版本 %s 与 Release 版本 %s 不一致",
+			ErrManifestSignature, manifest.Version, releaseVersion)
+	}
+	if compareVersions(manifest.Version, currentVersion) <= 0 {
+		return fmt.Errorf("%w: 清单版本 %s 不比当前版本 %s 新", ErrNoNewerRelease, manifest.Version, currentVersion)
+	}
+	return nil
+}
+
+// fetchSmallAsset 下载体积较小的 Release 资源（清单、签名、校验和文件）
+func fetchSmallAsset(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("状态码: %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxManifestSizeBytes))
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// signTestManifest 使用临时密钥签名清单
+func signTestManifest(t *testing.T, manifest ReleaseManifest) ([]byte, []byte, ed25519.PublicKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("序列化清单失败: %v", err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data))
+	return data, []byte(sig + "\n"), publicKey
+}
+
+// TestVerifyReleaseManifest 测试清单签名验证
+func TestVerifyReleaseManifest(t *testing.T) {
+	manifest := ReleaseManifest{
+		SchemaVersion: releaseManifestSchemaVersion,
+		Version:       "2.1.0",
+		Assets: []ManifestAsset{
+			{Name: "intellijapp-2.1.0-linux-amd64.AppImage", SHA256: strings.Repeat("A", 64), Size: 42},
+		},
+	}
+	data, sig, publicKey := signTestManifest(t, manifest)
+
+	verified, err := verifyReleaseManifest(data, sig, publicKey)
+	if err != nil {
+		t.Fatalf("验证失败: %v", err)
+	}
+	asset, ok := verified.asset("intellijapp-2.1.0-linux-amd64.AppImage")
+	if !ok {
+		t.Fatal("清单中缺少安装包")
+	}
+	if asset.SHA256 != strings.Repeat("a", 64) {
+		t.Errorf("哈希应统一为小写: %s", asset.SHA256)
+	}
+
+	tampered := []byte(strings.Replace(string(data), "2.1.0", "9.9.9", 1))
+	if _, err := verifyReleaseManifest(tampered, sig, publicKey); !errors.Is(err, ErrManifestSignature) {
+		t.Errorf("篡改后的清单应验证失败，实际: %v", err)
+	}
+
+	otherKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := verifyReleaseManifest(data, sig, otherKey); !errors.Is(err, ErrManifestSignature) {
+		t.Errorf("使用其他公钥应验证失败，实际: %v", err)
+	}
+}
+
+// TestCheckManifestVersion 测试拒绝与 Release 不一致或不比当前版本新的清单
+func TestCheckManifestVersion(t *testing.T) {
+	manifest := &ReleaseManifest{SchemaVersion: releaseManifestSchemaVersion, Version: "2.0.0"}
+
+	if err := checkManifestVersion(manifest, "2.0.0", "1.9.0"); err != nil {
+		t.Errorf("更新的清单应通过检查: %v", err)
+	}
+	if err := checkManifestVersion(manifest, "2.1.0", "1.9.0"); !errors.Is(err, ErrManifestSignature) {
+		t.Errorf("版本不一致应返回 ErrManifestSignature，实际: %v", err)
+	}
+	// 旧版本带有效签名的清单被重放时应拒绝
+	for _, current := range []string{"2.0.0", "2.1.0"} {
+		if err := checkManifestVersion(manifest, "2.0.0", current); !errors.Is(err, ErrNoNewerRelease) {
+			t.Errorf("当前版本 %s 时应拒绝清单，实际: %v", current, err)
+		}
+	}
+}
+
+// TestReleasePublicKey 测试内置公钥可以正确解析，未配置公钥时返回 ErrSigningKeyMissing
+func TestReleasePublicKey(t *testing.T) {
+	_, err := releasePublicKey()
+	if strings.TrimSpace(releaseSigningKey) == "" {
+		if !errors.Is(err, ErrSigningKeyMissing) {
+			t.Fatalf("未配置公钥应返回 ErrSigningKeyMissing，实际: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("内置公钥无效: %v", err)
+	}
+}
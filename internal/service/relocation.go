@@ -0,0 +1,423 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 迁移进度的阶段
+const (
+	RelocationPhaseCopy   = "copy"
+	RelocationPhaseVerify = "verify"
+	RelocationPhaseRemove = "remove"
+)
+
+// RelocationRequest 描述一次目录迁移
+type RelocationRequest struct {
+	Kind   string `json:"kind"`   // config、system、plugins 或 log
+	Target string `json:"target"` // 新位置，必须为不存在或空的目录
+	Move   bool   `json:"move"`   // true 表示校验通过后删除原目录，false 只复制
+	Scope  string `json:"scope"`  // 写入哪个 idea.properties，默认为用户级；config 目录默认且只能写入 bin 目录
+}
+
+// RelocationRecord 记录一次已完成的迁移，用于撤销
+type RelocationRecord struct {
+	ID               string `json:"id"`
+	InstallPath      string `json:"installPath"`
+	Kind             string `json:"kind"`
+	Property         string `json:"property"`
+	Scope            string `json:"scope"`
+	From             string `json:"from"`
+	To               string `json:"to"`
+	Moved            bool   `json:"moved"`
+	HadPreviousValue bool   `json:"hadPreviousValue"`
+	PreviousValue    string `json:"previousValue"`
+	Files            int    `json:"files"`
+	Bytes            int64  `json:"bytes"`
+	CreatedAt        string `json:"createdAt"`
+	RevertedAt       string `json:"revertedAt,omitempty"`
+}
+
+// RelocationProgress 通过 EventRelocationProgress 事件推送迁移进度
+type RelocationProgress struct {
+	Kind  string `json:"kind"`
+	Phase string `json:"phase"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"`
+	File  string `json:"file"`
+}
+
+// relocationProgressFunc 接收复制或校验的进度
+type relocationProgressFunc func(phase string, done, total int64, file string)
+
+// isSubPath 判断 child 是否位于 parent 目录内（或相同）
+func isSubPath(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// checkRelocationTarget 检查目标目录不存在或为空，且与原目录互不包含
+func checkRelocationTarget(from, to string) error {
+	if !filepath.IsAbs(to) {
+		return fmt.Errorf("%w: 目标路径必须是绝对路径", ErrInvalidRelocation)
+	}
+	if isSubPath(from, to) || isSubPath(to, from) {
+		return fmt.Errorf("%w: 目标目录不能与原目录互相包含", ErrInvalidRelocation)
+	}
+
+	entries, err := os.ReadDir(to)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("无法访问目标目录: %w", err)
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%w: %s", ErrRelocationTargetNotEmpty, to)
+	}
+	return nil
+}
+
+// copyTree 复制目录树并返回每个文件的 SHA-256，符号链接按原样重建
+func copyTree(src, dst string, total int64, progress relocationProgressFunc) (map[string]string, error) {
+	hashes := make(map[string]string)
+	var done int64
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			hash, err := copyFileWithHash(path, target, info.Mode().Perm())
+			if err != nil {
+				return err
+			}
+			hashes[rel] = hash
+			done += info.Size()
+			progress(RelocationPhaseCopy, done, total, rel)
+		}
+		return nil
+	})
+	return hashes, err
+}
+
+// copyFileWithHash 复制单个文件，同时计算源文件的 SHA-256
+func copyFileWithHash(src, dst string, perm fs.FileMode) (string, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return "", err
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(out, io.TeeReader(in, hasher)); err != nil {
+		out.Close()
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		return "", err
+	}
+
+	if info, err := os.Stat(src); err == nil {
+		_ = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// verifyTree 重新计算目标目录中每个文件的 SHA-256 并与复制时的结果比较
+func verifyTree(dst string, hashes map[string]string, total int64, progress relocationProgressFunc) error {
+	var done int64
+	for rel, want := range hashes {
+		path := filepath.Join(dst, rel)
+		got, err := fileSHA256(path)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrRelocationVerify, rel, err)
+		}
+		if got != want {
+			return fmt.Errorf("%w: %s", ErrRelocationVerify, rel)
+		}
+		if info, err := os.Stat(path); err == nil {
+			done += info.Size()
+		}
+		progress(RelocationPhaseVerify, done, total, rel)
+	}
+	return nil
+}
+
+// transferDirectory 复制目录并校验，move 为 true 时校验通过后删除原目录
+// 任何一步失败都会删除已复制的内容，原目录保持不变
+func transferDirectory(from, to string, move bool, progress relocationProgressFunc) (int, int64, error) {
+	if _, err := os.Stat(from); errors.Is(err, fs.ErrNotExist) {
+		// 原目录尚未创建（如从未启动过的 IDE），只需修改配置
+		return 0, 0, nil
+	}
+
+	total, count, err := dirSize(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("统计目录大小失败: %w", err)
+	}
+
+	_, statErr := os.Stat(to)
+	createdTarget := errors.Is(statErr, fs.ErrNotExist)
+	cleanup := func() {
+		if createdTarget {
+			_ = os.RemoveAll(to)
+			return
+		}
+		entries, _ := os.ReadDir(to)
+		for _, entry := range entries {
+			_ = os.RemoveAll(filepath.Join(to, entry.Name()))
+		}
+	}
+
+	hashes, err := copyTree(from, to, total, progress)
+	if err != nil {
+		cleanup()
+		return 0, 0, fmt.Errorf("复制目录失败: %w", err)
+	}
+	if err := verifyTree(to, hashes, total, progress); err != nil {
+		cleanup()
+		return 0, 0, err
+	}
+
+	if move {
+		progress(RelocationPhaseRemove, total, total, "")
+		if err := os.RemoveAll(from); err != nil {
+			return count, total, fmt.Errorf("删除原目录失败: %w", err)
+		}
+	}
+	return count, total, nil
+}
+
+// relocationProgress 返回按间隔节流推送进度事件的回调
+func (c *ConfigService) relocationProgress(kind string) relocationProgressFunc {
+	var lastEmit time.Time
+	return func(phase string, done, total int64, file string) {
+		if done < total && time.Since(lastEmit) < progressEmitInterval {
+			return
+		}
+		lastEmit = time.Now()
+		c.emit(EventRelocationProgress, RelocationProgress{Kind: kind, Phase: phase, Done: done, Total: total, File: file})
+	}
+}
+
+// RelocateDirectory 将 IDE 的 config、system、plugins 或 log 目录迁移到新位置
+// 复制并逐个文件校验 SHA-256 后写入 idea.properties，迁移记录保存在设置中以便撤销
+func (c *ConfigService) RelocateDirectory(installPath string, req RelocationRequest) (RelocationRecord, error) {
+	home := installationHome(installPath)
+	if !slices.Contains(ideDirKinds, req.Kind) {
+		return RelocationRecord{}, fmt.Errorf("%w: 未知的目录类型 %q", ErrInvalidRelocation, req.Kind)
+	}
+	if req.Scope == "" {
+		req.Scope = PropertiesScopeUser
+		// 用户级 idea.properties 位于默认 config 目录中，迁移 config 时只能写入 bin 目录
+		if req.Kind == IDEDirConfig {
+			req.Scope = PropertiesScopeBin
+		}
+	}
+	if req.Kind == IDEDirConfig && req.Scope == PropertiesScopeUser {
+		return RelocationRecord{}, fmt.Errorf("%w: config 目录的位置只能写入 bin 目录的 idea.properties", ErrInvalidRelocation)
+	}
+	if err := c.ensureNotRunning(home); err != nil {
+		return RelocationRecord{}, err
+	}
+
+	dirs, err := ideDirLocations(home)
+	if err != nil {
+		return RelocationRecord{}, err
+	}
+	current := dirs[slices.IndexFunc(dirs, func(d IDEDirectory) bool { return d.Kind == req.Kind })]
+
+	target := sanitizePath(req.Target)
+	if target == "" {
+		return RelocationRecord{}, ErrEmptyPath
+	}
+	if err := checkRelocationTarget(current.Path, target); err != nil {
+		return RelocationRecord{}, err
+	}
+
+	propsPath, err := propertiesFilePath(home, req.Scope)
+	if err != nil {
+		return RelocationRecord{}, err
+	}
+	// 属性文件位于原目录内时，移动会把刚写入的新位置一并删除
+	if isSubPath(current.Path, propsPath) {
+		return RelocationRecord{}, fmt.Errorf("%w: %s 位于待迁移的目录中", ErrInvalidRelocation, propsPath)
+	}
+	doc, _, err := readPropertiesDocument(propsPath)
+	if err != nil {
+		return RelocationRecord{}, err
+	}
+	previous, hadPrevious := doc.get(current.Property)
+
+	c.logger.Info("开始迁移 IDE 目录",
+		slog.String("kind", req.Kind),
+		slog.String("from", current.Path),
+		slog.String("to", target),
+		slog.Bool("move", req.Move))
+
+	// 先复制并校验，不删除原目录；写入配置成功后再删除，避免配置写入失败时数据只剩一份
+	files, size, err := transferDirectory(current.Path, target, false, c.relocationProgress(req.Kind))
+	if err != nil {
+		c.logger.Error("迁移 IDE 目录失败", slog.Any("error", err))
+		return RelocationRecord{}, err
+	}
+
+	if _, err := c.SetProperties(home, req.Scope, map[string]string{current.Property: filepath.ToSlash(target)}, nil); err != nil {
+		_ = os.RemoveAll(target)
+		return RelocationRecord{}, err
+	}
+
+	record := RelocationRecord{
+		ID:               newInstallationID(),
+		InstallPath:      home,
+		Kind:             req.Kind,
+		Property:         current.Property,
+		Scope:            req.Scope,
+		From:             current.Path,
+		To:               target,
+		Moved:            req.Move,
+		HadPreviousValue: hadPrevious,
+		PreviousValue:    previous,
+		Files:            files,
+		Bytes:            size,
+		CreatedAt:        time.Now().Format(time.RFC3339),
+	}
+	// 删除原目录前保存迁移记录，记录保存失败时回滚，否则移动后无法撤销
+	if _, err := c.updateSettings(func(s *AppSettings) error {
+		s.Relocations = append(s.Relocations, record)
+		return nil
+	}); err != nil {
+		c.logger.Error("保存迁移记录失败，回滚迁移", slog.Any("error", err))
+		if _, restoreErr := c.restoreRelocationProperty(record); restoreErr != nil {
+			c.logger.Error("恢复 idea.properties 失败，保留新位置的数据", slog.Any("error", restoreErr))
+			return RelocationRecord{}, fmt.Errorf("保存迁移记录失败: %w", errors.Join(err, restoreErr))
+		}
+		_ = os.RemoveAll(target)
+		return RelocationRecord{}, fmt.Errorf("保存迁移记录失败: %w", err)
+	}
+
+	if req.Move && files > 0 {
+		c.relocationProgress(req.Kind)(RelocationPhaseRemove, size, size, "")
+		if err := os.RemoveAll(current.Path); err != nil {
+			c.logger.Warn("删除原目录失败", slog.String("path", current.Path), slog.Any("error", err))
+		}
+	}
+
+	c.logger.Info("IDE 目录迁移完成", slog.Int("files", files), slog.Int64("bytes", size))
+	return record, nil
+}
+
+// ListRelocations 返回所有目录迁移记录
+func (c *ConfigService) ListRelocations() []RelocationRecord {
+	return c.currentSettings().Relocations
+}
+
+// restoreRelocationProperty 将 idea.properties 中的目录属性恢复为迁移前的值，迁移前未设置时删除该属性
+func (c *ConfigService) restoreRelocationProperty(record RelocationRecord) (PropertiesFile, error) {
+	values := map[string]string{}
+	var remove []string
+	if record.HadPreviousValue {
+		values[record.Property] = record.PreviousValue
+	} else {
+		remove = append(remove, record.Property)
+	}
+	return c.SetProperties(record.InstallPath, record.Scope, values, remove)
+}
+
+// RevertRelocation 撤销一次目录迁移：恢复 idea.properties 中的原设置
+// 若迁移时移动了数据，则将数据复制回原位置并校验后删除新位置的数据
+func (c *ConfigService) RevertRelocation(id string) (RelocationRecord, error) {
+	settings := c.currentSettings()
+	index := slices.IndexFunc(settings.Relocations, func(r RelocationRecord) bool { return r.ID == id })
+	if index < 0 {
+		return RelocationRecord{}, fmt.Errorf("%w: %s", ErrRelocationNotFound, id)
+	}
+	record := settings.Relocations[index]
+	if record.RevertedAt != "" {
+		return RelocationRecord{}, fmt.Errorf("%w: 该迁移已撤销", ErrInvalidRelocation)
+	}
+	if err := c.ensureNotRunning(installationHome(record.InstallPath)); err != nil {
+		return RelocationRecord{}, err
+	}
+
+	// 与迁移时的顺序相同：先复制回原位置，恢复配置成功后再删除新位置的数据
+	var size int64
+	if record.Moved {
+		if err := checkRelocationTarget(record.To, record.From); err != nil {
+			return RelocationRecord{}, err
+		}
+		var err error
+		if _, size, err = transferDirectory(record.To, record.From, false, c.relocationProgress(record.Kind)); err != nil {
+			return RelocationRecord{}, err
+		}
+	}
+
+	if _, err := c.restoreRelocationProperty(record); err != nil {
+		if record.Moved {
+			_ = os.RemoveAll(record.From)
+		}
+		return RelocationRecord{}, err
+	}
+
+	if record.Moved {
+		c.relocationProgress(record.Kind)(RelocationPhaseRemove, size, size, "")
+		if err := os.RemoveAll(record.To); err != nil {
+			c.logger.Warn("删除新位置的数据失败", slog.String("path", record.To), slog.Any("error", err))
+		}
+	}
+
+	record.RevertedAt = time.Now().Format(time.RFC3339)
+	if _, err := c.updateSettings(func(s *AppSettings) error {
+		for i := range s.Relocations {
+			if s.Relocations[i].ID == id {
+				s.Relocations[i] = record
+			}
+		}
+		return nil
+	}); err != nil {
+		c.logger.Error("保存迁移记录失败", slog.Any("error", err))
+		return RelocationRecord{}, fmt.Errorf("目录已恢复，但保存迁移记录失败: %w", err)
+	}
+
+	c.logger.Info("已撤销 IDE 目录迁移", slog.String("kind", record.Kind), slog.String("path", record.From))
+	return record, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRelocateDirectory 测试迁移 system 目录、写入 idea.properties 并撤销
+func TestRelocateDirectory(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+
+	home := filepath.Join(base, "goland")
+	createTestInstallation(t, home, "GO", "241.1", "-Xmx2048m\n")
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	dirs, err := svc.GetIDEDirectories(home)
+	if err != nil {
+		t.Fatalf("获取目录失败: %v", err)
+	}
+	system := dirs[1]
+	if system.Kind != IDEDirSystem || system.Custom {
+		t.Fatalf("system 目录应为默认位置: %+v", system)
+	}
+
+	if err := os.MkdirAll(filepath.Join(system.Path, "caches"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(system.Path, "caches", "index.dat"), []byte("cache data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(base, "bigdisk", "goland-system")
+	if _, err := svc.RelocateDirectory(home, RelocationRequest{Kind: IDEDirSystem, Target: system.Path + "/sub"}); err == nil {
+		t.Error("目标位于原目录内时应返回错误")
+	}
+
+	record, err := svc.RelocateDirectory(home, RelocationRequest{Kind: IDEDirSystem, Target: target, Move: true})
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if record.Files != 1 || record.From != system.Path {
+		t.Errorf("迁移记录不正确: %+v", record)
+	}
+	if _, err := os.Stat(system.Path); !os.IsNotExist(err) {
+		t.Error("移动后原目录应被删除")
+	}
+	if data, err := os.ReadFile(filepath.Join(target, "caches", "index.dat")); err != nil || string(data) != "cache data" {
+		t.Errorf("目标目录数据不正确: %q, %v", data, err)
+	}
+
+	dirs, err = svc.GetIDEDirectories(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirs[1].Path != target || !dirs[1].Custom || dirs[1].Source != PropertiesScopeUser {
+		t.Errorf("迁移后应读取到新位置: %+v", dirs[1])
+	}
+	if dirs[3].Path != filepath.Join(target, "log") {
+		t.Errorf("log 目录应跟随自定义的 system 目录，实际 %s", dirs[3].Path)
+	}
+
+	if _, err := svc.RevertRelocation(record.ID); err != nil {
+		t.Fatalf("撤销失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(system.Path, "caches", "index.dat")); err != nil {
+		t.Errorf("撤销后数据应回到原位置: %v", err)
+	}
+	dirs, _ = svc.GetIDEDirectories(home)
+	if dirs[1].Custom {
+		t.Errorf("撤销后应恢复默认位置: %+v", dirs[1])
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("撤销后新位置的数据应被删除: %v", err)
+	}
+	if _, err := svc.RevertRelocation(record.ID); err == nil {
+		t.Error("重复撤销应返回错误")
+	}
+
+	// 迁移记录无法保存时应回滚，保留原目录
+	blocker := filepath.Join(base, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := NewConfigService(WithSettingsPath(filepath.Join(blocker, settingsFileName)))
+	if _, err := broken.RelocateDirectory(home, RelocationRequest{Kind: IDEDirSystem, Target: target, Move: true}); err == nil {
+		t.Fatal("迁移记录保存失败时应返回错误")
+	}
+	if _, err := os.Stat(filepath.Join(system.Path, "caches", "index.dat")); err != nil {
+		t.Errorf("回滚后原目录应保留: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("回滚后应删除新位置的数据: %v", err)
+	}
+	if dirs, _ = svc.GetIDEDirectories(home); dirs[1].Custom {
+		t.Errorf("回滚后应恢复默认位置: %+v", dirs[1])
+	}
+}
+
+// TestRelocateConfigDirectory 测试移动 config 目录时新位置写入 bin 目录的 idea.properties
+func TestRelocateConfigDirectory(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(base, "cache"))
+
+	home := filepath.Join(base, "goland")
+	createTestInstallation(t, home, "GO", "241.1", "-Xmx2048m\n")
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	dirs, err := svc.GetIDEDirectories(home)
+	if err != nil {
+		t.Fatalf("获取目录失败: %v", err)
+	}
+	config := dirs[0]
+	if err := os.MkdirAll(filepath.Join(config.Path, "options"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(config.Path, "options", "ide.general.xml"), []byte("<application/>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(base, "bigdisk", "goland-config")
+	if _, err := svc.RelocateDirectory(home, RelocationRequest{Kind: IDEDirConfig, Target: target, Move: true, Scope: PropertiesScopeUser}); err == nil {
+		t.Error("config 目录写入用户级 idea.properties 应返回错误")
+	}
+
+	record, err := svc.RelocateDirectory(home, RelocationRequest{Kind: IDEDirConfig, Target: target, Move: true})
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if record.Scope != PropertiesScopeBin {
+		t.Errorf("config 目录应写入 bin 目录的 idea.properties: %+v", record)
+	}
+	if _, err := os.Stat(config.Path); !os.IsNotExist(err) {
+		t.Error("移动后原目录应被删除")
+	}
+
+	dirs, err = svc.GetIDEDirectories(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirs[0].Path != target || !dirs[0].Custom || !dirs[0].Exists || dirs[0].Source != PropertiesScopeBin {
+		t.Errorf("迁移后应读取到新位置: %+v", dirs[0])
+	}
+	if _, err := os.Stat(filepath.Join(target, "options", "ide.general.xml")); err != nil {
+		t.Errorf("目标目录数据不正确: %v", err)
+	}
+}
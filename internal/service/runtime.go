@@ -0,0 +1,338 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// JavaRuntime 描述一个通过 release 文件识别的 JDK/JBR
+type JavaRuntime struct {
+	Path        string `json:"path"`
+	Version     string `json:"version"`
+	Major       int    `json:"major"`
+	Vendor      string `json:"vendor"`
+	Arch        string `json:"arch"`
+	IsJBR       bool   `json:"isJbr"`
+	JavaBinary  string `json:"javaBinary"`
+	Description string `json:"description"`
+}
+
+// RuntimeStatus 描述 IDE 当前使用的运行时
+type RuntimeStatus struct {
+	Bundled       *JavaRuntime `json:"bundled"`
+	Selected      *JavaRuntime `json:"selected"`
+	SelectedPath  string       `json:"selectedPath"`
+	JDKFile       string       `json:"jdkFile"`
+	EnvVar        string       `json:"envVar"`
+	EnvOverride   string       `json:"envOverride"`
+	RequiredMajor int          `json:"requiredMajor"`
+	// RestartRequired 列出修改运行时后需要重启的实例
+	RestartRequired []RunningInstance `json:"restartRequired,omitempty"`
+}
+
+// runtimeHome 规范化 JDK 路径，macOS 的 JDK 主目录位于 Contents/Home
+func runtimeHome(path string) string {
+	path = sanitizePath(path)
+	if home := filepath.Join(path, "Contents", "Home"); fileExists(filepath.Join(home, "release")) {
+		return home
+	}
+	return path
+}
+
+// fileExists 判断文件或目录是否存在
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readJavaRuntime 读取 JDK 主目录中的 release 文件
+func readJavaRuntime(path string) (*JavaRuntime, error) {
+	home := runtimeHome(path)
+	file, err := os.Open(filepath.Join(home, "release"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRuntime, home)
+		}
+		return nil, fmt.Errorf("读取 release 文件失败: %w", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			values[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取 release 文件失败: %w", err)
+	}
+
+	version := values["JAVA_VERSION"]
+	if version == "" {
+		return nil, fmt.Errorf("%w: release 文件缺少 JAVA_VERSION", ErrInvalidRuntime)
+	}
+
+	javaBinary := filepath.Join(home, "bin", "java")
+	if runtime.GOOS == "windows" {
+		javaBinary += ".exe"
+	}
+	if !fileExists(javaBinary) {
+		return nil, fmt.Errorf("%w: 缺少 %s", ErrInvalidRuntime, javaBinary)
+	}
+
+	vendor := values["IMPLEMENTOR"]
+	return &JavaRuntime{
+		Path:        home,
+		Version:     version,
+		Major:       javaMajorVersion(version),
+		Vendor:      vendor,
+		Arch:        values["OS_ARCH"],
+		IsJBR:       strings.Contains(vendor, "JetBrains"),
+		JavaBinary:  javaBinary,
+		Description: values["JAVA_RUNTIME_VERSION"],
+	}, nil
+}
+
+// javaMajorVersion 解析 Java 主版本号，兼容 1.8.0_392 和 17.0.9 两种格式
+func javaMajorVersion(version string) int {
+	parts := strings.Split(version, ".")
+	if len(parts) > 1 && parts[0] == "1" {
+		parts = parts[1:]
+	}
+	major, _ := strconv.Atoi(extractNumber(parts[0]))
+	return major
+}
+
+// requiredJavaMajor 返回 IDE 需要的最低 Java 版本
+// 优先使用自带 JBR 的版本，没有自带运行时时按构建号推断
+func requiredJavaMajor(home string, info *productInfo) int {
+	if bundled, err := readJavaRuntime(filepath.Join(home, "jbr")); err == nil {
+		return bundled.Major
+	}
+
+	build, _ := strconv.Atoi(extractNumber(strings.Split(info.BuildNumber, ".")[0]))
+	// 2024.2（242）起需要 JBR 21
+	switch {
+	case build >= 242:
+		return 21
+	case build >= 222:
+		return 17
+	case build >= 203:
+		return 11
+	default:
+		return 8
+	}
+}
+
+// runtimeSearchRoots 返回当前平台上 JDK 的常见安装位置
+func runtimeSearchRoots() []string {
+	home, _ := os.UserHomeDir()
+	roots := []string{filepath.Join(home, ".jdks")}
+
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			if dir := os.Getenv(env); dir != "" {
+				roots = append(roots,
+					filepath.Join(dir, "Java"),
+					filepath.Join(dir, "Eclipse Adoptium"),
+					filepath.Join(dir, "Microsoft"),
+					filepath.Join(dir, "Zulu"),
+					filepath.Join(dir, "Amazon Corretto"))
+			}
+		}
+	case "darwin":
+		roots = append(roots,
+			"/Library/Java/JavaVirtualMachines",
+			filepath.Join(home, "Library", "Java", "JavaVirtualMachines"))
+	default:
+		roots = append(roots,
+			"/usr/lib/jvm",
+			"/usr/java",
+			"/opt",
+			filepath.Join(home, ".sdkman", "candidates", "java"))
+	}
+	return roots
+}
+
+// discoverRuntimes 在给定目录的直接子目录中查找 JDK，同时包含 JAVA_HOME 和额外路径
+func discoverRuntimes(roots []string, extra []string) []JavaRuntime {
+	var candidates []string
+	for _, root := range roots {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				candidates = append(candidates, filepath.Join(root, entry.Name()))
+			}
+		}
+	}
+	if javaHome := os.Getenv("JAVA_HOME"); javaHome != "" {
+		candidates = append(candidates, javaHome)
+	}
+	candidates = append(candidates, extra...)
+
+	var found []JavaRuntime
+	for _, candidate := range candidates {
+		rt, err := readJavaRuntime(candidate)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(found, func(existing JavaRuntime) bool { return existing.Path == rt.Path }) {
+			continue
+		}
+		found = append(found, *rt)
+	}
+
+	slices.SortFunc(found, func(a, b JavaRuntime) int {
+		if a.Major != b.Major {
+			return b.Major - a.Major
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return found
+}
+
+// jdkFileName 返回 IDE 在配置目录中读取的运行时选择文件名，如 idea.jdk 或 Windows 上的 idea64.exe.jdk
+func jdkFileName(product ProductDefinition, goos string) string {
+	base := strings.TrimSuffix(product.Launchers[0], ".sh")
+	if goos == "windows" {
+		return base + "64.exe.jdk"
+	}
+	return base + ".jdk"
+}
+
+// runtimeContext 汇总设置运行时所需的安装信息
+type runtimeContext struct {
+	home    string
+	info    *productInfo
+	product ProductDefinition
+	jdkFile string
+}
+
+// loadRuntimeContext 读取安装信息并确定 .jdk 文件位置
+func loadRuntimeContext(installPath string) (*runtimeContext, error) {
+	home := installationHome(installPath)
+	info, err := readProductInfo(home)
+	if err != nil {
+		return nil, err
+	}
+	product, ok := productByCode(info.ProductCode)
+	if !ok || len(product.Launchers) == 0 {
+		return nil, fmt.Errorf("%w: 未知产品 %s", ErrInvalidRuntime, info.ProductCode)
+	}
+	configDir, err := ideConfigDir(home)
+	if err != nil {
+		return nil, err
+	}
+	return &runtimeContext{
+		home:    home,
+		info:    info,
+		product: product,
+		jdkFile: filepath.Join(configDir, jdkFileName(product, runtime.GOOS)),
+	}, nil
+}
+
+// ListRuntimes 返回本机上找到的 JDK 和 JBR（包括已保存安装自带的 JBR）
+func (c *ConfigService) ListRuntimes() []JavaRuntime {
+	var extra []string
+	for _, inst := range c.currentSettings().Installations {
+		extra = append(extra, filepath.Join(inst.Path, "jbr"))
+	}
+	found := discoverRuntimes(runtimeSearchRoots(), extra)
+	c.logger.Info("运行时扫描完成", slog.Int("count", len(found)))
+	return found
+}
+
+// GetRuntime 返回 IDE 自带的运行时、通过 .jdk 文件选择的运行时以及环境变量覆盖情况
+func (c *ConfigService) GetRuntime(installPath string) (RuntimeStatus, error) {
+	ctx, err := loadRuntimeContext(installPath)
+	if err != nil {
+		return RuntimeStatus{}, err
+	}
+
+	status := RuntimeStatus{
+		JDKFile:       ctx.jdkFile,
+		RequiredMajor: requiredJavaMajor(ctx.home, ctx.info),
+	}
+	if bundled, err := readJavaRuntime(filepath.Join(ctx.home, "jbr")); err == nil {
+		status.Bundled = bundled
+	}
+	if content, err := os.ReadFile(ctx.jdkFile); err == nil {
+		status.SelectedPath = strings.TrimSpace(string(content))
+		if selected, err := readJavaRuntime(status.SelectedPath); err == nil {
+			status.Selected = selected
+		}
+	}
+	if len(ctx.product.EnvVars) > 0 {
+		status.EnvVar = strings.TrimSuffix(ctx.product.EnvVars[0], "_VM_OPTIONS") + "_JDK"
+		status.EnvOverride = os.Getenv(status.EnvVar)
+	}
+	return status, nil
+}
+
+// SetRuntime 验证 JDK 版本满足 IDE 要求后，将其路径写入配置目录中的 .jdk 文件
+func (c *ConfigService) SetRuntime(installPath, jdkPath string) (RuntimeStatus, error) {
+	ctx, err := loadRuntimeContext(installPath)
+	if err != nil {
+		return RuntimeStatus{}, err
+	}
+
+	rt, err := readJavaRuntime(jdkPath)
+	if err != nil {
+		return RuntimeStatus{}, err
+	}
+	if required := requiredJavaMajor(ctx.home, ctx.info); rt.Major < required {
+		return RuntimeStatus{}, fmt.Errorf("%w: %s 需要 Java %d 或更高版本，所选运行时为 Java %s",
+			ErrRuntimeIncompatible, ctx.info.displayName(), required, rt.Version)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(ctx.jdkFile), 0755); err != nil {
+		return RuntimeStatus{}, fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	if _, err := writeFileWithBackup(ctx.jdkFile, []byte(rt.Path+"\n"), 0644); err != nil {
+		return RuntimeStatus{}, err
+	}
+
+	c.logger.Info("已设置 IDE 运行时",
+		slog.String("file", ctx.jdkFile),
+		slog.String("runtime", rt.Path),
+		slog.String("version", rt.Version))
+	status, err := c.GetRuntime(installPath)
+	_, status.RestartRequired = restartNotice(ctx.home)
+	return status, err
+}
+
+// ResetRuntime 删除 .jdk 文件，恢复使用 IDE 自带的运行时（删除前会备份）
+func (c *ConfigService) ResetRuntime(installPath string) (RuntimeStatus, error) {
+	ctx, err := loadRuntimeContext(installPath)
+	if err != nil {
+		return RuntimeStatus{}, err
+	}
+
+	if fileExists(ctx.jdkFile) {
+		if _, err := backupFile(ctx.jdkFile); err != nil {
+			return RuntimeStatus{}, err
+		}
+		if err := os.Remove(ctx.jdkFile); err != nil {
+			return RuntimeStatus{}, fmt.Errorf("删除 .jdk 文件失败: %w", err)
+		}
+		c.logger.Info("已恢复 IDE 自带运行时", slog.String("file", ctx.jdkFile))
+	}
+	status, err := c.GetRuntime(installPath)
+	_, status.RestartRequired = restartNotice(ctx.home)
+	return status, err
+}
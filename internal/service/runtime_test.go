@@ -0,0 +1,114 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createTestRuntime 创建带 release 文件和 java 可执行文件的假 JDK
+func createTestRuntime(t *testing.T, home, version, vendor string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Join(home, "bin"), 0755); err != nil {
+		t.Fatalf("创建目录失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(home, "bin", "java"), nil, 0755); err != nil {
+		t.Fatalf("写入 java 失败: %v", err)
+	}
+	release := "IMPLEMENTOR=\"" + vendor + "\"\nJAVA_VERSION=\"" + version + "\"\nOS_ARCH=\"x86_64\"\n"
+	if err := os.WriteFile(filepath.Join(home, "release"), []byte(release), 0644); err != nil {
+		t.Fatalf("写入 release 失败: %v", err)
+	}
+}
+
+// TestJavaMajorVersion 测试 Java 主版本号解析
+func TestJavaMajorVersion(t *testing.T) {
+	tests := map[string]int{
+		"1.8.0_392": 8,
+		"11.0.21":   11,
+		"17":        17,
+		"21.0.5":    21,
+		"22-ea":     22,
+	}
+	for version, want := range tests {
+		if got := javaMajorVersion(version); got != want {
+			t.Errorf("javaMajorVersion(%q) = %d, want %d", version, got, want)
+		}
+	}
+}
+
+// TestRequiredJavaMajor 测试没有自带 JBR 时按构建号推断所需的 Java 版本
+func TestRequiredJavaMajor(t *testing.T) {
+	home := t.TempDir()
+	tests := map[string]int{
+		"201.8743": 8,
+		"213.7172": 11,
+		"233.1500": 17,
+		"241.1800": 17,
+		"242.2000": 21,
+		"251.1000": 21,
+	}
+	for build, want := range tests {
+		if got := requiredJavaMajor(home, &productInfo{BuildNumber: build}); got != want {
+			t.Errorf("requiredJavaMajor(%q) = %d, want %d", build, got, want)
+		}
+	}
+}
+
+// TestSetRuntime 测试设置、校验和重置 IDE 运行时
+func TestSetRuntime(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("HOME", base)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(base, "config"))
+
+	home := filepath.Join(base, "goland")
+	createTestInstallation(t, home, "GO", "241.1", "-Xmx2048m\n")
+	createTestRuntime(t, filepath.Join(home, "jbr"), "17.0.11", "JetBrains s.r.o.")
+
+	oldJDK := filepath.Join(base, "jdks", "jdk-11")
+	newJDK := filepath.Join(base, "jdks", "temurin-21")
+	createTestRuntime(t, oldJDK, "11.0.21", "Eclipse Adoptium")
+	createTestRuntime(t, newJDK, "21.0.5", "Eclipse Adoptium")
+
+	found := discoverRuntimes([]string{filepath.Join(base, "jdks")}, []string{filepath.Join(home, "jbr")})
+	if len(found) != 3 || found[0].Major != 21 || !found[1].IsJBR {
+		t.Fatalf("运行时扫描结果不正确: %+v", found)
+	}
+
+	svc := NewConfigService(WithSettingsPath(filepath.Join(base, settingsFileName)))
+	if _, err := svc.SetRuntime(home, oldJDK); !errors.Is(err, ErrRuntimeIncompatible) {
+		t.Fatalf("Java 11 应不满足要求，得到 %v", err)
+	}
+	if _, err := svc.SetRuntime(home, base); !errors.Is(err, ErrInvalidRuntime) {
+		t.Fatalf("非 JDK 目录应返回 ErrInvalidRuntime，得到 %v", err)
+	}
+
+	status, err := svc.SetRuntime(home, newJDK)
+	if err != nil {
+		t.Fatalf("设置运行时失败: %v", err)
+	}
+	if filepath.Base(status.JDKFile) != "goland.jdk" || status.RequiredMajor != 17 {
+		t.Errorf("运行时状态不正确: %+v", status)
+	}
+	if status.Selected == nil || status.Selected.Path != newJDK || status.Bundled == nil {
+		t.Errorf("应识别所选和自带运行时: %+v", status)
+	}
+	content, err := os.ReadFile(status.JDKFile)
+	if err != nil || strings.TrimSpace(string(content)) != newJDK {
+		t.Errorf(".jdk 文件内容不正确: %q, %v", content, err)
+	}
+
+	status, err = svc.ResetRuntime(home)
+	if err != nil {
+		t.Fatalf("重置运行时失败: %v", err)
+	}
+	if status.SelectedPath != "" {
+		t.Errorf("重置后不应再有所选运行时: %+v", status)
+	}
+	if _, err := os.Stat(status.JDKFile); !os.IsNotExist(err) {
+		t.Errorf("重置后 .jdk 文件应被删除: %v", err)
+	}
+}
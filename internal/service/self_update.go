@@ -0,0 +1,317 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HealthCheckArg 是自更新健康检查使用的命令行参数
+// main 包收到该参数时只做最小化自检并以退出码 0 结束，不创建窗口
+const HealthCheckArg = "--health-check"
+
+const (
+	healthCheckTimeout     = 15 * time.Second
+	maxUnconfirmedLaunches = 2
+	pendingUpdateFileName  = "pending-update.json"
+	newBinarySuffix        = ".new"
+	backupBinarySuffix     = ".old"
+	appBinaryName          = "intellijapp"
+)
+
+// pendingUpdate 记录已替换但尚未确认启动成功的更新
+// 新版本连续 maxUnconfirmedLaunches 次启动都未确认时回滚到旧版本
+type pendingUpdate struct {
+	Version  string `json:"version"`
+	Target   string `json:"target"`
+	Backup   string `json:"backup"`
+	Launches int    `json:"launches"`
+}
+
+// ApplyUpdate 下载并安装最新版本，成功后重新启动应用
+// 仅支持 Linux 的 AppImage 和 tar.gz 安装方式，其他安装方式请使用系统包管理器或安装程序更新
+func (c *ConfigService) ApplyUpdate() (string, error) {
+	if !selfUpdateSupported() {
+		return "", ErrSelfUpdateUnsupported
+	}
+
+	result, err := c.DownloadUpdate()
+	if err != nil {
+		return "", err
+	}
+	if compareVersions(result.Version, Version) <= 0 {
+		return "", fmt.Errorf("%w: %s", ErrNoNewerRelease, result.Version)
+	}
+
+	c.logger.Info("开始安装更新",
+		slog.String("version", result.Version),
+		slog.String("file", result.FilePath))
+
+	target, err := installDownloadedUpdate(result, c.logger)
+	if err != nil {
+		c.logger.Error("安装更新失败", slog.Any("error", err))
+		return "", err
+	}
+
+	if c.quit == nil {
+		c.logger.Info("更新已安装，未设置退出函数，需要手动重启", slog.String("target", target))
+		return fmt.Sprintf("已更新到 %s，请重启应用", result.Version), nil
+	}
+
+	c.logger.Info("更新已安装，准备重新启动", slog.String("target", target))
+	if err := relaunch(target); err != nil {
+		return "", fmt.Errorf("更新已安装，但重新启动失败，请手动重启应用: %w", err)
+	}
+
+	// 新程序已启动，当前进程通过应用的退出流程结束，停止后台监视、关闭日志文件并释放设置锁
+	// 在新 goroutine 中退出，使本次调用的结果能先返回给前端
+	go c.quit()
+	return fmt.Sprintf("已更新到 %s，正在重新启动", result.Version), nil
+}
+
+// CheckPendingUpdate 在应用启动早期调用，处理上一次自更新的确认与回滚
+// 新版本多次启动均未通过健康确认时，恢复旧版本，启动旧版本后退出当前进程
+func CheckPendingUpdate(logger *slog.Logger) {
+	markerPath, err := pendingUpdatePath()
+	if err != nil {
+		return
+	}
+
+	pending, err := loadPendingUpdate(markerPath)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			logger.Warn("读取待确认更新失败", slog.Any("error", err))
+		}
+		return
+	}
+
+	if pending.Launches >= maxUnconfirmedLaunches {
+		logger.Error("新版本多次启动未通过健康检查，回滚到旧版本",
+			slog.String("version", pending.Version),
+			slog.Int("launches", pending.Launches))
+		if err := rollbackBinary(pending.Target, pending.Backup); err != nil {
+			logger.Error("回滚失败", slog.Any("error", err))
+			return
+		}
+		_ = os.Remove(markerPath)
+		// 此时尚未创建应用和服务，启动旧版本后直接退出即可
+		if err := relaunch(pending.Target); err != nil {
+			logger.Error("回滚后重新启动失败", slog.Any("error", err))
+			return
+		}
+		os.Exit(0)
+	}
+
+	pending.Launches++
+	if err := savePendingUpdate(markerPath, pending); err != nil {
+		logger.Warn("更新启动计数失败", slog.Any("error", err))
+	}
+}
+
+// ConfirmPendingUpdate 在应用成功启动后调用，确认新版本可用并删除旧版本备份
+func ConfirmPendingUpdate(logger *slog.Logger) {
+	markerPath, err := pendingUpdatePath()
+	if err != nil {
+		return
+	}
+
+	pending, err := loadPendingUpdate(markerPath)
+	if err != nil {
+		return
+	}
+
+	if err := os.Remove(pending.Backup); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("删除旧版本备份失败", slog.Any("error", err))
+	}
+	if err := os.Remove(markerPath); err != nil {
+		logger.Warn("删除待确认更新记录失败", slog.Any("error", err))
+		return
+	}
+
+	logger.Info("更新已确认", slog.String("version", pending.Version))
+}
+
+// pendingUpdatePath 返回待确认更新记录的路径
+func pendingUpdatePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取缓存目录: %w", err)
+	}
+	return filepath.Join(cacheDir, appDataDirName, downloadDirName, pendingUpdateFileName), nil
+}
+
+// loadPendingUpdate 读取待确认更新记录
+func loadPendingUpdate(path string) (pendingUpdate, error) {
+	var pending pendingUpdate
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pending, err
+	}
+	if err := json.Unmarshal(data, &pending); err != nil {
+		return pending, fmt.Errorf("解析待确认更新记录失败: %w", err)
+	}
+	return pending, nil
+}
+
+// savePendingUpdate 保存待确认更新记录
+func savePendingUpdate(path string, pending pendingUpdate) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pending, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// runHealthCheck 以 HealthCheckArg 参数运行新版本程序，确认其能正常启动
+func runHealthCheck(binary string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binary, HealthCheckArg).CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("%w: 超时", ErrHealthCheckFailed)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v: %s", ErrHealthCheckFailed, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// extractBinaryFromTarGz 从 tar.gz 安装包中提取应用程序到 dest
+func extractBinaryFromTarGz(archivePath, dest string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("打开安装包失败: %w", err)
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("解压安装包失败: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return fmt.Errorf("安装包中未找到 %s 程序", appBinaryName)
+		}
+		if err != nil {
+			return fmt.Errorf("读取安装包失败: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || filepath.Base(header.Name) != appBinaryName {
+			continue
+		}
+
+		out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+		if err != nil {
+			return fmt.Errorf("创建新版本文件失败: %w", err)
+		}
+		if _, err := io.Copy(out, reader); err != nil {
+			out.Close()
+			return fmt.Errorf("写入新版本文件失败: %w", err)
+		}
+		return out.Close()
+	}
+}
+
+// copyExecutable 将下载的程序复制到 dest 并设置可执行权限
+func copyExecutable(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开安装包失败: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("创建新版本文件失败: %w", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("写入新版本文件失败: %w", err)
+	}
+	return out.Close()
+}
+
+// swapBinary 将 newPath 原子替换到 target，原文件保存为 backup
+// 两次 rename 位于同一目录，任一步失败都会恢复原文件
+func swapBinary(target, newPath, backup string) error {
+	if err := os.Rename(target, backup); err != nil {
+		return fmt.Errorf("备份当前版本失败: %w", err)
+	}
+	if err := os.Rename(newPath, target); err != nil {
+		if restoreErr := os.Rename(backup, target); restoreErr != nil {
+			return fmt.Errorf("替换新版本失败: %w（恢复旧版本也失败: %v）", err, restoreErr)
+		}
+		return fmt.Errorf("替换新版本失败: %w", err)
+	}
+	return nil
+}
+
+// rollbackBinary 使用 backup 恢复 target
+func rollbackBinary(target, backup string) error {
+	if _, err := os.Stat(backup); err != nil {
+		return fmt.Errorf("旧版本备份不可用: %w", err)
+	}
+	if err := os.Rename(backup, target); err != nil {
+		return fmt.Errorf("恢复旧版本失败: %w", err)
+	}
+	return nil
+}
+
+// installBinary 将已下载的安装包安装到 target
+// 流程：准备新文件 -> 健康检查 -> 原子替换 -> 再次健康检查（失败则回滚）-> 记录待确认更新
+func installBinary(result DownloadResult, target string, extract func(src, dest string) error, logger *slog.Logger) error {
+	newPath := target + newBinarySuffix
+	backup := target + backupBinarySuffix
+
+	if err := extract(result.FilePath, newPath); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	if err := runHealthCheck(newPath); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	if err := swapBinary(target, newPath, backup); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	if err := runHealthCheck(target); err != nil {
+		logger.Error("替换后的新版本健康检查失败，回滚", slog.Any("error", err))
+		if rollbackErr := rollbackBinary(target, backup); rollbackErr != nil {
+			return fmt.Errorf("%w（回滚失败: %v）", err, rollbackErr)
+		}
+		return err
+	}
+
+	markerPath, err := pendingUpdatePath()
+	if err != nil {
+		return err
+	}
+	return savePendingUpdate(markerPath, pendingUpdate{
+		Version: result.Version,
+		Target:  target,
+		Backup:  backup,
+	})
+}
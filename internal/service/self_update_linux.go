@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package service
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// selfUpdateSupported 判断当前安装方式是否支持应用内自更新
+// deb/rpm 安装的文件由系统包管理器维护，不应被直接替换
+func selfUpdateSupported() bool {
+	kind := linuxInstallKind()
+	return kind == linuxInstallAppImage || kind == linuxInstallTarball
+}
+
+// installDownloadedUpdate 将下载的 AppImage 或 tar.gz 安装包安装到当前程序所在位置
+// 返回安装后的程序路径
+func installDownloadedUpdate(result DownloadResult, logger *slog.Logger) (string, error) {
+	name := strings.ToLower(result.AssetName)
+
+	switch linuxInstallKind() {
+	case linuxInstallAppImage:
+		if !strings.HasSuffix(name, ".appimage") {
+			return "", fmt.Errorf("%w: AppImage 安装方式需要 AppImage 安装包，实际为 %s", ErrSelfUpdateUnsupported, result.AssetName)
+		}
+		target := os.Getenv("APPIMAGE")
+		return target, installBinary(result, target, copyExecutable, logger)
+
+	case linuxInstallTarball:
+		if !strings.HasSuffix(name, ".tar.gz") {
+			return "", fmt.Errorf("%w: tar.gz 安装方式需要 tar.gz 安装包，实际为 %s", ErrSelfUpdateUnsupported, result.AssetName)
+		}
+		exe, err := os.Executable()
+		if err != nil {
+			return "", fmt.Errorf("无法获取程序路径: %w", err)
+		}
+		target, err := filepath.EvalSymlinks(exe)
+		if err != nil {
+			return "", fmt.Errorf("无法解析程序路径: %w", err)
+		}
+		return target, installBinary(result, target, extractBinaryFromTarGz, logger)
+
+	default:
+		return "", ErrSelfUpdateUnsupported
+	}
+}
+
+// relaunch 以原有参数和环境变量在新会话中启动新程序，不等待其退出
+// 不使用 execve 替换当前进程，调用方随后通过正常的退出流程结束当前进程
+func relaunch(binary string) error {
+	cmd := exec.Command(binary, os.Args[1:]...)
+	cmd.Env = os.Environ()
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+// +build !linux
+
+package service
+
+import (
+	"log/slog"
+)
+
+// selfUpdateSupported 非 Linux 平台通过安装程序更新，不支持应用内替换
+func selfUpdateSupported() bool {
+	return false
+}
+
+// installDownloadedUpdate 非 Linux 平台不支持应用内替换
+func installDownloadedUpdate(result DownloadResult, logger *slog.Logger) (string, error) {
+	return "", ErrSelfUpdateUnsupported
+}
+
+// relaunch 非 Linux 平台不支持自动重新启动
+func relaunch(binary string) error {
+	return ErrSelfUpdateUnsupported
+}
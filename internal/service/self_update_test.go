@@ -0,0 +1,110 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestTarGz 创建包含指定文件的 tar.gz 安装包
+func writeTestTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("无法创建安装包: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("写入 tar 头失败: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("写入 tar 内容失败: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("关闭 tar 失败: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("关闭 gzip 失败: %v", err)
+	}
+}
+
+// TestExtractBinaryFromTarGz 测试从 tar.gz 安装包提取程序
+func TestExtractBinaryFromTarGz(t *testing.T) {
+	tempDir := t.TempDir()
+	archive := filepath.Join(tempDir, "intellijapp-2.1.0-linux-amd64.tar.gz")
+	writeTestTarGz(t, archive, map[string]string{
+		"README.md":               "readme",
+		"intellijapp/intellijapp": "new-binary",
+	})
+
+	dest := filepath.Join(tempDir, "intellijapp.new")
+	if err := extractBinaryFromTarGz(archive, dest); err != nil {
+		t.Fatalf("提取失败: %v", err)
+	}
+
+	content, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("无法读取提取的文件: %v", err)
+	}
+	if string(content) != "new-binary" {
+		t.Errorf("提取的内容不正确: %q", content)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatalf("无法获取文件信息: %v", err)
+	}
+	if info.Mode().Perm()&0100 == 0 {
+		t.Error("提取的程序没有可执行权限")
+	}
+
+	missing := filepath.Join(tempDir, "empty.tar.gz")
+	writeTestTarGz(t, missing, map[string]string{"README.md": "readme"})
+	if err := extractBinaryFromTarGz(missing, dest); err == nil {
+		t.Error("安装包中没有程序时应返回错误")
+	}
+}
+
+// TestSwapAndRollbackBinary 测试替换与回滚程序文件
+func TestSwapAndRollbackBinary(t *testing.T) {
+	tempDir := t.TempDir()
+	target := filepath.Join(tempDir, "intellijapp")
+	newPath := target + newBinarySuffix
+	backup := target + backupBinarySuffix
+
+	if err := os.WriteFile(target, []byte("old"), 0755); err != nil {
+		t.Fatalf("无法创建测试文件: %v", err)
+	}
+	if err := os.WriteFile(newPath, []byte("new"), 0755); err != nil {
+		t.Fatalf("无法创建测试文件: %v", err)
+	}
+
+	if err := swapBinary(target, newPath, backup); err != nil {
+		t.Fatalf("替换失败: %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "new" {
+		t.Errorf("替换后内容不正确: %q", content)
+	}
+	if content, _ := os.ReadFile(backup); string(content) != "old" {
+		t.Errorf("备份内容不正确: %q", content)
+	}
+
+	if err := rollbackBinary(target, backup); err != nil {
+		t.Fatalf("回滚失败: %v", err)
+	}
+	if content, _ := os.ReadFile(target); string(content) != "old" {
+		t.Errorf("回滚后内容不正确: %q", content)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Error("回滚后备份文件应被移除")
+	}
+}
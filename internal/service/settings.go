@@ -0,0 +1,429 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"time"
+)
+
+const (
+	settingsFileName      = "settings.json"
+	settingsLockSuffix    = ".lock"
+	settingsSchemaVersion = 5
+	maxRecentPaths        = 10
+	fileLockTimeout       = 5 * time.Second
+	fileLockRetryInterval = 50 * time.Millisecond
+)
+
+// AppSettings 保存需要在多次启动之间保留的应用设置
+// 设置文件带有 schemaVersion，旧版本文件在读取时按 settingsMigrations 依次升级
+type AppSettings struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	General       GeneralSettings   `json:"general"`
+	Paths         PathSettings      `json:"paths"`
+	Update        UpdatePreferences `json:"update"`
+	Network       NetworkSettings   `json:"network"`
+	Watcher       WatcherSettings   `json:"watcher"`
+	Logging       LoggingSettings   `json:"logging"`
+
+	Installations []SavedInstallation `json:"installations"`
+	Relocations   []RelocationRecord  `json:"relocations"`
+}
+
+// GeneralSettings 保存界面语言和主题
+type GeneralSettings struct {
+	Language string `json:"language"`
+	Theme    string `json:"theme"`
+}
+
+// PathSettings 保存最近使用的路径
+type PathSettings struct {
+	LastInstallPath    string   `json:"lastInstallPath"`
+	LastConfigPath     string   `json:"lastConfigPath"`
+	RecentInstallPaths []string `json:"recentInstallPaths"`
+}
+
+// UpdatePreferences 保存检查更新相关的偏好
+type UpdatePreferences struct {
+	CheckOnStartup bool   `json:"checkOnStartup"`
+	SkippedVersion string `json:"skippedVersion"`
+}
+
+// WatcherSettings 保存后台监视 vmoptions 文件的偏好
+type WatcherSettings struct {
+	Enabled     bool `json:"enabled"`
+	AutoReapply bool `json:"autoReapply"`
+}
+
+// LoggingSettings 保存日志级别和日志文件格式，空字符串表示默认值（info、text）
+type LoggingSettings struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// settingsMigration 将原始设置从版本 n 升级到 n+1
+type settingsMigration func(raw map[string]any) error
+
+// settingsMigrations 按版本顺序排列的迁移函数，下标 i 负责从版本 i 升级到 i+1
+var settingsMigrations = []settingsMigration{
+	// v0 -> v1：v0 只包含网络设置，补充界面、路径和更新偏好的默认值
+	func(raw map[string]any) error {
+		defaults := defaultSettings()
+		for key, value := range map[string]any{
+			"general": defaults.General,
+			"paths":   defaults.Paths,
+			"update":  defaults.Update,
+		} {
+			if _, ok := raw[key]; !ok {
+				raw[key] = value
+			}
+		}
+		return nil
+	},
+	// v1 -> v2：新增已保存的 IDE 安装列表
+	func(raw map[string]any) error {
+		if _, ok := raw["installations"]; !ok {
+			raw["installations"] = []any{}
+		}
+		return nil
+	},
+	// v2 -> v3：新增 IDE 目录迁移记录
+	func(raw map[string]any) error {
+		if _, ok := raw["relocations"]; !ok {
+			raw["relocations"] = []any{}
+		}
+		return nil
+	},
+	// v3 -> v4：新增 vmoptions 后台监视偏好，默认关闭
+	func(raw map[string]any) error {
+		if _, ok := raw["watcher"]; !ok {
+			raw["watcher"] = WatcherSettings{}
+		}
+		return nil
+	},
+	// v4 -> v5：新增日志设置
+	func(raw map[string]any) error {
+		if _, ok := raw["logging"]; !ok {
+			raw["logging"] = LoggingSettings{}
+		}
+		return nil
+	},
+}
+
+// 可选的界面语言和主题，空字符串表示跟随默认值
+var (
+	supportedLanguages = []string{"", "zh-CN", "en-US"}
+	supportedThemes    = []string{"", "light", "dark"}
+)
+
+// defaultSettings 返回默认设置
+func defaultSettings() AppSettings {
+	return AppSettings{
+		SchemaVersion: settingsSchemaVersion,
+		General:       GeneralSettings{Theme: "dark"},
+		Update:        UpdatePreferences{CheckOnStartup: true},
+		Network:       defaultNetworkSettings(),
+		Installations: []SavedInstallation{},
+		Relocations:   []RelocationRecord{},
+	}
+}
+
+// settingsFilePath 返回设置文件路径（Linux 上遵循 XDG_CONFIG_HOME）
+func settingsFilePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取配置目录: %w", err)
+	}
+	return filepath.Join(configDir, appDataDirName, settingsFileName), nil
+}
+
+// loadSettings 读取设置文件并升级到当前版本，文件不存在时返回默认设置
+// 返回的 bool 表示文件是否经过迁移，需要写回磁盘
+func loadSettings(path string) (AppSettings, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return defaultSettings(), false, nil
+		}
+		return defaultSettings(), false, fmt.Errorf("读取设置文件失败: %w", err)
+	}
+
+	return parseSettings(data)
+}
+
+// parseSettings 解析设置内容并执行必要的迁移
+func parseSettings(data []byte) (AppSettings, bool, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return defaultSettings(), false, fmt.Errorf("解析设置文件失败: %w", err)
+	}
+
+	version := 0
+	if v, ok := raw["schemaVersion"].(float64); ok {
+		version = int(v)
+	}
+	if version > settingsSchemaVersion {
+		return defaultSettings(), false, fmt.Errorf("%w: 文件版本 %d，当前支持 %d", ErrSettingsTooNew, version, settingsSchemaVersion)
+	}
+
+	migrated := version < settingsSchemaVersion
+	for v := version; v < settingsSchemaVersion; v++ {
+		if err := settingsMigrations[v](raw); err != nil {
+			return defaultSettings(), false, fmt.Errorf("设置从版本 %d 升级失败: %w", v, err)
+		}
+	}
+	raw["schemaVersion"] = settingsSchemaVersion
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return defaultSettings(), false, fmt.Errorf("序列化设置失败: %w", err)
+	}
+
+	settings := defaultSettings()
+	if err := json.Unmarshal(upgraded, &settings); err != nil {
+		return defaultSettings(), false, fmt.Errorf("解析设置文件失败: %w", err)
+	}
+
+	settings.Network = settings.Network.withDefaults()
+	return settings, migrated, nil
+}
+
+// saveSettings 写入设置文件，先写临时文件再重命名，避免写入中断导致文件损坏
+func saveSettings(path string, settings AppSettings) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %w", err)
+	}
+
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化设置失败: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入设置文件失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("保存设置文件失败: %w", err)
+	}
+	return nil
+}
+
+// validate 校验用户可编辑的设置项
+func (s AppSettings) validate() (AppSettings, error) {
+	if !slices.Contains(supportedLanguages, s.General.Language) {
+		return s, fmt.Errorf("%w: 不支持的语言 %q", ErrInvalidSettings, s.General.Language)
+	}
+	if !slices.Contains(supportedThemes, s.General.Theme) {
+		return s, fmt.Errorf("%w: 不支持的主题 %q", ErrInvalidSettings, s.General.Theme)
+	}
+
+	if !slices.Contains(supportedLogLevels, s.Logging.Level) {
+		return s, fmt.Errorf("%w: 不支持的日志级别 %q", ErrInvalidSettings, s.Logging.Level)
+	}
+	if !slices.Contains(supportedLogFormats, s.Logging.Format) {
+		return s, fmt.Errorf("%w: 不支持的日志格式 %q", ErrInvalidSettings, s.Logging.Format)
+	}
+
+	network, err := s.Network.validate()
+	if err != nil {
+		return s, err
+	}
+	s.Network = network
+	return s, nil
+}
+
+// lockSettings 对设置文件加锁，首次运行时设置目录尚不存在，先创建目录再创建锁文件
+func (c *ConfigService) lockSettings() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(c.settingsPath), 0755); err != nil {
+		return nil, fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	return lockFile(c.settingsPath + settingsLockSuffix)
+}
+
+// initSettings 在启动时读取设置，旧版本文件升级后立即写回
+func (c *ConfigService) initSettings() {
+	if c.settingsPath == "" {
+		return
+	}
+
+	unlock, err := c.lockSettings()
+	if err != nil {
+		c.logger.Warn("设置文件加锁失败，以只读方式加载", slog.Any("error", err))
+	} else {
+		defer unlock()
+	}
+
+	settings, migrated, err := loadSettings(c.settingsPath)
+	if err != nil {
+		c.logger.Warn("读取设置失败，使用默认设置", slog.Any("error", err))
+		return
+	}
+
+	if migrated && unlock != nil {
+		if err := saveSettings(c.settingsPath, settings); err != nil {
+			c.logger.Warn("保存升级后的设置失败", slog.Any("error", err))
+		} else {
+			c.logger.Info("设置文件已升级", slog.Int("schemaVersion", settingsSchemaVersion))
+		}
+	}
+
+	c.settings = settings
+	c.settingsModTime = settingsModTime(c.settingsPath)
+}
+
+// settingsModTime 返回设置文件的修改时间，文件不存在时返回零值
+func settingsModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// refreshSettings 检测设置文件是否被其他实例修改，有变化时重新加载并推送变更事件
+func (c *ConfigService) refreshSettings() {
+	if c.settingsPath == "" {
+		return
+	}
+
+	modTime := settingsModTime(c.settingsPath)
+	c.settingsMu.RLock()
+	unchanged := modTime.Equal(c.settingsModTime)
+	c.settingsMu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	settings, _, err := loadSettings(c.settingsPath)
+	if err != nil {
+		c.logger.Warn("重新加载设置失败", slog.Any("error", err))
+		return
+	}
+
+	c.applySettings(settings, modTime)
+	c.logger.Debug("检测到设置文件变更，已重新加载")
+}
+
+// applySettings 替换内存中的设置并推送变更事件，网络设置变化时清除镜像探测缓存
+func (c *ConfigService) applySettings(settings AppSettings, modTime time.Time) {
+	c.settingsMu.Lock()
+	previous := c.settings
+	c.settings = settings
+	c.settingsModTime = modTime
+	c.settingsMu.Unlock()
+
+	if !reflect.DeepEqual(previous.Network, settings.Network) {
+		c.mirrors.reset(&http.Client{Transport: newTransport(settings.Network), Timeout: mirrorProbeTimeout})
+	}
+	c.emit(EventSettingsChanged, settings)
+}
+
+// updateSettings 在文件锁保护下读取最新设置、应用修改并写回
+// 始终基于磁盘上的最新内容修改，避免覆盖其他实例的更改
+func (c *ConfigService) updateSettings(mutate func(*AppSettings) error) (AppSettings, error) {
+	if c.settingsPath == "" {
+		return AppSettings{}, ErrSettingsUnavailable
+	}
+
+	unlock, err := c.lockSettings()
+	if err != nil {
+		return AppSettings{}, err
+	}
+	defer unlock()
+
+	settings, _, err := loadSettings(c.settingsPath)
+	if err != nil {
+		return AppSettings{}, err
+	}
+
+	if err := mutate(&settings); err != nil {
+		return AppSettings{}, err
+	}
+	settings.SchemaVersion = settingsSchemaVersion
+
+	if err := saveSettings(c.settingsPath, settings); err != nil {
+		return AppSettings{}, err
+	}
+
+	c.applySettings(settings, settingsModTime(c.settingsPath))
+	return settings, nil
+}
+
+// currentSettings 返回当前设置的副本
+func (c *ConfigService) currentSettings() AppSettings {
+	c.refreshSettings()
+	c.settingsMu.RLock()
+	defer c.settingsMu.RUnlock()
+	return c.settings
+}
+
+// recordRecentPaths 记录最近一次成功使用的安装路径和配置路径
+func (c *ConfigService) recordRecentPaths(installPath, configPath string) {
+	_, err := c.updateSettings(func(s *AppSettings) error {
+		s.Paths.LastInstallPath = installPath
+		if configPath != "" {
+			s.Paths.LastConfigPath = configPath
+		}
+
+		recent := slices.DeleteFunc(s.Paths.RecentInstallPaths, func(p string) bool { return p == installPath })
+		recent = append([]string{installPath}, recent...)
+		if len(recent) > maxRecentPaths {
+			recent = recent[:maxRecentPaths]
+		}
+		s.Paths.RecentInstallPaths = recent
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn("保存最近使用的路径失败", slog.Any("error", err))
+	}
+}
+
+// GetSettings 返回应用设置，其他实例修改设置文件后会自动重新加载
+func (c *ConfigService) GetSettings() AppSettings {
+	return c.currentSettings()
+}
+
+// UpdateSettings 校验并保存用户可编辑的设置（界面、路径、更新偏好和网络）
+// 保存成功后推送 EventSettingsChanged 事件
+func (c *ConfigService) UpdateSettings(settings AppSettings) (AppSettings, error) {
+	settings, err := settings.validate()
+	if err != nil {
+		return AppSettings{}, err
+	}
+
+	if err := c.validateNewAPIMirrors(settings.Network); err != nil {
+		return AppSettings{}, err
+	}
+
+	updated, err := c.updateSettings(func(s *AppSettings) error {
+		s.General = settings.General
+		s.Paths = settings.Paths
+		s.Update = settings.Update
+		s.Network = settings.Network
+		s.Watcher = settings.Watcher
+		s.Logging = settings.Logging
+		return nil
+	})
+	if err != nil {
+		c.logger.Error("保存设置失败", slog.Any("error", err))
+		return AppSettings{}, err
+	}
+	// 日志级别立即生效，日志格式在下次启动时生效
+	if level, err := parseLogLevel(updated.Logging.Level); err == nil {
+		c.logLevel.Set(level)
+	}
+	c.configureWatcher(updated.Watcher)
+
+	c.logger.Info("设置已更新")
+	return updated, nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestParseSettingsMigratesV0 测试旧版本（仅含网络设置）的设置文件升级
+func TestParseSettingsMigratesV0(t *testing.T) {
+	v0 := `{"network":{"webMirrors":["https://github.com"],"apiMirrors":["https://api.github.com"],"proxyMode":"none","proxyUrl":""}}`
+
+	settings, migrated, err := parseSettings([]byte(v0))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if !migrated {
+		t.Error("v0 设置应被标记为已迁移")
+	}
+	if settings.SchemaVersion != settingsSchemaVersion {
+		t.Errorf("版本号应升级为 %d，实际 %d", settingsSchemaVersion, settings.SchemaVersion)
+	}
+	if settings.Network.ProxyMode != ProxyModeNone {
+		t.Errorf("迁移后应保留原有网络设置，实际代理模式 %s", settings.Network.ProxyMode)
+	}
+	if !settings.Update.CheckOnStartup || settings.General.Theme != "dark" {
+		t.Errorf("迁移后应补充默认值: %+v", settings)
+	}
+}
+
+// TestParseSettingsRejectsNewerVersion 测试拒绝更新版本应用写入的设置文件
+func TestParseSettingsRejectsNewerVersion(t *testing.T) {
+	_, _, err := parseSettings([]byte(`{"schemaVersion": 99}`))
+	if !errors.Is(err, ErrSettingsTooNew) {
+		t.Errorf("期望 ErrSettingsTooNew，实际 %v", err)
+	}
+}
+
+// TestSettingsSharedBetweenInstances 测试多个实例共享同一设置文件
+func TestSettingsSharedBetweenInstances(t *testing.T) {
+	settingsPath := filepath.Join(t.TempDir(), settingsFileName)
+
+	var events []string
+	var mu sync.Mutex
+	emitter := func(name string, data any) {
+		mu.Lock()
+		events = append(events, name)
+		mu.Unlock()
+	}
+
+	first := NewConfigService(WithSettingsPath(settingsPath), WithEventEmitter(emitter))
+	second := NewConfigService(WithSettingsPath(settingsPath))
+
+	settings := first.GetSettings()
+	settings.General.Language = "en-US"
+	if _, err := first.UpdateSettings(settings); err != nil {
+		t.Fatalf("保存设置失败: %v", err)
+	}
+
+	if got := second.GetSettings().General.Language; got != "en-US" {
+		t.Errorf("另一个实例应读取到最新设置，实际语言 %q", got)
+	}
+
+	// 第二个实例基于磁盘上的最新内容修改，不会覆盖第一个实例的更改
+	second.recordRecentPaths("/opt/idea", "/opt/config")
+	merged := first.GetSettings()
+	if merged.General.Language != "en-US" || merged.Paths.LastInstallPath != "/opt/idea" {
+		t.Errorf("设置合并不正确: %+v", merged)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) < 2 {
+		t.Errorf("应推送设置变更事件，实际 %v", events)
+	}
+
+	invalid := first.GetSettings()
+	invalid.General.Theme = "purple"
+	if _, err := first.UpdateSettings(invalid); !errors.Is(err, ErrInvalidSettings) {
+		t.Errorf("无效主题应被拒绝，实际 %v", err)
+	}
+
+	if _, err := os.Stat(settingsPath + settingsLockSuffix); err != nil {
+		t.Errorf("应创建锁文件: %v", err)
+	}
+}
+
+// TestSettingsCreatesMissingDir 测试首次运行时设置目录不存在也能保存设置
+func TestSettingsCreatesMissingDir(t *testing.T) {
+	settingsPath := filepath.Join(t.TempDir(), "missing", "intellijapp", settingsFileName)
+	svc := NewConfigService(WithSettingsPath(settingsPath))
+
+	settings := svc.GetSettings()
+	settings.General.Language = "en-US"
+	if _, err := svc.UpdateSettings(settings); err != nil {
+		t.Fatalf("设置目录不存在时保存失败: %v", err)
+	}
+	if _, err := os.Stat(settingsPath); err != nil {
+		t.Errorf("应创建设置文件: %v", err)
+	}
+}
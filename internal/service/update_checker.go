@@ -40,14 +40,14 @@ const (
 	httpTimeout = 10 * time.Second
 )
 
-// GitHub 镜像站点列表（按优先级排序）
+// 默认的 GitHub 镜像站点列表（按优先级排序），用户可在网络设置中修改
 var githubMirrors = []string{
 	"https://github.com",      // 官方站点（最优先）
 	"https://2git.xyz",        // 镜像站点 1
 	"https://lgithub.xyz",     // 镜像站点 2
 }
 
-// GitHub API 镜像站点列表
+// 默认的 GitHub API 镜像站点列表
 var githubAPIMirrors = []string{
 	"https://api.github.com",  // 官方 API（最优先）
 }
@@ -67,16 +67,16 @@ type gitHubRelease struct {
 }
 
 // fetchLatestRelease 从 GitHub API 获取最新 Release 信息
-// 自动尝试官方 API 和镜像站点
-func fetchLatestRelease(logger *slog.Logger) (*ReleaseInfo, error) {
+// 按传入顺序依次尝试官方 API 和镜像站点
+func fetchLatestRelease(client *http.Client, apiMirrors []string, logger *slog.Logger) (*ReleaseInfo, error) {
 	var lastErr error
 
 	// 依次尝试每个 API 镜像
-	for i, apiBase := range githubAPIMirrors {
+	for i, apiBase := range apiMirrors {
 		url := fmt.Sprintf("%s/repos/%s/%s/releases/latest", apiBase, repoOwner, repoName)
 		logger.Debug("尝试 GitHub API", slog.String("url", apiBase), slog.Int("attempt", i+1))
 
-		release, err := fetchFromAPI(url, logger)
+		release, err := fetchFromAPI(client, url, logger)
 		if err == nil {
 			logger.Info("成功从 GitHub API 获取版本", slog.String("api", apiBase))
 			return release, nil
@@ -92,8 +92,7 @@ func fetchLatestRelease(logger *slog.Logger) (*ReleaseInfo, error) {
 }
 
 // fetchFromAPI 从指定的 API URL 获取 Release 信息
-func fetchFromAPI(url string, logger *slog.Logger) (*ReleaseInfo, error) {
-	client := &http.Client{Timeout: httpTimeout}
+func fetchFromAPI(client *http.Client, url string, logger *slog.Logger) (*ReleaseInfo, error) {
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("请求失败: %w", err)
@@ -140,29 +139,23 @@ func fetchFromAPI(url string, logger *slog.Logger) (*ReleaseInfo, error) {
 	return release, nil
 }
 
-// GetAccessibleGitHubMirror 测试并返回可访问的 GitHub 镜像站点
-// 依次测试所有镜像站点，返回第一个可访问的
+// GetAccessibleGitHubMirror 返回延迟最低的可访问 GitHub 镜像站点
+// 所有镜像并发探测，结果在 mirrorCacheTTL 内缓存
 func (c *ConfigService) GetAccessibleGitHubMirror() string {
-	client := &http.Client{Timeout: 10 * time.Second}
-
-	// 依次测试每个镜像站点
-	for _, mirror := range githubMirrors {
-		c.logger.Debug("测试 GitHub 镜像", slog.String("mirror", mirror))
-
-		resp, err := client.Head(mirror)
-		if err == nil && resp.StatusCode == http.StatusOK {
-			c.logger.Info("找到可访问的 GitHub 镜像", slog.String("mirror", mirror))
-			return mirror
-		}
-
-		c.logger.Debug("镜像站点不可访问",
-			slog.String("mirror", mirror),
-			slog.Any("error", err))
+	mirror, ok := bestMirror(c.GetMirrorStatus(), mirrorKindWeb)
+	if ok {
+		c.logger.Debug("使用 GitHub 镜像", slog.String("mirror", mirror))
+		return mirror
 	}
 
 	// 如果都不可访问，返回官方站点
 	c.logger.Warn("所有镜像站点均不可访问，返回官方站点")
-	return githubMirrors[0]
+	return c.networkSettings().WebMirrors[0]
+}
+
+// rankedAPIMirrors 返回按探测结果排序的 GitHub API 镜像
+func (c *ConfigService) rankedAPIMirrors() []string {
+	return rankedMirrors(c.GetMirrorStatus(), mirrorKindAPI)
 }
 
 // ConvertToAccessibleURL 将 GitHub URL 转换为可访问的镜像 URL
@@ -251,7 +244,7 @@ func extractNumber(part string) string {
 func (c *ConfigService) CheckForUpdates() (UpdateCheckResult, error) {
 	c.logger.Info("开始检查更新", slog.String("currentVersion", Version))
 
-	release, err := fetchLatestRelease(c.logger)
+	release, err := fetchLatestRelease(c.httpClient(), c.rankedAPIMirrors(), c.logger)
 	if err != nil {
 		c.logger.Error("检查更新失败", slog.Any("error", err))
 		return UpdateCheckResult{HasUpdate: false, Release: nil}, err
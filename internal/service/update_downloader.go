@@ -0,0 +1,473 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DownloadProgress 保存下载进度，通过 EventUpdateDownloadProgress 事件推送给前端
+type DownloadProgress struct {
+	AssetName  string  `json:"assetName"`
+	Downloaded int64   `json:"downloaded"`
+	Total      int64   `json:"total"`
+	Percent    float64 `json:"percent"`
+}
+
+// DownloadResult 保存已下载并校验通过的安装包信息
+type DownloadResult struct {
+	Version   string `json:"version"`
+	AssetName string `json:"assetName"`
+	FilePath  string `json:"filePath"`
+	Size      int64  `json:"size"`
+	SHA256    string `json:"sha256"`
+	Verified  bool   `json:"verified"`
+}
+
+// Release 中发布的校验和文件名（按优先级排序）
+var checksumAssetNames = []string{"checksums.txt", "SHA256SUMS", "sha256sums.txt"}
+
+// 不同平台架构在安装包文件名中可能出现的别名
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+}
+
+const (
+	partFileSuffix        = ".part"
+	progressEmitInterval  = 200 * time.Millisecond
+	downloadHeaderTimeout = 30 * time.Second
+	downloadDirName       = "updates"
+	appDataDirName        = "intellijapp"
+	linuxInstallAppImage  = "appimage"
+	linuxInstallDeb       = "deb"
+	linuxInstallRPM       = "rpm"
+	linuxInstallTarball   = "tarball"
+)
+
+// linuxInstallKind 推断当前 Linux 程序的安装方式，用于选择同类型的安装包
+func linuxInstallKind() string {
+	if os.Getenv("APPIMAGE") != "" {
+		return linuxInstallAppImage
+	}
+
+	exe, err := os.Executable()
+	if err != nil || !strings.HasPrefix(exe, "/usr/") {
+		return linuxInstallTarball
+	}
+
+	// 安装在 /usr 下说明来自系统包管理器
+	if _, err := os.Stat("/var/lib/dpkg/info/intellijapp.list"); err == nil {
+		return linuxInstallDeb
+	}
+	if _, err := os.Stat("/usr/bin/rpm"); err == nil {
+		return linuxInstallRPM
+	}
+	return linuxInstallDeb
+}
+
+// assetFormatPreference 返回当前平台可接受的安装包后缀（按优先级排序）
+func assetFormatPreference(goos string) []string {
+	switch goos {
+	case "windows":
+		return []string{"-installer.exe", ".exe"}
+	case "darwin":
+		return []string{".app.zip"}
+	case "linux":
+		switch linuxInstallKind() {
+		case linuxInstallAppImage:
+			return []string{".appimage", ".tar.gz", ".deb", ".rpm"}
+		case linuxInstallDeb:
+			return []string{".deb", ".appimage", ".tar.gz", ".rpm"}
+		case linuxInstallRPM:
+			return []string{".rpm", ".appimage", ".tar.gz", ".deb"}
+		default:
+			return []string{".tar.gz", ".appimage", ".deb", ".rpm"}
+		}
+	default:
+		return nil
+	}
+}
+
+// selectReleaseAsset 根据操作系统、架构和格式偏好选择安装包
+// 资源命名遵循 release.yml 中的约定：intellijapp-<version>-<os>-<arch><suffix>
+func selectReleaseAsset(assets []AssetInfo, goos, goarch string, formats []string) (AssetInfo, error) {
+	arches := archAliases[goarch]
+	if len(arches) == 0 {
+		arches = []string{goarch}
+	}
+
+	for _, format := range formats {
+		for _, asset := range assets {
+			name := strings.ToLower(asset.Name)
+			if !strings.HasSuffix(name, format) {
+				continue
+			}
+			for _, arch := range arches {
+				if strings.Contains(name, "-"+goos+"-"+arch) {
+					return asset, nil
+				}
+			}
+		}
+	}
+
+	return AssetInfo{}, fmt.Errorf("%w: %s/%s", ErrNoMatchingAsset, goos, goarch)
+}
+
+// findChecksumAsset 查找 Release 中的校验和文件
+func findChecksumAsset(assets []AssetInfo) (AssetInfo, bool) {
+	for _, name := range checksumAssetNames {
+		for _, asset := range assets {
+			if strings.EqualFold(asset.Name, name) {
+				return asset, true
+			}
+		}
+	}
+	return AssetInfo{}, false
+}
+
+// parseChecksums 解析 sha256sum 格式的校验和文件
+// 每行格式为 "<hex>  <文件名>"，文件名可能带有二进制模式前缀 '*' 或相对路径前缀 './'
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		sum := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(sum); err != nil || len(sum) != sha256.Size*2 {
+			continue
+		}
+
+		name := strings.Join(fields[1:], " ")
+		name = strings.TrimPrefix(name, "*")
+		name = strings.TrimPrefix(name, "./")
+		checksums[name] = sum
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取校验和文件失败: %w", err)
+	}
+	return checksums, nil
+}
+
+// newDownloadClient 创建用于下载安装包的 HTTP 客户端
+// 下载大文件时不能使用整体超时，仅限制等待响应头的时间
+func newDownloadClient(network NetworkSettings) *http.Client {
+	transport := newTransport(network)
+	transport.ResponseHeaderTimeout = downloadHeaderTimeout
+	return &http.Client{Transport: transport}
+}
+
+// fetchChecksums 下载并解析校验和文件
+func fetchChecksums(ctx context.Context, client *http.Client, url string) (map[string]string, error) {
+	data, err := fetchSmallAsset(ctx, client, url)
+	if err != nil {
+		return nil, fmt.Errorf("下载校验和文件失败: %w", err)
+	}
+	return parseChecksums(bytes.NewReader(data))
+}
+
+// downloadFile 将 url 下载到 dest，支持断点续传
+// 未完成的数据保存在 dest.part 中，下次调用时通过 Range 请求继续下载；expected 为完整文件的 SHA-256
+func downloadFile(ctx context.Context, client *http.Client, url, dest, expected string, progress func(downloaded, total int64)) error {
+	partPath := dest + partFileSuffix
+
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("下载请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	var total int64
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+	case http.StatusOK:
+		// 服务器不支持续传，从头开始下载
+		flags |= os.O_TRUNC
+		offset = 0
+		total = resp.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 请求的起点超出文件大小，只有 .part 文件已包含完整内容时才可使用
+		if offset == 0 {
+			return fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
+		}
+		if sum, err := fileSHA256(partPath); err == nil && sum == expected {
+			return os.Rename(partPath, dest)
+		}
+		// .part 文件已损坏或来自其他文件，删除后从头下载
+		resp.Body.Close()
+		if err := os.Remove(partPath); err != nil {
+			return fmt.Errorf("删除损坏的下载文件失败: %w", err)
+		}
+		return downloadFile(ctx, client, url, dest, expected, progress)
+	default:
+		return fmt.Errorf("下载失败，状态码: %d", resp.StatusCode)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("创建下载文件失败: %w", err)
+	}
+
+	downloaded := offset
+	lastEmit := time.Time{}
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			if _, err := file.Write(buf[:n]); err != nil {
+				file.Close()
+				return fmt.Errorf("写入下载文件失败: %w", err)
+			}
+			downloaded += int64(n)
+			if progress != nil && time.Since(lastEmit) >= progressEmitInterval {
+				progress(downloaded, total)
+				lastEmit = time.Now()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			file.Close()
+			return fmt.Errorf("下载中断（已保存 %d 字节，可续传）: %w", downloaded, readErr)
+		}
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("保存下载文件失败: %w", err)
+	}
+	if progress != nil {
+		progress(downloaded, total)
+	}
+
+	return os.Rename(partPath, dest)
+}
+
+// fileSHA256 计算文件的 SHA-256 校验和
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// updateDownloadDir 返回指定版本安装包的缓存目录
+func updateDownloadDir(version string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("无法获取缓存目录: %w", err)
+	}
+	return filepath.Join(cacheDir, appDataDirName, downloadDirName, version), nil
+}
+
+// DownloadUpdate 下载最新 Release 中适用于当前平台的安装包，并按签名清单校验 SHA-256
+// 下载过程通过 EventUpdateDownloadProgress 事件推送进度，中断后再次调用会自动续传
+func (c *ConfigService) DownloadUpdate() (DownloadResult, error) {
+	if !c.downloadMu.TryLock() {
+		return DownloadResult{}, ErrDownloadInProgress
+	}
+	defer c.downloadMu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.setDownloadCancel(cancel)
+	defer func() {
+		cancel()
+		c.setDownloadCancel(nil)
+	}()
+
+	release, err := fetchLatestRelease(c.httpClient(), c.rankedAPIMirrors(), c.logger)
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	if release == nil {
+		return DownloadResult{}, ErrNoReleaseAvailable
+	}
+	// 只下载比当前版本新的 Release，防止被回滚到旧版本
+	if compareVersions(release.Version, Version) <= 0 {
+		c.logger.Warn("最新 Release 不比当前版本新，拒绝下载",
+			slog.String("latestVersion", release.Version),
+			slog.String("currentVersion", Version))
+		return DownloadResult{}, fmt.Errorf("%w: 最新版本 %s，当前版本 %s", ErrNoNewerRelease, release.Version, Version)
+	}
+
+	return c.downloadReleaseAsset(ctx, release)
+}
+
+// CancelUpdateDownload 取消正在进行的下载，已下载的部分会保留用于续传
+func (c *ConfigService) CancelUpdateDownload() {
+	c.cancelMu.Lock()
+	cancel := c.downloadCancel
+	c.cancelMu.Unlock()
+
+	if cancel != nil {
+		c.logger.Info("取消下载更新")
+		cancel()
+	}
+}
+
+// setDownloadCancel 记录当前下载任务的取消函数
+func (c *ConfigService) setDownloadCancel(cancel context.CancelFunc) {
+	c.cancelMu.Lock()
+	c.downloadCancel = cancel
+	c.cancelMu.Unlock()
+}
+
+// downloadReleaseAsset 下载并校验 Release 中适用于当前平台的安装包
+func (c *ConfigService) downloadReleaseAsset(ctx context.Context, release *ReleaseInfo) (DownloadResult, error) {
+	asset, err := selectReleaseAsset(release.Assets, runtime.GOOS, runtime.GOARCH, assetFormatPreference(runtime.GOOS))
+	if err != nil {
+		c.logger.Error("选择安装包失败", slog.Any("error", err))
+		return DownloadResult{}, err
+	}
+
+	// 安装包哈希以签名清单为准，API 和镜像返回的内容均不可信
+	client := newDownloadClient(c.networkSettings())
+	manifest, err := fetchSignedManifest(ctx, client, release, c.ConvertToAccessibleURL)
+	if err != nil {
+		c.logger.Error("Release 清单验证失败", slog.Any("error", err))
+		return DownloadResult{}, err
+	}
+
+	signed, ok := manifest.asset(asset.Name)
+	if !ok {
+		return DownloadResult{}, fmt.Errorf("%w: 清单中不包含 %s", ErrManifestMissing, asset.Name)
+	}
+	expected := signed.SHA256
+
+	// checksums.txt 仅作为一致性检查，与签名清单不一致说明 Release 已被篡改
+	if checksumAsset, ok := findChecksumAsset(release.Assets); ok {
+		checksums, err := fetchChecksums(ctx, client, c.ConvertToAccessibleURL(checksumAsset.DownloadURL))
+		if err != nil {
+			c.logger.Warn("获取校验和文件失败，仅使用签名清单", slog.Any("error", err))
+		} else if sum, ok := checksums[asset.Name]; ok && sum != expected {
+			return DownloadResult{}, fmt.Errorf("%w: checksums.txt 与签名清单不一致", ErrChecksumMismatch)
+		}
+	}
+
+	dir, err := updateDownloadDir(release.Version)
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return DownloadResult{}, fmt.Errorf("创建下载目录失败: %w", err)
+	}
+	dest := filepath.Join(dir, asset.Name)
+
+	// 已下载且校验通过的文件无需重复下载
+	if sum, err := fileSHA256(dest); err == nil && sum == expected {
+		c.logger.Info("安装包已存在且校验通过", slog.String("file", dest))
+		return c.newDownloadResult(release.Version, asset, dest, sum)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return DownloadResult{}, fmt.Errorf("读取已下载文件失败: %w", err)
+	}
+
+	c.logger.Info("开始下载安装包",
+		slog.String("asset", asset.Name),
+		slog.Int64("size", asset.Size))
+
+	progress := func(downloaded, total int64) {
+		if total <= 0 {
+			total = asset.Size
+		}
+		var percent float64
+		if total > 0 {
+			percent = float64(downloaded) * 100 / float64(total)
+		}
+		c.emit(EventUpdateDownloadProgress, DownloadProgress{
+			AssetName:  asset.Name,
+			Downloaded: downloaded,
+			Total:      total,
+			Percent:    percent,
+		})
+	}
+
+	if err := downloadFile(ctx, client, c.ConvertToAccessibleURL(asset.DownloadURL), dest, expected, progress); err != nil {
+		c.logger.Error("下载安装包失败", slog.Any("error", err))
+		return DownloadResult{}, err
+	}
+
+	sum, err := fileSHA256(dest)
+	if err != nil {
+		return DownloadResult{}, fmt.Errorf("计算校验和失败: %w", err)
+	}
+	var size int64
+	if info, err := os.Stat(dest); err == nil {
+		size = info.Size()
+	}
+	if sum != expected || (signed.Size > 0 && size != signed.Size) {
+		// 删除与签名清单不符的文件（镜像被篡改或下载损坏），避免下次被误认为已下载
+		_ = os.Remove(dest)
+		c.logger.Error("安装包校验失败",
+			slog.String("expected", expected),
+			slog.String("actual", sum))
+		return DownloadResult{}, fmt.Errorf("%w: %s", ErrChecksumMismatch, asset.Name)
+	}
+
+	c.logger.Info("安装包下载并校验完成", slog.String("file", dest))
+	return c.newDownloadResult(release.Version, asset, dest, sum)
+}
+
+// newDownloadResult 构造下载结果
+func (c *ConfigService) newDownloadResult(version string, asset AssetInfo, path, sum string) (DownloadResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return DownloadResult{}, fmt.Errorf("无法访问下载文件: %w", err)
+	}
+	return DownloadResult{
+		Version:   version,
+		AssetName: asset.Name,
+		FilePath:  path,
+		Size:      info.Size(),
+		SHA256:    sum,
+		Verified:  true,
+	}, nil
+}
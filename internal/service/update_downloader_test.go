@@ -0,0 +1,175 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestSelectReleaseAsset 测试按平台选择安装包
+func TestSelectReleaseAsset(t *testing.T) {
+	assets := []AssetInfo{
+		{Name: "checksums.txt"},
+		{Name: "intellijapp-2.1.0-windows-amd64-installer.exe"},
+		{Name: "intellijapp-2.1.0-windows-amd64.exe"},
+		{Name: "intellijapp-2.1.0-darwin-arm64.app.zip"},
+		{Name: "intellijapp-2.1.0-linux-amd64.AppImage"},
+		{Name: "intellijapp-2.1.0-linux-amd64.deb"},
+		{Name: "intellijapp-2.1.0-linux-amd64.tar.gz"},
+	}
+
+	tests := []struct {
+		name     string
+		goos     string
+		goarch   string
+		formats  []string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "Windows 优先选择安装程序",
+			goos:     "windows",
+			goarch:   "amd64",
+			formats:  []string{"-installer.exe", ".exe"},
+			expected: "intellijapp-2.1.0-windows-amd64-installer.exe",
+		},
+		{
+			name:     "Linux AppImage",
+			goos:     "linux",
+			goarch:   "amd64",
+			formats:  []string{".appimage", ".tar.gz"},
+			expected: "intellijapp-2.1.0-linux-amd64.AppImage",
+		},
+		{
+			name:     "Linux 偏好格式不存在时回退",
+			goos:     "linux",
+			goarch:   "amd64",
+			formats:  []string{".rpm", ".tar.gz"},
+			expected: "intellijapp-2.1.0-linux-amd64.tar.gz",
+		},
+		{
+			name:    "没有匹配的架构",
+			goos:    "darwin",
+			goarch:  "amd64",
+			formats: []string{".app.zip"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, err := selectReleaseAsset(assets, tt.goos, tt.goarch, tt.formats)
+			if tt.wantErr {
+				if !errors.Is(err, ErrNoMatchingAsset) {
+					t.Errorf("期望 ErrNoMatchingAsset，实际: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("不期望错误: %v", err)
+			}
+			if asset.Name != tt.expected {
+				t.Errorf("选择了 %s，期望 %s", asset.Name, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParseChecksums 测试解析 sha256sum 格式的校验和文件
+func TestParseChecksums(t *testing.T) {
+	sum := strings.Repeat("a", 64)
+	input := "# comment\n" +
+		sum + "  intellijapp-linux-amd64.AppImage\n" +
+		strings.Repeat("B", 64) + " *intellijapp-windows-amd64.exe\n" +
+		sum + "  ./intellijapp-darwin-arm64.app.zip\n" +
+		"invalid line\n" +
+		"xyz  not-a-hash.bin\n"
+
+	checksums, err := parseChecksums(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if len(checksums) != 3 {
+		t.Fatalf("期望 3 条记录，实际 %d 条: %v", len(checksums), checksums)
+	}
+	if checksums["intellijapp-windows-amd64.exe"] != strings.Repeat("b", 64) {
+		t.Error("二进制模式前缀或大小写未正确处理")
+	}
+	if _, ok := checksums["intellijapp-darwin-arm64.app.zip"]; !ok {
+		t.Error("相对路径前缀未正确处理")
+	}
+}
+
+// TestDownloadFileResume 测试断点续传
+func TestDownloadFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "asset.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "asset.bin")
+
+	// 模拟上次下载中断留下的部分文件
+	if err := os.WriteFile(dest+partFileSuffix, content[:4096], 0644); err != nil {
+		t.Fatalf("无法创建部分文件: %v", err)
+	}
+
+	var lastDownloaded, lastTotal int64
+	progress := func(downloaded, total int64) {
+		lastDownloaded, lastTotal = downloaded, total
+	}
+
+	sum := sha256.Sum256(content)
+	expected := hex.EncodeToString(sum[:])
+	if err := downloadFile(context.Background(), server.Client(), server.URL, dest, expected, progress); err != nil {
+		t.Fatalf("下载失败: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatalf("无法读取下载文件: %v", err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Error("续传后的文件内容不一致")
+	}
+	if lastDownloaded != int64(len(content)) || lastTotal != int64(len(content)) {
+		t.Errorf("进度不正确: %d/%d", lastDownloaded, lastTotal)
+	}
+	if _, err := os.Stat(dest + partFileSuffix); !os.IsNotExist(err) {
+		t.Error("下载完成后 .part 文件应被移除")
+	}
+
+	// .part 不短于完整文件时服务器返回 416，内容不符时应从头下载
+	corrupt := bytes.Repeat([]byte("x"), len(content))
+	if err := os.WriteFile(dest+partFileSuffix, corrupt, 0644); err != nil {
+		t.Fatalf("无法创建部分文件: %v", err)
+	}
+	_ = os.Remove(dest)
+	if err := downloadFile(context.Background(), server.Client(), server.URL, dest, expected, nil); err != nil {
+		t.Fatalf("重新下载失败: %v", err)
+	}
+	if got, _ := os.ReadFile(dest); !bytes.Equal(got, content) {
+		t.Error("损坏的 .part 文件应被丢弃并重新下载")
+	}
+
+	// 416 且没有 .part 文件时不应生成空文件
+	missing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer missing.Close()
+	other := filepath.Join(t.TempDir(), "asset.bin")
+	if err := downloadFile(context.Background(), missing.Client(), missing.URL, other, expected, nil); err == nil {
+		t.Error("没有 .part 文件时收到 416 应报错")
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	// watcherDebounce IDE 更新会连续写入多个文件，最后一次变化后等待该时长再检查
+	watcherDebounce = 2 * time.Second
+	// watcherPollInterval 轮询检查间隔，文件通知可用时也按该间隔同步监视的目录
+	watcherPollInterval = 30 * time.Second
+)
+
+// ManagedOptionsLost 通过 EventManagedOptionsLost 事件通知前端配置被 IDE 更新覆盖
+type ManagedOptionsLost struct {
+	InstallationID  string            `json:"installationId"`
+	Label           string            `json:"label"`
+	Path            string            `json:"path"`
+	Version         string            `json:"version"`
+	Reapplied       bool              `json:"reapplied"`
+	Files           int               `json:"files"`
+	Error           string            `json:"error,omitempty"`
+	VerifyNotice    string            `json:"verifyNotice,omitempty"`
+	RestartRequired []RunningInstance `json:"restartRequired,omitempty"`
+}
+
+// vmOptionsWatcher 保存运行中的后台监视任务
+type vmOptionsWatcher struct {
+	settings WatcherSettings
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// managedBinDirs 返回已应用配置的安装的 bin 目录
+func (c *ConfigService) managedBinDirs() []string {
+	var dirs []string
+	for _, inst := range c.currentSettings().Installations {
+		if inst.ManagedConfigPath == "" {
+			continue
+		}
+		if binDir, err := validateIntelliJPath(inst.Path); err == nil {
+			dirs = append(dirs, binDir)
+		}
+	}
+	return dirs
+}
+
+// checkManagedOptions 检查已应用配置的安装是否丢失了本工具添加的配置
+// reported 记录已通知过的安装，避免同一次覆盖重复通知；配置恢复后从中移除
+func (c *ConfigService) checkManagedOptions(autoReapply bool, reported map[string]bool) []ManagedOptionsLost {
+	var lost []ManagedOptionsLost
+	for _, inst := range c.currentSettings().Installations {
+		if inst.ManagedConfigPath == "" {
+			continue
+		}
+		if event, ok := c.checkInstallationOptions(inst.ID, autoReapply, reported); ok {
+			c.emit(EventManagedOptionsLost, event)
+			lost = append(lost, event)
+		}
+	}
+	return lost
+}
+
+// checkInstallationOptions 在安装锁内检查并按需重新应用单个安装的配置
+// 加锁后重新读取安装记录，用户刚刚清除或重新应用配置时不会被后台任务覆盖
+func (c *ConfigService) checkInstallationOptions(id string, autoReapply bool, reported map[string]bool) (ManagedOptionsLost, bool) {
+	inst, err := c.findInstallation(id)
+	if err != nil {
+		return ManagedOptionsLost{}, false
+	}
+	defer c.lockInstallation(inst.Path)()
+	if inst, err = c.findInstallation(id); err != nil || inst.ManagedConfigPath == "" {
+		return ManagedOptionsLost{}, false
+	}
+
+	binDir, err := validateIntelliJPath(inst.Path)
+	if err != nil {
+		return ManagedOptionsLost{}, false
+	}
+	managed, err := hasManagedVMOptions(binDir)
+	if err != nil || managed {
+		delete(reported, inst.ID)
+		return ManagedOptionsLost{}, false
+	}
+	if reported[inst.ID] {
+		return ManagedOptionsLost{}, false
+	}
+
+	event := ManagedOptionsLost{InstallationID: inst.ID, Label: inst.Label, Path: inst.Path}
+	if info, err := readProductInfo(inst.Path); err == nil {
+		event.Version = info.Version
+	}
+	c.logger.Warn("检测到 vmoptions 配置被覆盖", slog.String("path", inst.Path), slog.String("version", event.Version))
+
+	if autoReapply {
+		// 写入前会备份 IDE 更新后的 vmoptions 文件
+		count, notice, err := c.applyManagedConfigLocked(inst.Path, inst.ManagedConfigPath, "重新应用")
+		if err != nil {
+			event.Error = err.Error()
+		} else {
+			event.Reapplied = true
+			event.Files = count
+			event.VerifyNotice = notice
+			_, event.RestartRequired = restartNotice(installationHome(inst.Path))
+			c.logger.Info("已重新应用配置", slog.String("path", inst.Path), slog.Int("files", count))
+		}
+	}
+	if !event.Reapplied {
+		c.markVMOptionsReset(inst.ID)
+		reported[inst.ID] = true
+	}
+	return event, true
+}
+
+// markVMOptionsReset 在安装记录中标记配置已被覆盖，前端据此提示重新应用
+func (c *ConfigService) markVMOptionsReset(id string) {
+	if _, err := c.updateSettings(func(s *AppSettings) error {
+		for i := range s.Installations {
+			if s.Installations[i].ID == id {
+				s.Installations[i].VMOptionsReset = true
+			}
+		}
+		return nil
+	}); err != nil {
+		c.logger.Warn("保存安装状态失败", slog.Any("error", err))
+	}
+}
+
+// pollLoop 按固定间隔检查，用于不支持文件通知的平台
+func (c *ConfigService) pollLoop(settings WatcherSettings, stop <-chan struct{}) {
+	reported := make(map[string]bool)
+	c.checkManagedOptions(settings.AutoReapply, reported)
+
+	ticker := time.NewTicker(watcherPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			c.checkManagedOptions(settings.AutoReapply, reported)
+		}
+	}
+}
+
+// configureWatcher 按设置启动、重启或停止后台监视
+func (c *ConfigService) configureWatcher(settings WatcherSettings) {
+	c.watcherMu.Lock()
+	defer c.watcherMu.Unlock()
+
+	if c.watcher != nil {
+		if settings.Enabled && c.watcher.settings == settings {
+			return
+		}
+		close(c.watcher.stop)
+		<-c.watcher.done
+		c.watcher = nil
+		c.logger.Info("vmoptions 监视已停止")
+	}
+	if !settings.Enabled {
+		return
+	}
+
+	w := &vmOptionsWatcher{settings: settings, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		c.watchLoop(settings, w.stop)
+	}()
+	c.watcher = w
+	c.logger.Info("vmoptions 监视已启动", slog.Bool("autoReapply", settings.AutoReapply))
+}
+
+// StartWatcher 按当前设置启动后台监视，未启用时不执行任何操作
+func (c *ConfigService) StartWatcher() {
+	c.configureWatcher(c.currentSettings().Watcher)
+}
+
+// StopWatcher 停止后台监视，应用退出时调用
+func (c *ConfigService) StopWatcher() {
+	c.configureWatcher(WatcherSettings{})
+}
@@ -0,0 +1,75 @@
+//go:build linux
+// +build linux
+
+package service
+
+import (
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchLoop 通过 inotify 监视已应用配置的 bin 目录，vmoptions 变化后延迟检查
+// IDE 更新通常以重命名方式替换文件，因此监视目录而不是单个文件
+func (c *ConfigService) watchLoop(settings WatcherSettings, stop <-chan struct{}) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		c.logger.Warn("无法创建文件监视，改用轮询", slog.Any("error", err))
+		c.pollLoop(settings, stop)
+		return
+	}
+	defer watcher.Close()
+
+	syncDirs := func() {
+		dirs := c.managedBinDirs()
+		for _, dir := range watcher.WatchList() {
+			if !slices.Contains(dirs, dir) {
+				_ = watcher.Remove(dir)
+			}
+		}
+		for _, dir := range dirs {
+			if err := watcher.Add(dir); err != nil {
+				c.logger.Warn("监视目录失败", slog.String("dir", dir), slog.Any("error", err))
+			}
+		}
+	}
+
+	reported := make(map[string]bool)
+	syncDirs()
+	c.checkManagedOptions(settings.AutoReapply, reported)
+
+	debounce := time.NewTimer(watcherDebounce)
+	debounce.Stop()
+	resync := time.NewTicker(watcherPollInterval)
+	defer resync.Stop()
+
+	for {
+		select {
+		case <-stop:
+			debounce.Stop()
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if strings.EqualFold(filepath.Ext(event.Name), ".vmoptions") {
+				debounce.Reset(watcherDebounce)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			c.logger.Warn("文件监视出错", slog.Any("error", err))
+		case <-debounce.C:
+			c.checkManagedOptions(settings.AutoReapply, reported)
+		case <-resync.C:
+			// 安装列表可能已变化，同时兜底检查未触发通知的修改
+			syncDirs()
+			c.checkManagedOptions(settings.AutoReapply, reported)
+		}
+	}
+}
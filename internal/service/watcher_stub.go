@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package service
+
+// watchLoop 非 Linux 平台使用轮询检查 vmoptions 文件
+func (c *ConfigService) watchLoop(settings WatcherSettings, stop <-chan struct{}) {
+	c.pollLoop(settings, stop)
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"embed"
+	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
+	"sync/atomic"
 
 	"github.com/XgzK/intellijapp/internal/service"
 	"github.com/wailsapp/wails/v3/pkg/application"
+	"github.com/wailsapp/wails/v3/pkg/events"
 )
 
 // Wails 使用 Go 的 `embed` 包将前端文件嵌入到二进制文件中
@@ -21,16 +25,48 @@ var assets embed.FS
 // 然后运行应用程序并记录可能发生的任何错误
 func main() {
 
+	// 自更新时旧版本会以健康检查参数运行新版本，自检通过后直接退出
+	if len(os.Args) > 1 && os.Args[1] == service.HealthCheckArg {
+		os.Exit(runHealthCheck())
+	}
+
+	// 处理上一次自更新：新版本多次启动失败时回滚到旧版本
+	service.CheckPendingUpdate(slog.Default())
+
 	// 通过提供必要的选项创建一个新的 Wails 应用程序
 	// 变量 'Name' 和 'Description' 用于应用程序元数据
 	// 'Assets' 配置资产服务器，'FS' 变量指向前端文件
 	// 'Services' 是 Go 服务实例列表，前端可以访问这些实例的方法
 	// 'Mac' 选项用于在 macOS 上运行应用程序时进行定制
+	// 服务通过事件推送函数向前端发送进度等通知，推送函数在应用创建后才可用
+	// 服务可能在 application.New 返回前从其他 goroutine 推送事件，因此通过原子指针读取应用
+	var current atomic.Pointer[application.App]
+	emitter := func(name string, data any) {
+		if app := current.Load(); app != nil {
+			app.Event.Emit(name, data)
+		}
+	}
+
+	// 日志写入平台的状态目录，无法确定目录时只输出到标准错误
+	// 自更新启动新版本后通过 app.Quit 退出，使 OnShutdown 和 ServiceShutdown 正常执行
+	quit := func() {
+		if app := current.Load(); app != nil {
+			app.Quit()
+		}
+	}
+	serviceOptions := []service.ServiceOption{service.WithEventEmitter(emitter), service.WithQuitFunc(quit)}
+	if logDir, err := service.DefaultLogDir(); err == nil {
+		serviceOptions = append(serviceOptions, service.WithLogDir(logDir))
+	} else {
+		slog.Warn("无法确定日志目录", slog.Any("error", err))
+	}
+	configService := service.NewConfigService(serviceOptions...)
+
 	app := application.New(application.Options{
 		Name:        "IntelliJ",
 		Description: "IntelliJ Configuration Helper",
 		Services: []application.Service{
-			application.NewService(service.NewConfigService()),
+			application.NewService(configService),
 		},
 		Assets: application.AssetOptions{
 			Handler: application.AssetFileServerFS(assets),
@@ -38,8 +74,12 @@ func main() {
 		Mac: application.MacOptions{
 			ApplicationShouldTerminateAfterLastWindowClosed: true,
 		},
+		// 退出前停止后台监视，Wails 随后调用 ConfigService.ServiceShutdown 关闭日志文件
+		OnShutdown: configService.StopWatcher,
 	})
 
+	current.Store(app)
+
 	// 使用必要的选项创建一个新窗口
 	// 'Title' 是窗口的标题
 	// 'Mac' 选项用于在 macOS 上运行时定制窗口
@@ -57,6 +97,12 @@ func main() {
 		URL:              "/",
 	})
 
+	// 应用成功启动后确认自更新，删除旧版本备份，并按设置启动 vmoptions 后台监视
+	app.Event.OnApplicationEvent(events.Common.ApplicationStarted, func(*application.ApplicationEvent) {
+		service.ConfirmPendingUpdate(slog.Default())
+		configService.StartWatcher()
+	})
+
 	// 运行应用程序。这会阻塞直到应用程序退出
 	err := app.Run()
 
@@ -66,3 +112,13 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// runHealthCheck 执行最小化自检：确认嵌入的前端资源完整，返回进程退出码
+func runHealthCheck() int {
+	if _, err := fs.Stat(assets, "frontend/dist/index.html"); err != nil {
+		fmt.Fprintln(os.Stderr, "前端资源缺失:", err)
+		return 1
+	}
+	fmt.Println(service.AppName, service.Version)
+	return 0
+}